@@ -0,0 +1,573 @@
+// Package client is the Go client of the Metrigo gRPC service.
+//
+// It wraps the generated pb package with typed results, connection options and helpers
+// for one-off snapshots and periodic watches of the generic collectors:
+//
+//	c, err := client.New("localhost:50051", client.WithToken(token), client.WithRetry(client.DefaultRetryPolicy))
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//	memoryUsage, err := c.MemoryUsage(ctx)
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/Matyjash/Metrigo/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type Client struct {
+	conn *grpc.ClientConn
+	rpc  pb.MetrigoClient
+}
+
+// New creates a client of the Metrigo server at target, e.g. "localhost:50051".
+// The connection is established lazily on the first call.
+func New(target string, opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	dialOptions := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if o.tls != nil {
+		dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(o.tls))}
+	}
+	if o.token != "" {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(tokenCredentials{token: o.token}))
+	}
+	if o.retry != nil {
+		dialOptions = append(dialOptions, grpc.WithUnaryInterceptor(retryInterceptor(*o.retry)))
+	}
+	if o.keepalive != nil {
+		dialOptions = append(dialOptions, grpc.WithKeepaliveParams(*o.keepalive))
+	}
+	dialOptions = append(dialOptions, o.dialOptions...)
+
+	conn, err := grpc.NewClient(target, dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection: %v", err)
+	}
+	return &Client{conn: conn, rpc: pb.NewMetrigoClient(conn)}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) MemoryUsage(ctx context.Context) (MemoryUsage, error) {
+	res, err := c.rpc.GetMemoryUsage(ctx, &pb.MemoryUsageReq{})
+	if err != nil {
+		return MemoryUsage{}, err
+	}
+	return MemoryUsage{UsedB: res.UsedB, TotalB: res.TotalB}, nil
+}
+
+func (c *Client) CpuInfo(ctx context.Context) ([]CpuInfo, error) {
+	res, err := c.rpc.GetCpuInfo(ctx, &pb.CpuInfoReq{})
+	if err != nil {
+		return nil, err
+	}
+	cpuInfo := make([]CpuInfo, len(res.CpuInfo))
+	for i, info := range res.CpuInfo {
+		cpuInfo[i] = CpuInfo{
+			ID:           info.Id,
+			UsagePercent: float64(info.UsagePercent),
+			Times:        cpuTimes(info.Times),
+			CpuSpec:      CpuSpec{FrequencyMhz: float64(info.Frequency), SocketID: info.SocketId, CoreID: info.CoreId},
+		}
+	}
+	return cpuInfo, nil
+}
+
+func (c *Client) CpuStats(ctx context.Context) (CpuStats, error) {
+	res, err := c.rpc.GetCpuStats(ctx, &pb.CpuStatsReq{})
+	if err != nil {
+		return CpuStats{}, err
+	}
+	perCpu := make([]CpuTimes, len(res.PerCpu))
+	for i, times := range res.PerCpu {
+		perCpu[i] = cpuTimes(times)
+	}
+	return CpuStats{
+		Total:                 cpuTimes(res.Total),
+		PerCpu:                perCpu,
+		ContextSwitchesPerSec: res.ContextSwitchesPerSec,
+		InterruptsPerSec:      res.InterruptsPerSec,
+	}, nil
+}
+
+func (c *Client) CpuTopology(ctx context.Context) (CpuTopology, error) {
+	res, err := c.rpc.GetCpuTopology(ctx, &pb.CpuTopologyReq{})
+	if err != nil {
+		return CpuTopology{}, err
+	}
+	sockets := make([]CpuSocket, len(res.Sockets))
+	for i, socket := range res.Sockets {
+		cores := make([]CpuCore, len(socket.Cores))
+		for j, core := range socket.Cores {
+			cores[j] = CpuCore{ID: core.Id, Cpus: core.Cpus}
+		}
+		sockets[i] = CpuSocket{
+			ID:          socket.Id,
+			VendorID:    socket.VendorId,
+			ModelName:   socket.ModelName,
+			Family:      socket.Family,
+			Model:       socket.Model,
+			Stepping:    socket.Stepping,
+			CacheSizeKB: socket.CacheSizeKB,
+			Flags:       socket.Flags,
+			Cores:       cores,
+		}
+	}
+	return CpuTopology{
+		PhysicalCores: int(res.PhysicalCores),
+		LogicalCpus:   int(res.LogicalCpus),
+		Sockets:       sockets,
+	}, nil
+}
+
+func (c *Client) CpuFrequencies(ctx context.Context) ([]CpuFrequency, error) {
+	res, err := c.rpc.GetCpuFrequencies(ctx, &pb.CpuFrequenciesReq{})
+	if err != nil {
+		return nil, err
+	}
+	frequencies := make([]CpuFrequency, len(res.Frequencies))
+	for i, frequency := range res.Frequencies {
+		frequencies[i] = CpuFrequency{
+			Cpu:                         frequency.Cpu,
+			CurrentMhz:                  frequency.CurrentMhz,
+			MinMhz:                      frequency.MinMhz,
+			MaxMhz:                      frequency.MaxMhz,
+			HardwareMinMhz:              frequency.HardwareMinMhz,
+			HardwareMaxMhz:              frequency.HardwareMaxMhz,
+			Governor:                    frequency.Governor,
+			EnergyPerformancePreference: frequency.EnergyPerformancePreference,
+			CoreThrottleCount:           frequency.CoreThrottleCount,
+			PackageThrottleCount:        frequency.PackageThrottleCount,
+		}
+	}
+	return frequencies, nil
+}
+
+func cpuTimes(times *pb.CpuTimes) CpuTimes {
+	return CpuTimes{
+		User:      times.GetUser(),
+		System:    times.GetSystem(),
+		Idle:      times.GetIdle(),
+		Nice:      times.GetNice(),
+		Iowait:    times.GetIowait(),
+		Irq:       times.GetIrq(),
+		Softirq:   times.GetSoftirq(),
+		Steal:     times.GetSteal(),
+		Guest:     times.GetGuest(),
+		GuestNice: times.GetGuestNice(),
+	}
+}
+
+func (c *Client) Temperatures(ctx context.Context) ([]TemperatureSensor, error) {
+	res, err := c.rpc.GetTemperatures(ctx, &pb.TemperatureReq{})
+	if err != nil {
+		return nil, err
+	}
+	temperatures := make([]TemperatureSensor, len(res.Sensors))
+	for i, sensor := range res.Sensors {
+		temperatures[i] = TemperatureSensor{
+			Key:      sensor.Key,
+			Chip:     sensor.Chip,
+			Value:    float64(sensor.Value),
+			High:     float64(sensor.High),
+			Critical: float64(sensor.Critical),
+			Status:   sensor.Status,
+		}
+	}
+	return temperatures, nil
+}
+
+func (c *Client) HostInfo(ctx context.Context) (HostInfo, error) {
+	res, err := c.rpc.GetHostInfo(ctx, &pb.HostInfoReq{})
+	if err != nil {
+		return HostInfo{}, err
+	}
+	var users []UserSession
+	for _, session := range res.Users {
+		users = append(users, UserSession{
+			User:     session.User,
+			Terminal: session.Terminal,
+			Host:     session.Host,
+			Started:  session.Started,
+		})
+	}
+	return HostInfo{
+		Hostname:             res.Hostname,
+		OS:                   res.Os,
+		Platform:             res.Platform,
+		PlatformVersion:      res.PlatformVersion,
+		Uptime:               res.Uptime,
+		KernelVersion:        res.KernelVersion,
+		KernelArch:           res.KernelArch,
+		BootTime:             res.BootTime,
+		VirtualizationSystem: res.VirtualizationSystem,
+		VirtualizationRole:   res.VirtualizationRole,
+		HostID:               res.HostId,
+		Procs:                res.Procs,
+		Users:                users,
+		Timezone:             res.Timezone,
+	}, nil
+}
+
+func (c *Client) NetInterfaces(ctx context.Context) ([]NetInterface, error) {
+	res, err := c.rpc.GetNetInfo(ctx, &pb.NetInfoReq{})
+	if err != nil {
+		return nil, err
+	}
+	netInterfaces := make([]NetInterface, len(res.Interfaces))
+	for i, iface := range res.Interfaces {
+		netInterfaces[i] = NetInterface{
+			Name:       iface.Name,
+			Index:      int(iface.Index),
+			Addressess: iface.Addresses,
+			MTU:        int(iface.MTU),
+		}
+	}
+	return netInterfaces, nil
+}
+
+func (c *Client) Containers(ctx context.Context) ([]ContainerStats, error) {
+	res, err := c.rpc.GetContainers(ctx, &pb.ContainersReq{})
+	if err != nil {
+		return nil, err
+	}
+	containers := make([]ContainerStats, len(res.Containers))
+	for i, container := range res.Containers {
+		containers[i] = ContainerStats{
+			ID:           container.Id,
+			Name:         container.Name,
+			Image:        container.Image,
+			State:        container.State,
+			Labels:       container.Labels,
+			CpuPercent:   container.CpuPercent,
+			MemoryUsageB: container.MemoryUsageB,
+			MemoryLimitB: container.MemoryLimitB,
+			NetRxB:       container.NetRxB,
+			NetTxB:       container.NetTxB,
+			BlockReadB:   container.BlockReadB,
+			BlockWriteB:  container.BlockWriteB,
+		}
+	}
+	return containers, nil
+}
+
+func (c *Client) HwmonSensors(ctx context.Context) ([]HwmonSensor, error) {
+	res, err := c.rpc.GetHwmonSensors(ctx, &pb.HwmonSensorsReq{})
+	if err != nil {
+		return nil, err
+	}
+	sensors := make([]HwmonSensor, len(res.Sensors))
+	for i, sensor := range res.Sensors {
+		sensors[i] = HwmonSensor{
+			Chip:   sensor.Chip,
+			Sensor: sensor.Sensor,
+			Label:  sensor.Label,
+			Type:   sensor.Type,
+			Unit:   sensor.Unit,
+			Value:  sensor.Value,
+			Min:    sensor.Min,
+			Max:    sensor.Max,
+			Crit:   sensor.Crit,
+			Alarm:  sensor.Alarm,
+		}
+	}
+	return sensors, nil
+}
+
+func (c *Client) Pressure(ctx context.Context) ([]PressureStats, error) {
+	res, err := c.rpc.GetPressure(ctx, &pb.PressureReq{})
+	if err != nil {
+		return nil, err
+	}
+	pressure := make([]PressureStats, len(res.Scopes))
+	for i, scope := range res.Scopes {
+		resources := make([]ResourcePressure, len(scope.Resources))
+		for j, resource := range scope.Resources {
+			resources[j] = ResourcePressure{
+				Resource: resource.Resource,
+				Some:     pressureStall(resource.Some),
+				Full:     pressureStall(resource.Full),
+				HasFull:  resource.Full != nil,
+			}
+		}
+		pressure[i] = PressureStats{Cgroup: scope.Cgroup, Resources: resources}
+	}
+	return pressure, nil
+}
+
+func pressureStall(stall *pb.PressureStall) PressureStall {
+	return PressureStall{
+		Avg10:        stall.GetAvg10(),
+		Avg60:        stall.GetAvg60(),
+		Avg300:       stall.GetAvg300(),
+		TotalSeconds: stall.GetTotalSeconds(),
+	}
+}
+
+func (c *Client) SocketStats(ctx context.Context) (SocketStats, error) {
+	res, err := c.rpc.GetSocketStats(ctx, &pb.SocketStatsReq{})
+	if err != nil {
+		return SocketStats{}, err
+	}
+	connections := make([]Connection, len(res.Connections))
+	for i, conn := range res.Connections {
+		connections[i] = Connection{
+			Protocol:   conn.Protocol,
+			LocalAddr:  conn.LocalAddr,
+			LocalPort:  conn.LocalPort,
+			RemoteAddr: conn.RemoteAddr,
+			RemotePort: conn.RemotePort,
+			State:      conn.State,
+			Pid:        conn.Pid,
+		}
+	}
+	counters := res.GetCounters()
+	return SocketStats{
+		Connections: connections,
+		HasCounters: counters != nil,
+		Counters: ProtocolCounters{
+			TcpActiveOpens:     counters.GetTcpActiveOpens(),
+			TcpPassiveOpens:    counters.GetTcpPassiveOpens(),
+			TcpAttemptFails:    counters.GetTcpAttemptFails(),
+			TcpEstabResets:     counters.GetTcpEstabResets(),
+			TcpCurrEstab:       counters.GetTcpCurrEstab(),
+			TcpInSegs:          counters.GetTcpInSegs(),
+			TcpOutSegs:         counters.GetTcpOutSegs(),
+			TcpRetransSegs:     counters.GetTcpRetransSegs(),
+			TcpInErrs:          counters.GetTcpInErrs(),
+			TcpOutRsts:         counters.GetTcpOutRsts(),
+			TcpListenOverflows: counters.GetTcpListenOverflows(),
+			TcpListenDrops:     counters.GetTcpListenDrops(),
+			UdpInDatagrams:     counters.GetUdpInDatagrams(),
+			UdpOutDatagrams:    counters.GetUdpOutDatagrams(),
+			UdpNoPorts:         counters.GetUdpNoPorts(),
+			UdpInErrors:        counters.GetUdpInErrors(),
+			UdpRcvbufErrors:    counters.GetUdpRcvbufErrors(),
+			UdpSndbufErrors:    counters.GetUdpSndbufErrors(),
+		},
+	}, nil
+}
+
+// SystemdUnits returns the units matching the glob pattern, e.g. "nginx*", all units the agent reads when it is empty.
+func (c *Client) SystemdUnits(ctx context.Context, pattern string) ([]SystemdUnit, error) {
+	res, err := c.rpc.GetSystemdUnits(ctx, &pb.SystemdUnitsReq{Pattern: pattern})
+	if err != nil {
+		return nil, err
+	}
+	units := make([]SystemdUnit, len(res.Units))
+	for i, unit := range res.Units {
+		units[i] = SystemdUnit{
+			Name:          unit.Name,
+			Description:   unit.Description,
+			LoadState:     unit.LoadState,
+			ActiveState:   unit.ActiveState,
+			SubState:      unit.SubState,
+			UnitFileState: unit.UnitFileState,
+			Restarts:      unit.Restarts,
+			MemoryB:       unit.MemoryB,
+			CpuSeconds:    unit.CpuSeconds,
+		}
+	}
+	return units, nil
+}
+
+func (c *Client) KernelLimits(ctx context.Context) (KernelLimits, error) {
+	res, err := c.rpc.GetKernelLimits(ctx, &pb.KernelLimitsReq{})
+	if err != nil {
+		return KernelLimits{}, err
+	}
+	processes := make([]ProcessFds, len(res.Processes))
+	for i, process := range res.Processes {
+		processes[i] = ProcessFds{
+			Pid:       process.Pid,
+			Name:      process.Name,
+			OpenFds:   process.OpenFds,
+			SoftLimit: process.SoftLimit,
+			HardLimit: process.HardLimit,
+		}
+	}
+	return KernelLimits{
+		OpenFiles:        res.OpenFiles,
+		MaxFiles:         res.MaxFiles,
+		Inodes:           res.Inodes,
+		FreeInodes:       res.FreeInodes,
+		HasConntrack:     res.HasConntrack,
+		ConntrackEntries: res.ConntrackEntries,
+		ConntrackMax:     res.ConntrackMax,
+		Pids:             res.Pids,
+		PidMax:           res.PidMax,
+		ThreadsMax:       res.ThreadsMax,
+		EntropyAvailable: res.EntropyAvailable,
+		EntropyPoolSize:  res.EntropyPoolSize,
+		Processes:        processes,
+	}, nil
+}
+
+// ProcessGroups returns the usage of the process groups configured on the agent, only the named one when group is set.
+func (c *Client) ProcessGroups(ctx context.Context, group string) ([]ProcessGroup, error) {
+	res, err := c.rpc.GetProcessGroups(ctx, &pb.ProcessGroupsReq{Group: group})
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]ProcessGroup, len(res.Groups))
+	for i, group := range res.Groups {
+		groups[i] = ProcessGroup{
+			Name:       group.Name,
+			Pids:       group.Pids,
+			CpuPercent: group.CpuPercent,
+			RssB:       group.RssB,
+			ReadBps:    group.ReadBps,
+			WriteBps:   group.WriteBps,
+			Threads:    group.Threads,
+			OpenFds:    group.OpenFds,
+		}
+	}
+	return groups, nil
+}
+
+func (c *Client) MemoryDetails(ctx context.Context) (MemoryDetails, error) {
+	res, err := c.rpc.GetMemoryDetails(ctx, &pb.MemoryDetailsReq{})
+	if err != nil {
+		return MemoryDetails{}, err
+	}
+	nodes := make([]NumaNode, len(res.NumaNodes))
+	for i, node := range res.NumaNodes {
+		nodes[i] = NumaNode{
+			ID:        int(node.Id),
+			TotalB:    node.TotalB,
+			FreeB:     node.FreeB,
+			HugePages: hugePagePools(node.HugePages),
+		}
+	}
+	return MemoryDetails{
+		TotalB:             res.TotalB,
+		FreeB:              res.FreeB,
+		AvailableB:         res.AvailableB,
+		BuffersB:           res.BuffersB,
+		CachedB:            res.CachedB,
+		SwapTotalB:         res.SwapTotalB,
+		SwapFreeB:          res.SwapFreeB,
+		DirtyB:             res.DirtyB,
+		WritebackB:         res.WritebackB,
+		SlabB:              res.SlabB,
+		SlabReclaimableB:   res.SlabReclaimableB,
+		SlabUnreclaimableB: res.SlabUnreclaimableB,
+		CommittedAsB:       res.CommittedAsB,
+		CommitLimitB:       res.CommitLimitB,
+		AnonHugePagesB:     res.AnonHugePagesB,
+		HugePages:          hugePagePools(res.HugePages),
+		TransparentHugePages: TransparentHugePages{
+			Enabled: res.TransparentHugePagesEnabled,
+			Defrag:  res.TransparentHugePagesDefrag,
+		},
+		NumaNodes: nodes,
+	}, nil
+}
+
+func hugePagePools(poolsPb []*pb.HugePagePool) []HugePagePool {
+	pools := make([]HugePagePool, len(poolsPb))
+	for i, pool := range poolsPb {
+		pools[i] = HugePagePool{
+			SizeB:    pool.SizeB,
+			Total:    pool.Total,
+			Free:     pool.Free,
+			Reserved: pool.Reserved,
+			Surplus:  pool.Surplus,
+		}
+	}
+	return pools
+}
+
+// Mounts returns the mounted filesystems with their health, only the unhealthy ones when unhealthyOnly is set.
+func (c *Client) Mounts(ctx context.Context, unhealthyOnly bool) ([]MountStatus, error) {
+	res, err := c.rpc.GetMounts(ctx, &pb.MountsReq{UnhealthyOnly: unhealthyOnly})
+	if err != nil {
+		return nil, err
+	}
+	mounts := make([]MountStatus, len(res.Mounts))
+	for i, mount := range res.Mounts {
+		mounts[i] = MountStatus{
+			Path:              mount.Path,
+			Device:            mount.Device,
+			Fstype:            mount.Fstype,
+			Options:           mount.Options,
+			ReadOnly:          mount.ReadOnly,
+			FstabWritable:     mount.FstabWritable,
+			Stale:             mount.Stale,
+			TotalB:            mount.TotalB,
+			UsedB:             mount.UsedB,
+			FreeB:             mount.FreeB,
+			UsedPercent:       mount.UsedPercent,
+			Inodes:            mount.Inodes,
+			InodesFree:        mount.InodesFree,
+			InodesUsedPercent: mount.InodesUsedPercent,
+			Health:            mount.Health,
+		}
+	}
+	return mounts, nil
+}
+
+// WatchProcesses calls handle with the events of the processes watched by the agent, of the named ones
+// when processes are given, until ctx is done or the stream fails. It returns nil when ctx is done.
+func (c *Client) WatchProcesses(ctx context.Context, handle func(ProcessEvent), processes ...string) error {
+	stream, err := c.rpc.WatchProcesses(ctx, &pb.WatchProcessesReq{Processes: processes})
+	if err != nil {
+		return err
+	}
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		handle(ProcessEvent{
+			Process:     event.Process,
+			Type:        event.Type,
+			Pid:         event.Pid,
+			PreviousPid: event.PreviousPid,
+			Restarts:    int(event.Restarts),
+			Message:     event.Message,
+			Time:        timeOrZero(event.Time),
+		})
+	}
+}
+
+func (c *Client) Status(ctx context.Context) (AgentStatus, error) {
+	res, err := c.rpc.GetStatus(ctx, &pb.StatusReq{})
+	if err != nil {
+		return AgentStatus{}, err
+	}
+	return AgentStatus{
+		Version:         res.Version,
+		ConfigPath:      res.ConfigPath,
+		StartedAt:       timeOrZero(res.StartedAt),
+		ConfigLoadedAt:  timeOrZero(res.ConfigLoadedAt),
+		LastReloadAt:    timeOrZero(res.LastReloadAt),
+		LastReloadError: res.LastReloadError,
+		ReloadCount:     res.ReloadCount,
+	}, nil
+}
+
+func timeOrZero(unix int64) time.Time {
+	if unix == 0 {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
+}
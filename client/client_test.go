@@ -0,0 +1,286 @@
+package client
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/collector"
+	"github.com/Matyjash/Metrigo/internal/models"
+	pb "github.com/Matyjash/Metrigo/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeServer struct {
+	pb.UnimplementedMetrigoServer
+
+	mu             sync.Mutex
+	calls          int
+	unavailableFor int
+	authorization  []string
+	temperatureErr error
+	collectRes     *pb.CollectRes
+}
+
+func (f *fakeServer) GetMemoryUsage(ctx context.Context, req *pb.MemoryUsageReq) (*pb.MemoryUsageRes, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	md, _ := metadata.FromIncomingContext(ctx)
+	f.authorization = md.Get("authorization")
+	if f.calls <= f.unavailableFor {
+		return nil, status.Error(codes.Unavailable, "starting up")
+	}
+	return &pb.MemoryUsageRes{TotalB: 1000, UsedB: 250}, nil
+}
+
+func (f *fakeServer) GetTemperatures(ctx context.Context, req *pb.TemperatureReq) (*pb.TemperatureRes, error) {
+	return nil, f.temperatureErr
+}
+
+func (f *fakeServer) Collect(ctx context.Context, req *pb.CollectReq) (*pb.CollectRes, error) {
+	return f.collectRes, nil
+}
+
+func (f *fakeServer) WatchProcesses(req *pb.WatchProcessesReq, stream pb.Metrigo_WatchProcessesServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	f.mu.Lock()
+	f.authorization = md.Get("authorization")
+	f.mu.Unlock()
+	for _, process := range req.Processes {
+		if err := stream.Send(&pb.ProcessEvent{Process: process, Type: "restarted", Pid: 20, PreviousPid: 10, Restarts: 1, Time: 1700000000}); err != nil {
+			return err
+		}
+	}
+	if len(req.Processes) == 0 {
+		return status.Error(codes.FailedPrecondition, "no processes")
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func newTestClient(t *testing.T, fake *fakeServer, opts ...Option) *Client {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	pb.RegisterMetrigoServer(grpcServer, fake)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	opts = append(opts, WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	})))
+	c, err := New("passthrough:///bufnet", opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func Test_MemoryUsage(t *testing.T) {
+	tests := []struct {
+		name              string
+		unavailableFor    int
+		opts              []Option
+		wantCalls         int
+		wantAuthorization []string
+		wantCode          codes.Code
+	}{
+		{
+			name:      "typed result",
+			wantCalls: 1,
+		},
+		{
+			name:              "token is sent as bearer authorization",
+			opts:              []Option{WithToken("secret")},
+			wantCalls:         1,
+			wantAuthorization: []string{"Bearer secret"},
+		},
+		{
+			name:           "unavailable server is retried",
+			unavailableFor: 2,
+			opts:           []Option{WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, Codes: []codes.Code{codes.Unavailable}})},
+			wantCalls:      3,
+		},
+		{
+			name:           "retries are limited by max attempts",
+			unavailableFor: 5,
+			opts:           []Option{WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond, Codes: []codes.Code{codes.Unavailable}})},
+			wantCalls:      2,
+			wantCode:       codes.Unavailable,
+		},
+		{
+			name:           "no retries without policy",
+			unavailableFor: 1,
+			wantCalls:      1,
+			wantCode:       codes.Unavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeServer{unavailableFor: tt.unavailableFor}
+			c := newTestClient(t, fake, tt.opts...)
+
+			memoryUsage, err := c.MemoryUsage(context.Background())
+			if fake.calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, fake.calls)
+			}
+			if tt.wantCode != codes.OK {
+				if status.Code(err) != tt.wantCode {
+					t.Fatalf("expected code %v, got \"%v\"", tt.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := (MemoryUsage{UsedB: 250, TotalB: 1000}); memoryUsage != want {
+				t.Errorf("expected %v, got %v", want, memoryUsage)
+			}
+			if !reflect.DeepEqual(tt.wantAuthorization, fake.authorization) {
+				t.Errorf("expected authorization %v, got %v", tt.wantAuthorization, fake.authorization)
+			}
+		})
+	}
+}
+
+func Test_ErrorReason(t *testing.T) {
+	st, err := status.New(codes.NotFound, "no temperature sensors found").WithDetails(&errdetails.ErrorInfo{Reason: ReasonNotFound, Domain: errorDomain})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := newTestClient(t, &fakeServer{temperatureErr: st.Err()})
+
+	_, err = c.Temperatures(context.Background())
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected code %v, got \"%v\"", codes.NotFound, err)
+	}
+	if reason := ErrorReason(err); reason != ReasonNotFound {
+		t.Errorf("expected reason %s, got %q", ReasonNotFound, reason)
+	}
+}
+
+func Test_SnapshotAndWatch(t *testing.T) {
+	c := newTestClient(t, &fakeServer{collectRes: &pb.CollectRes{Results: []*pb.CollectorSamples{
+		{Collector: "load", Samples: []*pb.Sample{{Metric: "load1", Value: 0.5}}},
+		{Collector: "temp", Error: "no temperature sensors found", Reason: ReasonNotFound},
+	}}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	snapshots := c.Watch(ctx, time.Millisecond, "load", "temp")
+	for range 2 {
+		snapshot := <-snapshots
+		if snapshot.Err != nil {
+			t.Fatalf("unexpected error: %v", snapshot.Err)
+		}
+		load, ok := snapshot.Result("load")
+		if !ok || load.Err != nil || !reflect.DeepEqual(load.Samples, []Sample{{Metric: "load1", Value: 0.5}}) {
+			t.Errorf("unexpected load result %+v", load)
+		}
+		temp, _ := snapshot.Result("temp")
+		if ErrorReason(temp.Err) != ReasonNotFound {
+			t.Errorf("expected temp reason %s, got %v", ReasonNotFound, temp.Err)
+		}
+	}
+
+	cancel()
+	for range snapshots {
+	}
+}
+
+func Test_WatchProcesses(t *testing.T) {
+	fake := &fakeServer{}
+	c := newTestClient(t, fake, WithToken("secret"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []ProcessEvent
+	err := c.WatchProcesses(ctx, func(event ProcessEvent) {
+		events = append(events, event)
+		if len(events) == 2 {
+			cancel()
+		}
+	}, "sshd", "nginx")
+	if err != nil {
+		t.Fatalf("expected no error once the context is done, got %v", err)
+	}
+	want := []ProcessEvent{
+		{Process: "sshd", Type: "restarted", Pid: 20, PreviousPid: 10, Restarts: 1, Time: time.Unix(1700000000, 0)},
+		{Process: "nginx", Type: "restarted", Pid: 20, PreviousPid: 10, Restarts: 1, Time: time.Unix(1700000000, 0)},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("expected events %+v, got %+v", want, events)
+	}
+	if !reflect.DeepEqual(fake.authorization, []string{"Bearer secret"}) {
+		t.Errorf("expected the token on the stream, got %v", fake.authorization)
+	}
+
+	err = c.WatchProcesses(context.Background(), func(ProcessEvent) {})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected the stream error, got %v", err)
+	}
+}
+
+// Test_modelsMatchInternal guards the public types against drifting from the server's models,
+// which would leave new fields unset by the client.
+func Test_modelsMatchInternal(t *testing.T) {
+	pairs := []struct{ public, internal any }{
+		{CpuInfo{}, models.CpuInfo{}},
+		{CpuSpec{}, models.CpuSpec{}},
+		{CpuTimes{}, models.CpuTimes{}},
+		{CpuStats{}, models.CpuStats{}},
+		{CpuTopology{}, models.CpuTopology{}},
+		{CpuFrequency{}, models.CpuFrequency{}},
+		{CpuSocket{}, models.CpuSocket{}},
+		{CpuCore{}, models.CpuCore{}},
+		{TemperatureSensor{}, models.TemperatureSensor{}},
+		{MemoryUsage{}, models.MemoryUsage{}},
+		{HostInfo{}, models.HostInfo{}},
+		{UserSession{}, models.UserSession{}},
+		{NetInterface{}, models.NetInterface{}},
+		{AgentStatus{}, models.AgentStatus{}},
+		{ContainerStats{}, models.ContainerStats{}},
+		{HwmonSensor{}, models.HwmonSensor{}},
+		{PressureStats{}, models.PressureStats{}},
+		{ResourcePressure{}, models.ResourcePressure{}},
+		{PressureStall{}, models.PressureStall{}},
+		{SocketStats{}, models.SocketStats{}},
+		{Connection{}, models.Connection{}},
+		{ProtocolCounters{}, models.ProtocolCounters{}},
+		{SystemdUnit{}, models.SystemdUnit{}},
+		{KernelLimits{}, models.KernelLimits{}},
+		{ProcessFds{}, models.ProcessFds{}},
+		{ProcessGroup{}, models.ProcessGroup{}},
+		{ProcessEvent{}, models.ProcessEvent{}},
+		{MemoryDetails{}, models.MemoryDetails{}},
+		{HugePagePool{}, models.HugePagePool{}},
+		{TransparentHugePages{}, models.TransparentHugePages{}},
+		{NumaNode{}, models.NumaNode{}},
+		{MountStatus{}, models.MountStatus{}},
+		{Descriptor{}, collector.Descriptor{}},
+		{Sample{}, collector.Sample{}},
+	}
+	for _, pair := range pairs {
+		public, internal := reflect.TypeOf(pair.public), reflect.TypeOf(pair.internal)
+		if public.NumField() != internal.NumField() {
+			t.Errorf("%s has %d fields, %s has %d", public, public.NumField(), internal, internal.NumField())
+			continue
+		}
+		for i := 0; i < public.NumField(); i++ {
+			publicField, internalField := public.Field(i), internal.Field(i)
+			if publicField.Name != internalField.Name || publicField.Type.Kind() != internalField.Type.Kind() {
+				t.Errorf("%s.%s (%s) does not match %s.%s (%s)", public, publicField.Name, publicField.Type, internal, internalField.Name, internalField.Type)
+			}
+		}
+	}
+}
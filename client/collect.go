@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/Matyjash/Metrigo/pb"
+)
+
+type MetricType int
+
+const (
+	Gauge MetricType = iota
+	Counter
+)
+
+func (t MetricType) String() string {
+	if t == Counter {
+		return "counter"
+	}
+	return "gauge"
+}
+
+// Descriptor describes a metric reported by a collector.
+type Descriptor struct {
+	Name   string
+	Help   string
+	Unit   string
+	Type   MetricType
+	Labels []string
+}
+
+type Sample struct {
+	Metric string
+	Labels map[string]string
+	Value  float64
+}
+
+type CollectorInfo struct {
+	Name        string
+	Description string
+	Enabled     bool
+	Metrics     []Descriptor
+}
+
+// CollectorResult holds the samples of one collector. Err is a *CollectorError when the collector failed.
+type CollectorResult struct {
+	Collector string
+	Samples   []Sample
+	Err       error
+}
+
+// Snapshot is the result of a Collect call.
+type Snapshot struct {
+	Time    time.Time
+	Results []CollectorResult
+	// Err is set when the call itself failed.
+	Err error
+}
+
+// Result returns the result of the collector.
+func (s Snapshot) Result(collector string) (CollectorResult, bool) {
+	for _, result := range s.Results {
+		if result.Collector == collector {
+			return result, true
+		}
+	}
+	return CollectorResult{}, false
+}
+
+func (c *Client) ListCollectors(ctx context.Context) ([]CollectorInfo, error) {
+	res, err := c.rpc.ListCollectors(ctx, &pb.ListCollectorsReq{})
+	if err != nil {
+		return nil, err
+	}
+	collectors := make([]CollectorInfo, len(res.Collectors))
+	for i, info := range res.Collectors {
+		metrics := make([]Descriptor, len(info.Metrics))
+		for j, metric := range info.Metrics {
+			metrics[j] = Descriptor{
+				Name:   metric.Name,
+				Help:   metric.Help,
+				Unit:   metric.Unit,
+				Type:   metricType(metric.Type),
+				Labels: metric.Labels,
+			}
+		}
+		collectors[i] = CollectorInfo{
+			Name:        info.Name,
+			Description: info.Description,
+			Enabled:     info.Enabled,
+			Metrics:     metrics,
+		}
+	}
+	return collectors, nil
+}
+
+// Snapshot runs the collectors, all enabled ones when none are given. A failing collector is reported
+// in its result, the returned error is set only when the call itself failed.
+func (c *Client) Snapshot(ctx context.Context, collectors ...string) (Snapshot, error) {
+	res, err := c.rpc.Collect(ctx, &pb.CollectReq{Collectors: collectors})
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot := Snapshot{Time: time.Now(), Results: make([]CollectorResult, len(res.Results))}
+	for i, result := range res.Results {
+		samples := make([]Sample, len(result.Samples))
+		for j, sample := range result.Samples {
+			samples[j] = Sample{Metric: sample.Metric, Labels: sample.Labels, Value: sample.Value}
+		}
+		snapshot.Results[i] = CollectorResult{Collector: result.Collector, Samples: samples}
+		if result.Error != "" {
+			snapshot.Results[i].Err = &CollectorError{Collector: result.Collector, Reason: result.Reason, Message: result.Error}
+		}
+	}
+	return snapshot, nil
+}
+
+// Watch takes a snapshot of the collectors right away and then every interval until ctx is done,
+// when the returned channel is closed. Failed calls are delivered as snapshots with Err set.
+// A snapshot is dropped if the receiver has not consumed the previous one by the next tick.
+func (c *Client) Watch(ctx context.Context, interval time.Duration, collectors ...string) <-chan Snapshot {
+	snapshots := make(chan Snapshot, 1)
+	go func() {
+		defer close(snapshots)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			snapshot, err := c.Snapshot(ctx, collectors...)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				snapshot = Snapshot{Time: time.Now(), Err: err}
+			}
+			select {
+			case snapshots <- snapshot:
+			default:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return snapshots
+}
+
+func metricType(metricType pb.MetricType) MetricType {
+	if metricType == pb.MetricType_METRIC_TYPE_COUNTER {
+		return Counter
+	}
+	return Gauge
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// Error reasons reported by the server, see ErrorReason.
+const (
+	ReasonNotFound          = "NOT_FOUND"
+	ReasonUnsupported       = "UNSUPPORTED"
+	ReasonTimeout           = "TIMEOUT"
+	ReasonCanceled          = "CANCELED"
+	ReasonInternal          = "INTERNAL"
+	ReasonCollectorDisabled = "COLLECTOR_DISABLED"
+	ReasonUnknownCollector  = "UNKNOWN_COLLECTOR"
+)
+
+const errorDomain = "metrigo"
+
+// CollectorError is the error of a collector that failed within a Snapshot.
+type CollectorError struct {
+	Collector string
+	Reason    string
+	Message   string
+}
+
+func (e *CollectorError) Error() string {
+	return e.Message
+}
+
+// ErrorReason returns the reason of an error returned by the client, e.g. ReasonNotFound,
+// or an empty string when the server did not classify it.
+func ErrorReason(err error) string {
+	var collectorErr *CollectorError
+	if errors.As(err, &collectorErr) {
+		return collectorErr.Reason
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return ""
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == errorDomain {
+			return info.Reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,416 @@
+package client
+
+import "time"
+
+type CpuInfo struct {
+	ID           string
+	UsagePercent float64
+	Times        CpuTimes
+	CpuSpec
+}
+
+type CpuSpec struct {
+	FrequencyMhz float64
+	VendorID     string
+	ModelName    string
+	Family       string
+	Model        string
+	Stepping     int32
+	CacheSizeKB  int32
+	Flags        []string
+	// SocketID and CoreID place the logical CPU in the topology, empty when the platform does not report them.
+	SocketID string
+	CoreID   string
+	// Cores is the count of logical CPUs the spec describes on platforms that report one spec per socket or per host.
+	Cores int32
+}
+
+// CpuTimes is the share of the measure interval spent in each CPU state, in percent.
+// Guest and GuestNice are included in User and Nice.
+type CpuTimes struct {
+	User      float64
+	System    float64
+	Idle      float64
+	Nice      float64
+	Iowait    float64
+	Irq       float64
+	Softirq   float64
+	Steal     float64
+	Guest     float64
+	GuestNice float64
+}
+
+// CpuStats is the CPU time breakdown of all CPUs together and of each logical CPU, with the system-wide event rates.
+type CpuStats struct {
+	Total                 CpuTimes
+	PerCpu                []CpuTimes
+	ContextSwitchesPerSec float64
+	InterruptsPerSec      float64
+}
+
+// CpuTopology maps the logical CPUs to their cores and sockets.
+type CpuTopology struct {
+	PhysicalCores int
+	LogicalCpus   int
+	Sockets       []CpuSocket
+}
+
+// CpuFrequency is the cpufreq state of a logical CPU. Values the driver does not report are 0 or empty.
+type CpuFrequency struct {
+	Cpu        string
+	CurrentMhz float64
+	// MinMhz and MaxMhz are the limits set for the governor, HardwareMinMhz and HardwareMaxMhz the limits of the CPU.
+	MinMhz         float64
+	MaxMhz         float64
+	HardwareMinMhz float64
+	HardwareMaxMhz float64
+	Governor       string
+	// EnergyPerformancePreference is the hint given to intel_pstate or amd-pstate, e.g. balance_performance.
+	EnergyPerformancePreference string
+	// CoreThrottleCount and PackageThrottleCount count the times the core or its package was thermally throttled.
+	CoreThrottleCount    uint64
+	PackageThrottleCount uint64
+}
+
+type CpuSocket struct {
+	ID          string
+	VendorID    string
+	ModelName   string
+	Family      string
+	Model       string
+	Stepping    int32
+	CacheSizeKB int32
+	Flags       []string
+	Cores       []CpuCore
+}
+
+type CpuCore struct {
+	ID string
+	// Cpus are the IDs of the logical CPUs of the core, more than one with SMT.
+	Cpus []string
+}
+
+type TemperatureSensor struct {
+	Key string
+	// Chip is the device the sensor belongs to, e.g. coretemp, k10temp or nvme.
+	Chip  string
+	Value float64
+	// High and Critical are the thresholds reported by the chip, 0 when not reported.
+	High     float64
+	Critical float64
+	// Status is ok, high or critical depending on the thresholds.
+	Status string
+}
+
+type MemoryUsage struct {
+	UsedB  uint64
+	TotalB uint64
+}
+
+type HostInfo struct {
+	Hostname        string
+	OS              string
+	Platform        string
+	PlatformVersion string
+	Uptime          uint64
+	KernelVersion   string
+	KernelArch      string
+	// BootTime is the Unix time the host booted at, in seconds.
+	BootTime uint64
+	// VirtualizationSystem is e.g. kvm, docker or lxc, empty on bare metal.
+	VirtualizationSystem string
+	// VirtualizationRole is host or guest.
+	VirtualizationRole string
+	HostID             string
+	Procs              uint64
+	Users              []UserSession
+	// Timezone is the IANA name of the host's time zone, e.g. Europe/Warsaw, or its abbreviation when the name is unknown.
+	Timezone string
+}
+
+// UserSession is a logged-in user session of utmp.
+type UserSession struct {
+	User     string
+	Terminal string
+	// Host is the remote host of the session, empty for local ones.
+	Host string
+	// Started is the Unix time the session started at, in seconds.
+	Started uint64
+}
+
+type NetInterface struct {
+	Name       string
+	Index      int
+	Addressess []string
+	MTU        int
+}
+
+type AgentStatus struct {
+	Version         string
+	ConfigPath      string
+	StartedAt       time.Time
+	ConfigLoadedAt  time.Time
+	LastReloadAt    time.Time
+	LastReloadError string
+	ReloadCount     uint64
+}
+
+type ContainerStats struct {
+	ID     string
+	Name   string
+	Image  string
+	State  string
+	Labels map[string]string
+	// CpuPercent is relative to a single CPU, so it exceeds 100 for containers using several CPUs.
+	CpuPercent   float64
+	MemoryUsageB uint64
+	// MemoryLimitB is the host memory when the container is not limited.
+	MemoryLimitB uint64
+	NetRxB       uint64
+	NetTxB       uint64
+	BlockReadB   uint64
+	BlockWriteB  uint64
+}
+
+// HwmonSensor is a fan, voltage, power or current sensor of a hardware monitoring chip.
+// Limits the chip does not report are nil.
+type HwmonSensor struct {
+	Chip   string
+	Sensor string
+	Label  string
+	Type   string
+	Unit   string
+	Value  float64
+	Min    *float64
+	Max    *float64
+	Crit   *float64
+	Alarm  bool
+}
+
+// PressureStats is the Pressure Stall Information of the host, when Cgroup is empty, or of a cgroup.
+type PressureStats struct {
+	Cgroup    string
+	Resources []ResourcePressure
+}
+
+// ResourcePressure is the stall of tasks waiting for a resource: cpu, memory or io.
+// Some is the time at least one task was stalled, Full the time all non-idle tasks were stalled at once.
+type ResourcePressure struct {
+	Resource string
+	Some     PressureStall
+	Full     PressureStall
+	// HasFull is false when the kernel does not report full stalls of the resource, e.g. cpu before Linux 5.13.
+	HasFull bool
+}
+
+// PressureStall is the share of time stalled over 10, 60 and 300 second windows in percent, and the total stall time.
+type PressureStall struct {
+	Avg10        float64
+	Avg60        float64
+	Avg300       float64
+	TotalSeconds float64
+}
+
+// SocketStats are the TCP and UDP sockets of the host and the counters of its network stack.
+type SocketStats struct {
+	Connections []Connection
+	// HasCounters is false where the kernel does not report the counters, e.g. off Linux.
+	HasCounters bool
+	Counters    ProtocolCounters
+}
+
+// Connection is a TCP or UDP socket. Protocol is tcp, tcp6, udp or udp6.
+// State is the TCP state, e.g. ESTABLISHED or LISTEN, and UNCONN or ESTABLISHED for UDP sockets.
+type Connection struct {
+	Protocol   string
+	LocalAddr  string
+	LocalPort  uint32
+	RemoteAddr string
+	RemotePort uint32
+	State      string
+	// Pid is the process owning the socket, 0 when it is unknown, e.g. without the permissions to read it.
+	Pid int32
+}
+
+// ProtocolCounters are the TCP and UDP counters of /proc/net/snmp and /proc/net/netstat since boot.
+type ProtocolCounters struct {
+	TcpActiveOpens     uint64
+	TcpPassiveOpens    uint64
+	TcpAttemptFails    uint64
+	TcpEstabResets     uint64
+	TcpCurrEstab       uint64
+	TcpInSegs          uint64
+	TcpOutSegs         uint64
+	TcpRetransSegs     uint64
+	TcpInErrs          uint64
+	TcpOutRsts         uint64
+	TcpListenOverflows uint64
+	TcpListenDrops     uint64
+	UdpInDatagrams     uint64
+	UdpOutDatagrams    uint64
+	UdpNoPorts         uint64
+	UdpInErrors        uint64
+	UdpRcvbufErrors    uint64
+	UdpSndbufErrors    uint64
+}
+
+// SystemdUnitFailed is the ActiveState of a failed SystemdUnit.
+const SystemdUnitFailed = "failed"
+
+// SystemdUnit is the state of a loaded systemd unit. Accounting values systemd does not track for the unit are 0.
+type SystemdUnit struct {
+	Name          string
+	Description   string
+	LoadState     string
+	ActiveState   string
+	SubState      string
+	UnitFileState string
+	// Restarts counts the automatic restarts of a service.
+	Restarts   uint64
+	MemoryB    uint64
+	CpuSeconds float64
+}
+
+// KernelLimits is the usage of the kernel tables that can be exhausted: file handles, inodes, conntrack entries,
+// pids and entropy, and the file descriptors of each process against its limit.
+type KernelLimits struct {
+	OpenFiles uint64
+	MaxFiles  uint64
+	// Inodes are the allocated inodes, FreeInodes the allocated ones not in use.
+	Inodes     uint64
+	FreeInodes uint64
+	// HasConntrack is false when the nf_conntrack module is not loaded.
+	HasConntrack     bool
+	ConntrackEntries uint64
+	ConntrackMax     uint64
+	// Pids is the number of threads, each of which uses a pid.
+	Pids       uint64
+	PidMax     uint64
+	ThreadsMax uint64
+	// EntropyAvailable and EntropyPoolSize are in bits.
+	EntropyAvailable uint64
+	EntropyPoolSize  uint64
+	Processes        []ProcessFds
+}
+
+// ProcessFds are the open file descriptors of a process and its RLIMIT_NOFILE, 0 when unlimited.
+type ProcessFds struct {
+	Pid       int32
+	Name      string
+	OpenFds   uint64
+	SoftLimit uint64
+	HardLimit uint64
+}
+
+// ProcessGroup is the aggregated usage of the processes of a group.
+type ProcessGroup struct {
+	Name       string
+	Pids       []int32
+	CpuPercent float64
+	RssB       uint64
+	ReadBps    float64
+	WriteBps   float64
+	Threads    uint64
+	OpenFds    uint64
+}
+
+// ProcessEvent reports a change of a watched process: its start, stop or restart, or too many restarts.
+type ProcessEvent struct {
+	// Process is the name of the watched process.
+	Process string
+	Type    string
+	Pid     int32
+	// PreviousPid is the pid of the process before a restart or a stop.
+	PreviousPid int32
+	// Restarts are the restarts counted within the restart window of the process.
+	Restarts int
+	Message  string
+	Time     time.Time
+}
+
+// MemoryDetails is the breakdown of /proc/meminfo with the hugepage pools, the transparent hugepage settings
+// and the memory of each NUMA node.
+type MemoryDetails struct {
+	TotalB     uint64
+	FreeB      uint64
+	AvailableB uint64
+	BuffersB   uint64
+	CachedB    uint64
+	SwapTotalB uint64
+	SwapFreeB  uint64
+	DirtyB     uint64
+	WritebackB uint64
+	// SlabB is the kernel slab memory, the sum of its reclaimable and unreclaimable parts.
+	SlabB              uint64
+	SlabReclaimableB   uint64
+	SlabUnreclaimableB uint64
+	// CommittedAsB is the memory allocated by processes, which may exceed CommitLimitB unless overcommit is disabled.
+	CommittedAsB uint64
+	CommitLimitB uint64
+	// AnonHugePagesB is the anonymous memory backed by transparent hugepages.
+	AnonHugePagesB       uint64
+	HugePages            []HugePagePool
+	TransparentHugePages TransparentHugePages
+	NumaNodes            []NumaNode
+}
+
+// HugePagePool is the pool of hugepages of one size, counted in pages.
+type HugePagePool struct {
+	SizeB uint64
+	Total uint64
+	Free  uint64
+	// Reserved are the pages promised to mappings but not faulted in yet, not reported per NUMA node.
+	Reserved uint64
+	// Surplus are the pages allocated above Total, up to nr_overcommit_hugepages.
+	Surplus uint64
+}
+
+// TransparentHugePages are the selected transparent hugepage modes, e.g. "madvise". Empty when THP is not available.
+type TransparentHugePages struct {
+	Enabled string
+	Defrag  string
+}
+
+// NumaNode is the memory of a NUMA node and its share of the hugepage pools.
+type NumaNode struct {
+	ID        int
+	TotalB    uint64
+	FreeB     uint64
+	HugePages []HugePagePool
+}
+
+// Health states of a MountStatus, from the worst to the best.
+const (
+	// MountHealthStale is a mount whose usage could not be read within the stat timeout, e.g. a hung NFS mount.
+	MountHealthStale = "stale"
+	// MountHealthReadOnly is a read-only mount that the fstab mounts read-write or that was read-write before,
+	// e.g. a filesystem remounted read-only by the kernel after I/O errors.
+	MountHealthReadOnly = "read-only"
+	// MountHealthInodesExhausted is a mount with less than 5 percent of its inodes free.
+	MountHealthInodesExhausted = "inodes-exhausted"
+	MountHealthOK              = "ok"
+)
+
+// MountStatus is the health of a mounted filesystem with its space and inode usage.
+type MountStatus struct {
+	Path   string
+	Device string
+	Fstype string
+	// Options are the mount options followed by the filesystem's own options, e.g. "rw,noatime,errors=remount-ro".
+	Options  []string
+	ReadOnly bool
+	// FstabWritable is true when the fstab mounts the filesystem read-write, so being read-only means it was remounted.
+	FstabWritable bool
+	// Stale is true when reading the usage did not complete within the stat timeout, e.g. an unreachable NFS server.
+	// The usage is then 0.
+	Stale             bool
+	TotalB            uint64
+	UsedB             uint64
+	FreeB             uint64
+	UsedPercent       float64
+	Inodes            uint64
+	InodesFree        uint64
+	InodesUsedPercent float64
+	// Health is the worst state of the mount, one of the MountHealth* values.
+	Health string
+}
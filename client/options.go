@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+type options struct {
+	tls         *tls.Config
+	token       string
+	retry       *RetryPolicy
+	keepalive   *keepalive.ClientParameters
+	dialOptions []grpc.DialOption
+}
+
+// Option configures a Client.
+type Option func(*options) error
+
+// WithTLS enables TLS with the given configuration. The connection is plaintext without it.
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) error {
+		o.tls = config
+		return nil
+	}
+}
+
+// WithTLSFiles enables TLS trusting the CA in caFile, or the system roots when it is empty.
+// certFile and keyFile set the client certificate for servers that require mutual TLS and may be empty.
+func WithTLSFiles(caFile, certFile, keyFile string) Option {
+	return func(o *options) error {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if caFile != "" {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return fmt.Errorf("failed to read CA file: %v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("no certificates found in CA file %s", caFile)
+			}
+			config.RootCAs = pool
+		}
+		if certFile != "" || keyFile != "" {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return fmt.Errorf("failed to load client certificate: %v", err)
+			}
+			config.Certificates = []tls.Certificate{cert}
+		}
+		o.tls = config
+		return nil
+	}
+}
+
+// WithToken sends the token as "authorization: Bearer <token>" metadata on every call.
+func WithToken(token string) Option {
+	return func(o *options) error {
+		o.token = token
+		return nil
+	}
+}
+
+// RetryPolicy retries calls failing with one of the Codes, waiting Backoff before the first retry
+// and doubling the wait up to MaxBackoff after each one.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Codes       []codes.Code
+}
+
+// DefaultRetryPolicy retries unavailable servers three times.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	Backoff:     100 * time.Millisecond,
+	MaxBackoff:  2 * time.Second,
+	Codes:       []codes.Code{codes.Unavailable},
+}
+
+// WithRetry retries failed calls according to the policy. All Metrigo calls are read-only, so retrying them is safe.
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *options) error {
+		if policy.MaxAttempts < 1 {
+			return fmt.Errorf("retry max attempts must be at least 1, got %d", policy.MaxAttempts)
+		}
+		o.retry = &policy
+		return nil
+	}
+}
+
+// WithKeepalive pings the server after interval without activity and closes the connection
+// if the ping is not acknowledged within timeout.
+func WithKeepalive(interval, timeout time.Duration) Option {
+	return func(o *options) error {
+		o.keepalive = &keepalive.ClientParameters{Time: interval, Timeout: timeout, PermitWithoutStream: true}
+		return nil
+	}
+}
+
+// WithDialOptions passes additional options to grpc.NewClient.
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *options) error {
+		o.dialOptions = append(o.dialOptions, dialOptions...)
+		return nil
+	}
+}
+
+// tokenCredentials implements credentials.PerRPCCredentials. The token is also sent over plaintext
+// connections because the server accepts tokens without TLS.
+type tokenCredentials struct {
+	token string
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+func retryInterceptor(policy RetryPolicy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		backoff := policy.Backoff
+		var err error
+		for attempt := 1; ; attempt++ {
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= policy.MaxAttempts || !retryable(policy, err) {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+				backoff = policy.MaxBackoff
+			}
+		}
+	}
+}
+
+func retryable(policy RetryPolicy, err error) bool {
+	return slices.Contains(policy.Codes, status.Code(err))
+}
@@ -1,53 +1,79 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"net"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
 
+	"github.com/Matyjash/Metrigo/internal/agent"
+	"github.com/Matyjash/Metrigo/internal/check"
+	"github.com/Matyjash/Metrigo/internal/collector"
+	"github.com/Matyjash/Metrigo/internal/config"
 	"github.com/Matyjash/Metrigo/internal/metrigo"
-	"github.com/Matyjash/Metrigo/internal/server"
-	"github.com/Matyjash/Metrigo/pb"
-	"google.golang.org/grpc"
+	"github.com/Matyjash/Metrigo/internal/models"
 )
 
-const listenPort = ":50051"
-
 var version = "dev"
 
 func main() {
+	// Check mode output is parsed by monitoring systems, so it must not be preceded by any other output.
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+
 	fmt.Printf("Metrigo version: %s\n", version)
 
 	serverMode := flag.Bool("server", false, "Run in server mode")
+	configPath := flag.String("config", "", "Path to the YAML config file")
 	help := flag.Bool("help", false, "Show help")
 	flag.Parse()
 
-	metrigo := metrigo.NewMetrigo()
+	if *help {
+		printHelp()
+	}
+
+	args := flag.Args()
+	m := metrigo.NewMetrigo()
+	registry := metrigo.NewRegistry(&m)
+
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(runConfigCommand(args[1:], *configPath, registry.Names()))
+	}
+
+	cfg, err := config.Load(*configPath, registry.Names())
+	if err != nil {
+		fmt.Printf("Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+
 	if *serverMode {
-		if err := runGrpcServer(metrigo); err != nil {
+		if err := runServer(cfg, *configPath); err != nil {
 			fmt.Printf("Error starting server: %v\n", err)
 			os.Exit(1)
 		}
 		return
 	}
 
-	if *help {
-		printHelp()
-	}
+	m.Configure(cfg.Collectors)
 
 	fmt.Println("Running in CLI mode")
 
-	args := flag.Args()
 	if len(args) == 0 {
-		fmt.Println("No command provided. Available commands: cpu, temp")
+		fmt.Printf("No command provided. Available commands: %s\n", strings.Join(commandNames(registry), ", "))
 		os.Exit(1)
 	}
-	if len(args) > 1 {
+	if len(args) > 1 && !commands[args[0]].parsesFlags {
 		fmt.Println("The count of provided arguments is more than one. Trying to proceed with the first one.")
 	}
 
-	returnMessage, err := handleCommand(metrigo, args[0])
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	returnMessage, err := handleCommand(ctx, &m, registry, args[0], args[1:])
+	stop()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -55,66 +81,326 @@ func main() {
 	fmt.Println(returnMessage)
 }
 
-func runGrpcServer(metrigo metrigo.Metrigo) error {
-	lis, err := net.Listen("tcp", listenPort)
-	if err != nil {
-		return fmt.Errorf("failed to listen: %v", err)
+func runServer(cfg config.Config, configPath string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return agent.New(cfg, configPath, version).Run(ctx)
+}
+
+func runConfigCommand(args []string, configPath string, knownCollectors []string) int {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Println("Usage: metrigo config validate [path]")
+		return 1
+	}
+	if len(args) > 1 {
+		configPath = args[1]
+	}
+	if configPath == "" {
+		fmt.Println("No config file provided. Pass it as an argument or with the --config flag.")
+		return 1
 	}
-	s := grpc.NewServer()
-	pb.RegisterMetrigoServer(s, server.NewServer(metrigo))
-	fmt.Printf("Server is running on port %s\n", listenPort)
-	if err := s.Serve(lis); err != nil {
-		return fmt.Errorf("failed to serve: %v", err)
+
+	cfg, err := config.Load(configPath, knownCollectors)
+	if err == nil {
+		err = agent.ValidateAlertRules(cfg.Alerts.Rules)
 	}
-	return nil
+	if err != nil {
+		fmt.Printf("Config %s is invalid:\n%v\n", configPath, err)
+		return 1
+	}
+	fmt.Printf("Config %s is valid\n", configPath)
+	return 0
+}
+
+// command renders a command of the CLI that does more than list the samples of its collector.
+type command struct {
+	// parsesFlags is set for the commands that parse the arguments following them with their own flag set.
+	parsesFlags bool
+	run         func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error)
 }
 
-func handleCommand(metrigoMetrics metrigo.Metrigo, command string) (string, error) {
-	switch command {
-	case "cpu":
-		cpuInfo, err := metrigoMetrics.GetCpuInfo()
+// commands are the commands with their own rendering. The other collectors of the registry are
+// available as commands listing their samples.
+var commands = map[string]command{
+	"cpu": {run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		cpuInfo, cpuStats, err := metrigoMetrics.GetCpuInfoAndStats(ctx)
+		if err != nil {
+			return "", err
+		}
+		cpuTopology, err := metrigoMetrics.GetCpuTopology(ctx)
 		if err != nil {
 			return "", err
 		}
-		return metrigo.CpuMessage(cpuInfo), nil
-	case "temp":
-		temps, err := metrigoMetrics.GetTemperatures()
+		return metrigo.CpuMessage(cpuInfo) + "\n" + metrigo.CpuStatsMessage(cpuStats) + "\n" + metrigo.CpuTopologyMessage(cpuTopology), nil
+	}},
+	"cpufreq": {run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		frequencies, err := metrigoMetrics.GetCpuFrequencies(ctx)
 		if err != nil {
 			return "", err
 		}
+		return metrigo.CpuFrequenciesMessage(frequencies), nil
+	}},
+	"temp": {parsesFlags: true, run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		tempFlags := flag.NewFlagSet("temp", flag.ContinueOnError)
+		chip := tempFlags.String("chip", "", "Show only the sensors of the chip, e.g. coretemp")
+		if err := tempFlags.Parse(args); err != nil {
+			return "", err
+		}
+		temps, err := metrigoMetrics.GetTemperatures(ctx)
+		if err != nil {
+			return "", err
+		}
+		if *chip != "" {
+			temps = metrigo.FilterTemperatures(temps, *chip)
+			if len(temps) == 0 {
+				return "", fmt.Errorf("no temperature sensors of chip %s", *chip)
+			}
+		}
 		return metrigo.TempMessage(temps), nil
-	case "mem":
-		memoryUsage, err := metrigoMetrics.GetMemoryUsage()
+	}},
+	"mem": {run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		memoryUsage, err := metrigoMetrics.GetMemoryUsage(ctx)
 		if err != nil {
 			return "", err
 		}
 		return metrigo.MemoryUsageMessage(memoryUsage), nil
-	case "host":
-		hostInfo, err := metrigoMetrics.GetHostInfo()
+	}},
+	"host": {run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		hostInfo, err := metrigoMetrics.GetHostInfo(ctx)
 		if err != nil {
 			return "", err
 		}
 		return metrigo.HostInfoMessage(hostInfo), nil
-	case "net":
-		netInterfaces, err := metrigoMetrics.GetNetInterfaces()
+	}},
+	"net": {run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		netInterfaces, err := metrigoMetrics.GetNetInterfaces(ctx)
 		if err != nil {
 			return "", err
 		}
 		return metrigo.NetInterfacesMessage(netInterfaces), nil
-	default:
-		return "", fmt.Errorf("unknown command: %s. Available commands: cpu, temp, mem", command)
+	}},
+	"containers": {run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		containers, err := metrigoMetrics.GetContainers(ctx)
+		if err != nil {
+			return "", err
+		}
+		return metrigo.ContainersMessage(containers), nil
+	}},
+	"conns": {parsesFlags: true, run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		connsFlags := flag.NewFlagSet("conns", flag.ContinueOnError)
+		port := connsFlags.Uint("port", 0, "Show only the sockets with the local or remote port")
+		state := connsFlags.String("state", "", "Show only the sockets in the state, e.g. ESTABLISHED, LISTEN or TIME_WAIT")
+		if err := connsFlags.Parse(args); err != nil {
+			return "", err
+		}
+		stats, err := metrigoMetrics.GetSocketStats(ctx)
+		if err != nil {
+			return "", err
+		}
+		stats.Connections = metrigo.FilterConnections(stats.Connections, uint32(*port), *state)
+		return metrigo.ConnectionsMessage(stats), nil
+	}},
+	"systemd": {parsesFlags: true, run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		systemdFlags := flag.NewFlagSet("systemd", flag.ContinueOnError)
+		unit := systemdFlags.String("unit", "", "Show only the units matching the glob pattern, e.g. nginx* or *.mount")
+		failed := systemdFlags.Bool("failed", false, "Show only the failed units")
+		if err := systemdFlags.Parse(args); err != nil {
+			return "", err
+		}
+		units, err := metrigoMetrics.GetSystemdUnitsMatching(ctx, *unit)
+		if err != nil {
+			return "", err
+		}
+		if *failed {
+			units = metrigo.FailedSystemdUnits(units)
+		}
+		return metrigo.SystemdUnitsMessage(units), nil
+	}},
+	"limits": {parsesFlags: true, run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		limitsFlags := flag.NewFlagSet("limits", flag.ContinueOnError)
+		top := limitsFlags.Int("top", 10, "Number of processes closest to their open files limit to show")
+		if err := limitsFlags.Parse(args); err != nil {
+			return "", err
+		}
+		limits, err := metrigoMetrics.GetKernelLimits(ctx)
+		if err != nil {
+			return "", err
+		}
+		return metrigo.LimitsMessage(limits, *top), nil
+	}},
+	"procgroups": {parsesFlags: true, run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		groupsFlags := flag.NewFlagSet("procgroups", flag.ContinueOnError)
+		group := groupsFlags.String("group", "", "Show only the configured group, or name the group selected by the flags below")
+		process := groupsFlags.String("process", "", "Group the processes whose name or command line matches the regular expression")
+		user := groupsFlags.String("user", "", "Group the processes of the user")
+		cgroup := groupsFlags.String("cgroup", "", "Group the processes of the cgroup and its descendants")
+		unit := groupsFlags.String("unit", "", "Group the processes of the systemd units matching the glob pattern")
+		if err := groupsFlags.Parse(args); err != nil {
+			return "", err
+		}
+		if *process != "" || *user != "" || *cgroup != "" || *unit != "" {
+			name := *group
+			if name == "" {
+				name = "processes"
+			}
+			metrigoMetrics.SetProcessGroups([]models.ProcessGroupSpec{{Name: name, Process: *process, User: *user, Cgroup: *cgroup, Unit: *unit}})
+		}
+		groups, err := metrigoMetrics.GetProcessGroups(ctx)
+		if err != nil {
+			return "", err
+		}
+		if *group != "" {
+			groups = slices.DeleteFunc(groups, func(g models.ProcessGroup) bool { return g.Name != *group })
+			if len(groups) == 0 {
+				return "", fmt.Errorf("no process group named %s", *group)
+			}
+		}
+		return metrigo.ProcGroupsMessage(groups), nil
+	}},
+	"meminfo": {run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		details, err := metrigoMetrics.GetMemoryDetails(ctx)
+		if err != nil {
+			return "", err
+		}
+		return metrigo.MemInfoMessage(details), nil
+	}},
+	"mounts": {parsesFlags: true, run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		mountsFlags := flag.NewFlagSet("mounts", flag.ContinueOnError)
+		unhealthy := mountsFlags.Bool("unhealthy", false, "Show only the stale, remounted read-only and out of inodes mounts")
+		if err := mountsFlags.Parse(args); err != nil {
+			return "", err
+		}
+		mounts, err := metrigoMetrics.GetMounts(ctx)
+		if err != nil {
+			return "", err
+		}
+		if *unhealthy {
+			mounts = metrigo.UnhealthyMounts(mounts)
+		}
+		return metrigo.MountsMessage(mounts), nil
+	}},
+	"psi": {run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		pressure, err := metrigoMetrics.GetPressure(ctx)
+		if err != nil {
+			return "", err
+		}
+		return metrigo.PsiMessage(pressure), nil
+	}},
+	"sensors": {run: func(ctx context.Context, metrigoMetrics *metrigo.Metrigo, args []string) (string, error) {
+		sensors, err := metrigoMetrics.GetHwmonSensors(ctx)
+		if err != nil {
+			return "", err
+		}
+		return metrigo.SensorsMessage(sensors), nil
+	}},
+}
+
+func handleCommand(ctx context.Context, metrigoMetrics *metrigo.Metrigo, registry *collector.Registry, name string, args []string) (string, error) {
+	if name == "list" {
+		return metrigo.CollectorsMessage(registry.Collectors()), nil
+	}
+	if cmd, ok := commands[name]; ok {
+		return cmd.run(ctx, metrigoMetrics, args)
+	}
+	c, ok := registry.Get(name)
+	if !ok {
+		return "", fmt.Errorf("unknown command: %s. Available commands: %s", name, strings.Join(commandNames(registry), ", "))
+	}
+	samples, err := c.Collect(ctx)
+	if err != nil {
+		return "", err
+	}
+	return metrigo.SamplesMessage(c.Name(), samples), nil
+}
+
+// commandNames returns the sorted names of the commands, including the collectors of the registry.
+func commandNames(registry *collector.Registry) []string {
+	names := append([]string{"list"}, registry.Names()...)
+	for name := range commands {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func runCheck(args []string) int {
+	checkFlags := flag.NewFlagSet("check", flag.ContinueOnError)
+	warn := checkFlags.Float64("warn", 0, "Warning threshold")
+	crit := checkFlags.Float64("crit", 0, "Critical threshold")
+	timeout := checkFlags.Duration("timeout", config.Default().Collectors.Timeout, "Collection timeout")
+	configPath := checkFlags.String("config", "", "Path to the YAML config file")
+	pattern := checkFlags.String("pattern", "", "Glob pattern of the systemd units evaluated by the systemd check, e.g. \"nginx*\"")
+
+	if len(args) == 0 {
+		fmt.Printf("UNKNOWN - no check family provided. Available families: %s\n", strings.Join(check.Families, ", "))
+		return int(check.StatusUnknown)
 	}
+	family := args[0]
+	if err := checkFlags.Parse(args[1:]); err != nil {
+		fmt.Printf("UNKNOWN - %v\n", err)
+		return int(check.StatusUnknown)
+	}
+	if !isFlagSet(checkFlags, "warn") || !isFlagSet(checkFlags, "crit") {
+		fmt.Println("UNKNOWN - both --warn and --crit thresholds are required")
+		return int(check.StatusUnknown)
+	}
+
+	// The config is applied as in the other modes, so that a check run in a container reads the host's roots.
+	m := metrigo.NewMetrigo()
+	cfg, err := config.Load(*configPath, metrigo.NewRegistry(&m).Names())
+	if err != nil {
+		fmt.Printf("UNKNOWN - invalid configuration: %v\n", strings.ReplaceAll(err.Error(), "\n", "; "))
+		return int(check.StatusUnknown)
+	}
+	m.Configure(cfg.Collectors)
+	if isFlagSet(checkFlags, "timeout") {
+		m.SetTimeouts(*timeout, cfg.Collectors.Timeouts)
+	}
+	result := check.Run(context.Background(), &m, family, check.Thresholds{Warn: *warn, Crit: *crit}, *pattern)
+	fmt.Println(result.String())
+	return result.ExitCode()
+}
+
+func isFlagSet(flagSet *flag.FlagSet, name string) bool {
+	set := false
+	flagSet.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
 
 func printHelp() {
-	fmt.Println("Usage: metrigo [--server] [command]")
+	fmt.Println("Usage: metrigo [--server] [--config path] [command]")
+	fmt.Println("       metrigo temp [--chip name]")
+	fmt.Println("       metrigo check <family> --warn X --crit Y [--timeout d] [--config path] [--pattern glob]")
+	fmt.Println("       metrigo config validate [path]")
 	fmt.Println("Flags:")
 	flag.PrintDefaults()
 	fmt.Println("\nAvailable commands:")
-	fmt.Println("  cpu   Show CPU metrics")
-	fmt.Println("  temp  Show temperature sensors")
+	fmt.Println("  cpu   Show CPU usage, frequency, time breakdown and topology")
+	fmt.Println("  cpufreq  Show current frequency, scaling limits, governor and thermal throttling per CPU")
+	fmt.Println("  temp  Show temperature sensors grouped by chip, with their thresholds")
 	fmt.Println("  mem   Show memory usage")
 	fmt.Println("  host  Show host info")
 	fmt.Println("  net   Show network interfaces")
+	fmt.Println("  disk  Show disk usage")
+	fmt.Println("  load  Show load averages")
+	fmt.Println("  cgroup  Show CPU, memory and I/O of the agent's cgroup")
+	fmt.Println("  containers  Show running containers of the local container runtime")
+	fmt.Println("  conns Show TCP and UDP sockets by state, listeners and protocol counters (--port, --state)")
+	fmt.Println("  systemd  Show systemd units with their state, restarts and accounting (--unit, --failed)")
+	fmt.Println("  limits  Show file handles, conntrack, pids and entropy against their limits, and processes near their open files limit (--top)")
+	fmt.Println("  procgroups  Show CPU, memory, I/O, threads and open files of process groups (--group, --process, --user, --cgroup, --unit)")
+	fmt.Println("  meminfo  Show slab, dirty pages, overcommit, hugepage pools, transparent hugepages and NUMA node memory")
+	fmt.Println("  mounts  Show mount options, read-only remounts, stale network mounts and inode usage (--unhealthy)")
+	fmt.Println("  psi   Show CPU, memory and I/O pressure stall information of the host and the agent's cgroup")
+	fmt.Println("  sensors  Show fan, voltage, power and current sensors")
+	fmt.Println("  list  List the available collectors and their metrics")
+	fmt.Println("  check Run a Nagios/Icinga compatible check (families: cpu, mem, temp, disk, load, systemd, mounts)")
+	fmt.Println("  config validate  Validate the config file")
 	os.Exit(0)
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/collector"
+	"github.com/Matyjash/Metrigo/internal/metrigo"
+)
+
+func Test_handleCommand(t *testing.T) {
+	m := metrigo.NewMetrigo()
+	registry := metrigo.NewRegistry(&m)
+	registry.MustRegister(collector.New("queue", "Length of the work queue",
+		[]collector.Descriptor{{Name: "queue_length", Help: "Number of queued jobs."}},
+		func(ctx context.Context) ([]collector.Sample, error) {
+			return []collector.Sample{{Metric: "queue_length", Labels: map[string]string{"queue": "mail"}, Value: 3}}, nil
+		}))
+
+	tests := []struct {
+		name            string
+		command         string
+		wantContains    []string
+		wantErrContains []string
+	}{
+		{
+			name:         "collector registered only in the registry",
+			command:      "queue",
+			wantContains: []string{"queue metrics:", "queue_length{queue=mail}: 3"},
+		},
+		{
+			name:         "list includes the registered collector",
+			command:      "list",
+			wantContains: []string{"queue", "Length of the work queue"},
+		},
+		{
+			name:            "unknown command lists the available ones",
+			command:         "gpu",
+			wantErrContains: []string{"unknown command: gpu", "queue", "cpufreq", "sensors"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := handleCommand(context.Background(), &m, registry, tt.command, nil)
+			if len(tt.wantErrContains) > 0 {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErrContains)
+				}
+				for _, substr := range tt.wantErrContains {
+					if !strings.Contains(err.Error(), substr) {
+						t.Errorf("expected error containing %q, got \"%v\"", substr, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, substr := range tt.wantContains {
+				if !strings.Contains(message, substr) {
+					t.Errorf("expected message containing %q, got %q", substr, message)
+				}
+			}
+		})
+	}
+}
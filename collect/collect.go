@@ -0,0 +1,367 @@
+// Package collect embeds Metrigo's collection in another Go program, without running the agent:
+//
+//	set, err := collect.New(collect.WithCollectors("cpu", "mem"), collect.WithTimeout(5*time.Second))
+//	if err != nil {
+//		return err
+//	}
+//	snapshot := set.Snapshot(ctx)
+//	memoryUsage, err := set.MemoryUsage(ctx)
+//
+// Metrics are read with gopsutil by default. WithPuller replaces it, e.g. with a fake in tests
+// or a puller reading another host's metrics.
+package collect
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/collector"
+	"github.com/Matyjash/Metrigo/internal/metrics"
+	"github.com/Matyjash/Metrigo/internal/metrigo"
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+type (
+	CpuInfo              = models.CpuInfo
+	CpuSpec              = models.CpuSpec
+	CpuTimes             = models.CpuTimes
+	CpuStats             = models.CpuStats
+	CpuTopology          = models.CpuTopology
+	CpuFrequency         = models.CpuFrequency
+	CpuSocket            = models.CpuSocket
+	CpuCore              = models.CpuCore
+	TemperatureSensor    = models.TemperatureSensor
+	MemoryUsage          = models.MemoryUsage
+	HostInfo             = models.HostInfo
+	UserSession          = models.UserSession
+	NetInterface         = models.NetInterface
+	DiskUsage            = models.DiskUsage
+	LoadAverage          = models.LoadAverage
+	CgroupStats          = models.CgroupStats
+	CgroupCpuStats       = models.CgroupCpuStats
+	CgroupMemoryStats    = models.CgroupMemoryStats
+	CgroupIOStats        = models.CgroupIOStats
+	ContainerStats       = models.ContainerStats
+	HwmonSensor          = models.HwmonSensor
+	PressureStats        = models.PressureStats
+	ResourcePressure     = models.ResourcePressure
+	PressureStall        = models.PressureStall
+	SocketStats          = models.SocketStats
+	Connection           = models.Connection
+	ProtocolCounters     = models.ProtocolCounters
+	SystemdUnit          = models.SystemdUnit
+	KernelLimits         = models.KernelLimits
+	ProcessFds           = models.ProcessFds
+	ProcessStats         = models.ProcessStats
+	ProcessGroupSpec     = models.ProcessGroupSpec
+	ProcessGroup         = models.ProcessGroup
+	MemoryDetails        = models.MemoryDetails
+	HugePagePool         = models.HugePagePool
+	TransparentHugePages = models.TransparentHugePages
+	NumaNode             = models.NumaNode
+	MountStatus          = models.MountStatus
+)
+
+// Roots are the directories the host filesystems are mounted at. Empty fields use the default paths.
+type Roots = metrics.Roots
+
+var (
+	ErrNoCpus               = metrics.ErrNoCpus
+	ErrNoTemperatureSensors = metrics.ErrNoTemperatureSensors
+	ErrNoDiskPartitions     = metrics.ErrNoDiskPartitions
+	ErrNoContainerRuntime   = metrics.ErrNoContainerRuntime
+	ErrNoHwmonSensors       = metrics.ErrNoHwmonSensors
+	ErrNoCpuFreq            = metrics.ErrNoCpuFreq
+	ErrNoSystemd            = metrics.ErrNoSystemd
+	ErrNoCgroup             = metrics.ErrNoCgroup
+	ErrNotSupported         = metrics.ErrNotSupported
+)
+
+type (
+	CollectorError = metrigo.CollectorError
+	ErrorKind      = metrigo.ErrorKind
+)
+
+const (
+	KindInternal    = metrigo.KindInternal
+	KindNotFound    = metrigo.KindNotFound
+	KindUnsupported = metrigo.KindUnsupported
+	KindTimeout     = metrigo.KindTimeout
+	KindCanceled    = metrigo.KindCanceled
+)
+
+// Names of the built-in collectors.
+const (
+	CollectorCpu        = metrigo.CollectorCpu
+	CollectorTemp       = metrigo.CollectorTemp
+	CollectorMem        = metrigo.CollectorMem
+	CollectorHost       = metrigo.CollectorHost
+	CollectorNet        = metrigo.CollectorNet
+	CollectorDisk       = metrigo.CollectorDisk
+	CollectorLoad       = metrigo.CollectorLoad
+	CollectorCgroup     = metrigo.CollectorCgroup
+	CollectorContainers = metrigo.CollectorContainers
+	CollectorHwmon      = metrigo.CollectorHwmon
+	CollectorPsi        = metrigo.CollectorPsi
+	CollectorConns      = metrigo.CollectorConns
+	CollectorSystemd    = metrigo.CollectorSystemd
+	CollectorLimits     = metrigo.CollectorLimits
+	CollectorProcGroups = metrigo.CollectorProcGroups
+	CollectorMemInfo    = metrigo.CollectorMemInfo
+	CollectorMounts     = metrigo.CollectorMounts
+)
+
+// Health states of the mounts returned by Set.Mounts.
+const (
+	MountHealthStale           = metrigo.MountHealthStale
+	MountHealthReadOnly        = metrigo.MountHealthReadOnly
+	MountHealthInodesExhausted = metrigo.MountHealthInodesExhausted
+	MountHealthOK              = metrigo.MountHealthOK
+)
+
+// Set is a set of collectors sharing a MetricsPuller. It is safe for concurrent use.
+type Set struct {
+	metrigo  *metrigo.Metrigo
+	registry *collector.Registry
+	enabled  []string
+}
+
+// New returns a Set of the built-in collectors and the ones added with WithCollector.
+func New(opts ...Option) (*Set, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	m := metrigo.NewMetrigo()
+	if o.puller != nil {
+		m = metrigo.NewMetrigoWithPuller(pullerAdapter{o.puller})
+	}
+	m.SetMeasureInterval(o.measureInterval)
+	m.SetTimeouts(o.timeout, o.timeouts)
+	m.SetCgroupPath(o.cgroupPath)
+	m.SetContainerSocket(o.containerSocket)
+	m.SetProcessGroups(o.processGroups)
+	m.SetMountStatTimeout(o.mountTimeout)
+	m.SetRoots(o.roots)
+
+	registry := metrigo.NewRegistry(&m)
+	for _, c := range o.collectors {
+		if err := registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range o.enabled {
+		if _, ok := registry.Get(name); !ok {
+			return nil, fmt.Errorf("unknown collector %q, available: %s", name, strings.Join(registry.Names(), ", "))
+		}
+	}
+	for name := range o.timeouts {
+		if _, ok := registry.Get(name); !ok {
+			return nil, fmt.Errorf("timeout set for unknown collector %q", name)
+		}
+	}
+
+	return &Set{metrigo: &m, registry: registry, enabled: o.enabled}, nil
+}
+
+// Collectors returns the enabled collectors.
+func (s *Set) Collectors() []Collector {
+	var collectors []Collector
+	for _, c := range s.registry.Collectors() {
+		if s.isEnabled(c.Name()) {
+			collectors = append(collectors, c)
+		}
+	}
+	return collectors
+}
+
+func (s *Set) isEnabled(name string) bool {
+	return len(s.enabled) == 0 || slices.Contains(s.enabled, name)
+}
+
+func (s *Set) checkEnabled(name string) error {
+	if !s.isEnabled(name) {
+		return fmt.Errorf("collector %s is disabled", name)
+	}
+	return nil
+}
+
+// Snapshot runs the enabled collectors, all of them when none are given. A failing collector is
+// reported in its result. Asking for an unknown or disabled collector fails its result as well.
+func (s *Set) Snapshot(ctx context.Context, collectors ...string) Snapshot {
+	if len(collectors) == 0 {
+		for _, c := range s.Collectors() {
+			collectors = append(collectors, c.Name())
+		}
+	}
+
+	snapshot := Snapshot{Time: time.Now(), Results: make([]CollectorResult, len(collectors))}
+	for i, name := range collectors {
+		result := CollectorResult{Collector: name}
+		c, ok := s.registry.Get(name)
+		switch {
+		case !ok:
+			result.Err = fmt.Errorf("unknown collector %s", name)
+		case !s.isEnabled(name):
+			result.Err = s.checkEnabled(name)
+		default:
+			result.Samples, result.Err = c.Collect(ctx)
+		}
+		snapshot.Results[i] = result
+	}
+	return snapshot
+}
+
+func (s *Set) CpuInfo(ctx context.Context) ([]CpuInfo, error) {
+	if err := s.checkEnabled(CollectorCpu); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetCpuInfo(ctx)
+}
+
+func (s *Set) TotalCpuUsage(ctx context.Context) (float64, error) {
+	if err := s.checkEnabled(CollectorCpu); err != nil {
+		return 0, err
+	}
+	return s.metrigo.GetTotalCpuUsage(ctx)
+}
+
+func (s *Set) CpuStats(ctx context.Context) (CpuStats, error) {
+	if err := s.checkEnabled(CollectorCpu); err != nil {
+		return CpuStats{}, err
+	}
+	return s.metrigo.GetCpuStats(ctx)
+}
+
+func (s *Set) CpuTopology(ctx context.Context) (CpuTopology, error) {
+	if err := s.checkEnabled(CollectorCpu); err != nil {
+		return CpuTopology{}, err
+	}
+	return s.metrigo.GetCpuTopology(ctx)
+}
+
+func (s *Set) CpuFrequencies(ctx context.Context) ([]CpuFrequency, error) {
+	if err := s.checkEnabled(CollectorCpu); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetCpuFrequencies(ctx)
+}
+
+func (s *Set) Temperatures(ctx context.Context) ([]TemperatureSensor, error) {
+	if err := s.checkEnabled(CollectorTemp); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetTemperatures(ctx)
+}
+
+func (s *Set) MemoryUsage(ctx context.Context) (MemoryUsage, error) {
+	if err := s.checkEnabled(CollectorMem); err != nil {
+		return MemoryUsage{}, err
+	}
+	return s.metrigo.GetMemoryUsage(ctx)
+}
+
+func (s *Set) HostInfo(ctx context.Context) (HostInfo, error) {
+	if err := s.checkEnabled(CollectorHost); err != nil {
+		return HostInfo{}, err
+	}
+	return s.metrigo.GetHostInfo(ctx)
+}
+
+func (s *Set) NetInterfaces(ctx context.Context) ([]NetInterface, error) {
+	if err := s.checkEnabled(CollectorNet); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetNetInterfaces(ctx)
+}
+
+func (s *Set) DisksUsage(ctx context.Context) ([]DiskUsage, error) {
+	if err := s.checkEnabled(CollectorDisk); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetDisksUsage(ctx)
+}
+
+func (s *Set) LoadAverage(ctx context.Context) (LoadAverage, error) {
+	if err := s.checkEnabled(CollectorLoad); err != nil {
+		return LoadAverage{}, err
+	}
+	return s.metrigo.GetLoadAverage(ctx)
+}
+
+func (s *Set) CgroupStats(ctx context.Context) (CgroupStats, error) {
+	if err := s.checkEnabled(CollectorCgroup); err != nil {
+		return CgroupStats{}, err
+	}
+	return s.metrigo.GetCgroupStats(ctx)
+}
+
+func (s *Set) Containers(ctx context.Context) ([]ContainerStats, error) {
+	if err := s.checkEnabled(CollectorContainers); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetContainers(ctx)
+}
+
+func (s *Set) HwmonSensors(ctx context.Context) ([]HwmonSensor, error) {
+	if err := s.checkEnabled(CollectorHwmon); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetHwmonSensors(ctx)
+}
+
+func (s *Set) Pressure(ctx context.Context) ([]PressureStats, error) {
+	if err := s.checkEnabled(CollectorPsi); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetPressure(ctx)
+}
+
+func (s *Set) SocketStats(ctx context.Context) (SocketStats, error) {
+	if err := s.checkEnabled(CollectorConns); err != nil {
+		return SocketStats{}, err
+	}
+	return s.metrigo.GetSocketStats(ctx)
+}
+
+func (s *Set) SystemdUnits(ctx context.Context) ([]SystemdUnit, error) {
+	if err := s.checkEnabled(CollectorSystemd); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetSystemdUnits(ctx)
+}
+
+func (s *Set) KernelLimits(ctx context.Context) (KernelLimits, error) {
+	if err := s.checkEnabled(CollectorLimits); err != nil {
+		return KernelLimits{}, err
+	}
+	return s.metrigo.GetKernelLimits(ctx)
+}
+
+// ProcessGroups returns the usage of the groups added with WithProcessGroup.
+func (s *Set) ProcessGroups(ctx context.Context) ([]ProcessGroup, error) {
+	if err := s.checkEnabled(CollectorProcGroups); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetProcessGroups(ctx)
+}
+
+func (s *Set) MemoryDetails(ctx context.Context) (MemoryDetails, error) {
+	if err := s.checkEnabled(CollectorMemInfo); err != nil {
+		return MemoryDetails{}, err
+	}
+	return s.metrigo.GetMemoryDetails(ctx)
+}
+
+// Mounts returns the mounted filesystems with their health, see the MountHealth* values.
+func (s *Set) Mounts(ctx context.Context) ([]MountStatus, error) {
+	if err := s.checkEnabled(CollectorMounts); err != nil {
+		return nil, err
+	}
+	return s.metrigo.GetMounts(ctx)
+}
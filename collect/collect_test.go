@@ -0,0 +1,221 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakePuller struct{}
+
+func (f *fakePuller) GetCpuUsage(ctx context.Context, perCpu bool, interval time.Duration) ([]float64, error) {
+	if perCpu {
+		return []float64{10, 30}, nil
+	}
+	return []float64{20}, nil
+}
+func (f *fakePuller) GetPhysicalCpuCount(ctx context.Context) (int, error) {
+	return 1, nil
+}
+func (f *fakePuller) GetLogicalCpuCount(ctx context.Context) (int, error) {
+	return 2, nil
+}
+func (f *fakePuller) GetCpusSpec(ctx context.Context) ([]CpuSpec, error) {
+	return []CpuSpec{{FrequencyMhz: 2000}, {FrequencyMhz: 2100}}, nil
+}
+func (f *fakePuller) GetCpuStats(ctx context.Context, interval time.Duration) (CpuStats, error) {
+	return CpuStats{Total: CpuTimes{User: 20, Idle: 80}, PerCpu: []CpuTimes{{User: 10, Idle: 90}, {User: 30, Idle: 70}}}, nil
+}
+func (f *fakePuller) GetCpuFrequencies(ctx context.Context) ([]CpuFrequency, error) {
+	return nil, ErrNoCpuFreq
+}
+func (f *fakePuller) GetVMMemoryUsage(ctx context.Context) (MemoryUsage, error) {
+	return MemoryUsage{UsedB: 250, TotalB: 1000}, nil
+}
+func (f *fakePuller) GetTemperatures(ctx context.Context) ([]TemperatureSensor, error) {
+	return nil, ErrNoTemperatureSensors
+}
+func (f *fakePuller) GetHostInfo(ctx context.Context) (HostInfo, error) {
+	return HostInfo{Hostname: "embedded"}, nil
+}
+func (f *fakePuller) GetNetInterfaces(ctx context.Context) ([]NetInterface, error) {
+	return nil, nil
+}
+func (f *fakePuller) GetDisksUsage(ctx context.Context) ([]DiskUsage, error) {
+	return nil, ErrNoDiskPartitions
+}
+func (f *fakePuller) GetLoadAverage(ctx context.Context) (LoadAverage, error) {
+	return LoadAverage{Load1: 0.5, Load5: 1, Load15: 1.5}, nil
+}
+
+func (f *fakePuller) GetCgroupStats(ctx context.Context, path string) (CgroupStats, error) {
+	return CgroupStats{Path: path, Version: 2}, nil
+}
+
+func (f *fakePuller) GetContainers(ctx context.Context, socket string) ([]ContainerStats, error) {
+	return nil, nil
+}
+
+func (f *fakePuller) GetKernelLimits(ctx context.Context) (KernelLimits, error) {
+	return KernelLimits{OpenFiles: 100, MaxFiles: 1000}, nil
+}
+
+func (f *fakePuller) GetProcesses(ctx context.Context, interval time.Duration) ([]ProcessStats, error) {
+	return []ProcessStats{{Pid: 42, Name: "nginx", CpuPercent: 1.5}}, nil
+}
+
+func (f *fakePuller) GetMemoryDetails(ctx context.Context) (MemoryDetails, error) {
+	return MemoryDetails{TotalB: 1000, FreeB: 750}, nil
+}
+
+func (f *fakePuller) GetMounts(ctx context.Context, statTimeout time.Duration) ([]MountStatus, error) {
+	return []MountStatus{{Path: "/", Fstype: "ext4", Options: []string{"rw"}}}, nil
+}
+
+func (f *fakePuller) GetSystemdUnits(ctx context.Context, patterns []string) ([]SystemdUnit, error) {
+	return []SystemdUnit{{Name: "nginx.service", ActiveState: "active"}}, nil
+}
+
+func (f *fakePuller) GetSocketStats(ctx context.Context) (SocketStats, error) {
+	return SocketStats{Connections: []Connection{{Protocol: "tcp", LocalPort: 22, State: "LISTEN"}}}, nil
+}
+
+func (f *fakePuller) GetPressure(ctx context.Context, cgroupPath string) ([]PressureStats, error) {
+	return []PressureStats{{Resources: []ResourcePressure{{Resource: "cpu", Some: PressureStall{Avg10: 1.5}}}}}, nil
+}
+
+func (f *fakePuller) GetHwmonSensors(ctx context.Context) ([]HwmonSensor, error) {
+	return nil, ErrNoHwmonSensors
+}
+
+func Test_New(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []Option
+		wantCollectors  []string
+		wantErrContains string
+	}{
+		{
+			name:           "all built-in collectors by default",
+			wantCollectors: []string{"cpu", "temp", "mem", "host", "net", "disk", "load", "cgroup", "containers", "hwmon", "psi", "conns", "systemd", "limits", "procgroups", "meminfo", "mounts"},
+		},
+		{
+			name:           "enabled collectors only",
+			opts:           []Option{WithCollectors(CollectorMem, CollectorLoad)},
+			wantCollectors: []string{"mem", "load"},
+		},
+		{
+			name: "custom collector",
+			opts: []Option{
+				WithCollectors("queue"),
+				WithCollector(NewCollector("queue", "job queue", nil, nil)),
+			},
+			wantCollectors: []string{"queue"},
+		},
+		{
+			name:            "unknown enabled collector",
+			opts:            []Option{WithCollectors("gpu")},
+			wantErrContains: "unknown collector \"gpu\"",
+		},
+		{
+			name:            "custom collector clashing with a built-in one",
+			opts:            []Option{WithCollector(NewCollector(CollectorMem, "", nil, nil))},
+			wantErrContains: "mem",
+		},
+		{
+			name:            "timeout of unknown collector",
+			opts:            []Option{WithCollectorTimeout("gpu", time.Second)},
+			wantErrContains: "timeout set for unknown collector \"gpu\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := New(append(tt.opts, WithPuller(&fakePuller{}))...)
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, c := range set.Collectors() {
+				names = append(names, c.Name())
+			}
+			if !reflect.DeepEqual(tt.wantCollectors, names) {
+				t.Errorf("expected collectors %v, got %v", tt.wantCollectors, names)
+			}
+		})
+	}
+}
+
+func Test_SetCollection(t *testing.T) {
+	set, err := New(WithPuller(&fakePuller{}), WithCollectors(CollectorMem, CollectorTemp))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	memoryUsage, err := set.MemoryUsage(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (MemoryUsage{UsedB: 250, TotalB: 1000}); memoryUsage != want {
+		t.Errorf("expected %v, got %v", want, memoryUsage)
+	}
+
+	if _, err := set.LoadAverage(ctx); err == nil || !strings.Contains(err.Error(), "collector load is disabled") {
+		t.Errorf("expected disabled collector error, got \"%v\"", err)
+	}
+
+	snapshot := set.Snapshot(ctx)
+	mem, ok := snapshot.Result(CollectorMem)
+	if !ok || mem.Err != nil || len(mem.Samples) != 2 {
+		t.Errorf("unexpected mem result %+v", mem)
+	}
+	temp, _ := snapshot.Result(CollectorTemp)
+	var collectorErr *CollectorError
+	if !errors.As(temp.Err, &collectorErr) || collectorErr.Kind != KindNotFound {
+		t.Errorf("expected not found collector error, got \"%v\"", temp.Err)
+	}
+	if _, ok := snapshot.Result(CollectorLoad); ok {
+		t.Errorf("snapshot contains disabled collector %s", CollectorLoad)
+	}
+}
+
+func Test_SetPullerWithoutOptionalInterfaces(t *testing.T) {
+	// Only the methods of MetricsPuller are promoted by the embedded interface.
+	puller := struct{ MetricsPuller }{&fakePuller{}}
+	set, err := New(WithPuller(puller), WithCollectors(CollectorCpu, CollectorMem, CollectorMounts))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := set.MemoryUsage(ctx); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	cpuInfo, err := set.CpuInfo(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cpuInfo) != 2 || cpuInfo[1].UsagePercent != 30 || cpuInfo[1].FrequencyMhz != 2100 || cpuInfo[1].Times != (CpuTimes{}) {
+		t.Errorf("expected the usage and spec frequencies with empty times, got %+v", cpuInfo)
+	}
+	if _, err := set.CpuFrequencies(ctx); !errors.Is(err, ErrNoCpuFreq) {
+		t.Errorf("expected %v, got \"%v\"", ErrNoCpuFreq, err)
+	}
+	_, err = set.Mounts(ctx)
+	var collectorErr *CollectorError
+	if !errors.As(err, &collectorErr) || collectorErr.Kind != KindUnsupported || !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected unsupported collector error, got \"%v\"", err)
+	}
+	if !strings.Contains(err.Error(), "does not implement GetMounts") {
+		t.Errorf("expected the missing method in the error, got \"%v\"", err)
+	}
+}
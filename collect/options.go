@@ -0,0 +1,103 @@
+package collect
+
+import "time"
+
+type options struct {
+	puller          MetricsPuller
+	enabled         []string
+	collectors      []Collector
+	measureInterval time.Duration
+	timeout         time.Duration
+	timeouts        map[string]time.Duration
+	cgroupPath      string
+	containerSocket string
+	processGroups   []ProcessGroupSpec
+	mountTimeout    time.Duration
+	roots           Roots
+}
+
+// Option configures a Set.
+type Option func(*options)
+
+// WithPuller reads metrics from puller instead of gopsutil.
+func WithPuller(puller MetricsPuller) Option {
+	return func(o *options) {
+		o.puller = puller
+	}
+}
+
+// WithCollectors enables only the named collectors. All collectors are enabled without it.
+func WithCollectors(names ...string) Option {
+	return func(o *options) {
+		o.enabled = append(o.enabled, names...)
+	}
+}
+
+// WithCollector adds a custom collector to the set. Its name must not clash with a built-in one.
+func WithCollector(c Collector) Option {
+	return func(o *options) {
+		o.collectors = append(o.collectors, c)
+	}
+}
+
+// WithMeasureInterval sets the window over which CPU usage is measured, 200ms by default.
+func WithMeasureInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.measureInterval = interval
+	}
+}
+
+// WithTimeout limits how long a single collection may take. Collections are only limited
+// by the caller's context without it.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithCollectorTimeout overrides the timeout of one collector.
+func WithCollectorTimeout(collector string, timeout time.Duration) Option {
+	return func(o *options) {
+		if o.timeouts == nil {
+			o.timeouts = map[string]time.Duration{}
+		}
+		o.timeouts[collector] = timeout
+	}
+}
+
+// WithCgroupPath makes the cgroup collector read the cgroup at path, relative to the cgroupfs root,
+// instead of the cgroup of the current process.
+func WithCgroupPath(path string) Option {
+	return func(o *options) {
+		o.cgroupPath = path
+	}
+}
+
+// WithContainerSocket sets the Unix socket of the Docker compatible container runtime API, /var/run/docker.sock by default.
+func WithContainerSocket(socket string) Option {
+	return func(o *options) {
+		o.containerSocket = socket
+	}
+}
+
+// WithProcessGroup adds a group of processes whose usage is aggregated by the procgroups collector.
+func WithProcessGroup(group ProcessGroupSpec) Option {
+	return func(o *options) {
+		o.processGroups = append(o.processGroups, group)
+	}
+}
+
+// WithMountStatTimeout sets how long the usage of a mount is waited for before it is reported as stale, 5s by default.
+func WithMountStatTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.mountTimeout = timeout
+	}
+}
+
+// WithRoots reads the host filesystems from roots, e.g. when the program runs in a container
+// with the host's /proc mounted at /host/proc, or from fixture trees in tests.
+func WithRoots(roots Roots) Option {
+	return func(o *options) {
+		o.roots = roots
+	}
+}
@@ -0,0 +1,187 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/metrics"
+)
+
+// MetricsPuller reads raw metrics from the host. Errors wrapping one of the Err* values
+// are classified in the returned CollectorError.
+//
+// The other collectors read their metrics through the optional interfaces below. A puller that
+// does not implement one of them makes its collectors fail with ErrNotSupported, so new optional
+// interfaces can be added without breaking existing pullers. The CPU collector only needs the base
+// methods: without CpuStatsPuller the times are left empty, and without CpuFrequencyPuller the spec
+// frequencies are reported, as on hosts without cpufreq.
+type MetricsPuller interface {
+	GetCpuUsage(ctx context.Context, perCpu bool, interval time.Duration) ([]float64, error)
+	GetPhysicalCpuCount(ctx context.Context) (int, error)
+	GetLogicalCpuCount(ctx context.Context) (int, error)
+	GetCpusSpec(ctx context.Context) ([]CpuSpec, error)
+	GetVMMemoryUsage(ctx context.Context) (MemoryUsage, error)
+	GetTemperatures(ctx context.Context) ([]TemperatureSensor, error)
+	GetHostInfo(ctx context.Context) (HostInfo, error)
+	GetNetInterfaces(ctx context.Context) ([]NetInterface, error)
+	GetDisksUsage(ctx context.Context) ([]DiskUsage, error)
+	GetLoadAverage(ctx context.Context) (LoadAverage, error)
+}
+
+// CpuStatsPuller measures the time spent in each CPU state and the rate of context switches and interrupts over the interval.
+type CpuStatsPuller interface {
+	GetCpuStats(ctx context.Context, interval time.Duration) (CpuStats, error)
+}
+
+// CpuFrequencyPuller reads the live cpufreq state of each logical CPU.
+type CpuFrequencyPuller interface {
+	GetCpuFrequencies(ctx context.Context) ([]CpuFrequency, error)
+}
+
+// CgroupPuller reads the cgroup at path, relative to the cgroupfs root, or the current process's cgroup when path is empty.
+type CgroupPuller interface {
+	GetCgroupStats(ctx context.Context, path string) (CgroupStats, error)
+}
+
+// ContainerPuller reads the running containers of the runtime listening on the Unix socket.
+type ContainerPuller interface {
+	GetContainers(ctx context.Context, socket string) ([]ContainerStats, error)
+}
+
+// PressurePuller reads the host-wide Pressure Stall Information, followed by the one of the cgroup at cgroupPath.
+type PressurePuller interface {
+	GetPressure(ctx context.Context, cgroupPath string) ([]PressureStats, error)
+}
+
+// SocketPuller reads the TCP and UDP sockets and the protocol counters of the network stack.
+type SocketPuller interface {
+	GetSocketStats(ctx context.Context) (SocketStats, error)
+}
+
+// SystemdPuller reads the loaded systemd units matching the glob patterns, all of them when there are none.
+type SystemdPuller interface {
+	GetSystemdUnits(ctx context.Context, patterns []string) ([]SystemdUnit, error)
+}
+
+// KernelLimitsPuller reads the usage of the kernel tables and the file descriptors of each process.
+type KernelLimitsPuller interface {
+	GetKernelLimits(ctx context.Context) (KernelLimits, error)
+}
+
+// ProcessPuller reads the processes with their CPU usage and I/O rates measured over the interval.
+type ProcessPuller interface {
+	GetProcesses(ctx context.Context, interval time.Duration) ([]ProcessStats, error)
+}
+
+// MemoryDetailsPuller reads the breakdown of the memory with the hugepage pools and the NUMA nodes.
+type MemoryDetailsPuller interface {
+	GetMemoryDetails(ctx context.Context) (MemoryDetails, error)
+}
+
+// MountPuller reads the mounted filesystems, reporting the ones whose usage is not read within statTimeout as stale.
+type MountPuller interface {
+	GetMounts(ctx context.Context, statTimeout time.Duration) ([]MountStatus, error)
+}
+
+// HwmonPuller reads the fan, voltage, power and current sensors of the hardware monitoring chips.
+type HwmonPuller interface {
+	GetHwmonSensors(ctx context.Context) ([]HwmonSensor, error)
+}
+
+// pullerAdapter serves the internal puller interface with a public MetricsPuller and the optional
+// interfaces it implements.
+type pullerAdapter struct {
+	MetricsPuller
+}
+
+var _ metrics.MetricsPuller = pullerAdapter{}
+
+func (p pullerAdapter) unsupported(method string) error {
+	return fmt.Errorf("%w: %T does not implement %s", ErrNotSupported, p.MetricsPuller, method)
+}
+
+func (p pullerAdapter) GetCpuStats(ctx context.Context, interval time.Duration) (CpuStats, error) {
+	if puller, ok := p.MetricsPuller.(CpuStatsPuller); ok {
+		return puller.GetCpuStats(ctx, interval)
+	}
+	return CpuStats{}, p.unsupported("GetCpuStats")
+}
+
+func (p pullerAdapter) GetCpuFrequencies(ctx context.Context) ([]CpuFrequency, error) {
+	if puller, ok := p.MetricsPuller.(CpuFrequencyPuller); ok {
+		return puller.GetCpuFrequencies(ctx)
+	}
+	// The CPU collector then keeps the spec frequencies, as on hosts without cpufreq.
+	return nil, fmt.Errorf("%w: %T does not implement GetCpuFrequencies", ErrNoCpuFreq, p.MetricsPuller)
+}
+
+func (p pullerAdapter) GetCgroupStats(ctx context.Context, path string) (CgroupStats, error) {
+	if puller, ok := p.MetricsPuller.(CgroupPuller); ok {
+		return puller.GetCgroupStats(ctx, path)
+	}
+	return CgroupStats{}, p.unsupported("GetCgroupStats")
+}
+
+func (p pullerAdapter) GetContainers(ctx context.Context, socket string) ([]ContainerStats, error) {
+	if puller, ok := p.MetricsPuller.(ContainerPuller); ok {
+		return puller.GetContainers(ctx, socket)
+	}
+	return nil, p.unsupported("GetContainers")
+}
+
+func (p pullerAdapter) GetPressure(ctx context.Context, cgroupPath string) ([]PressureStats, error) {
+	if puller, ok := p.MetricsPuller.(PressurePuller); ok {
+		return puller.GetPressure(ctx, cgroupPath)
+	}
+	return nil, p.unsupported("GetPressure")
+}
+
+func (p pullerAdapter) GetSocketStats(ctx context.Context) (SocketStats, error) {
+	if puller, ok := p.MetricsPuller.(SocketPuller); ok {
+		return puller.GetSocketStats(ctx)
+	}
+	return SocketStats{}, p.unsupported("GetSocketStats")
+}
+
+func (p pullerAdapter) GetSystemdUnits(ctx context.Context, patterns []string) ([]SystemdUnit, error) {
+	if puller, ok := p.MetricsPuller.(SystemdPuller); ok {
+		return puller.GetSystemdUnits(ctx, patterns)
+	}
+	return nil, p.unsupported("GetSystemdUnits")
+}
+
+func (p pullerAdapter) GetKernelLimits(ctx context.Context) (KernelLimits, error) {
+	if puller, ok := p.MetricsPuller.(KernelLimitsPuller); ok {
+		return puller.GetKernelLimits(ctx)
+	}
+	return KernelLimits{}, p.unsupported("GetKernelLimits")
+}
+
+func (p pullerAdapter) GetProcesses(ctx context.Context, interval time.Duration) ([]ProcessStats, error) {
+	if puller, ok := p.MetricsPuller.(ProcessPuller); ok {
+		return puller.GetProcesses(ctx, interval)
+	}
+	return nil, p.unsupported("GetProcesses")
+}
+
+func (p pullerAdapter) GetMemoryDetails(ctx context.Context) (MemoryDetails, error) {
+	if puller, ok := p.MetricsPuller.(MemoryDetailsPuller); ok {
+		return puller.GetMemoryDetails(ctx)
+	}
+	return MemoryDetails{}, p.unsupported("GetMemoryDetails")
+}
+
+func (p pullerAdapter) GetMounts(ctx context.Context, statTimeout time.Duration) ([]MountStatus, error) {
+	if puller, ok := p.MetricsPuller.(MountPuller); ok {
+		return puller.GetMounts(ctx, statTimeout)
+	}
+	return nil, p.unsupported("GetMounts")
+}
+
+func (p pullerAdapter) GetHwmonSensors(ctx context.Context) ([]HwmonSensor, error) {
+	if puller, ok := p.MetricsPuller.(HwmonPuller); ok {
+		return puller.GetHwmonSensors(ctx)
+	}
+	return nil, p.unsupported("GetHwmonSensors")
+}
@@ -0,0 +1,48 @@
+package collect
+
+import (
+	"context"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/collector"
+)
+
+type (
+	Collector  = collector.Collector
+	Descriptor = collector.Descriptor
+	Sample     = collector.Sample
+	MetricType = collector.MetricType
+)
+
+const (
+	Gauge   = collector.Gauge
+	Counter = collector.Counter
+)
+
+// NewCollector builds a custom Collector from its metadata and a collect function.
+func NewCollector(name string, description string, descriptors []Descriptor, collect func(ctx context.Context) ([]Sample, error)) Collector {
+	return collector.New(name, description, descriptors, collect)
+}
+
+// CollectorResult holds the samples of one collector. Err is a *CollectorError when a built-in collector failed.
+type CollectorResult struct {
+	Collector string
+	Samples   []Sample
+	Err       error
+}
+
+// Snapshot is the result of Set.Snapshot.
+type Snapshot struct {
+	Time    time.Time
+	Results []CollectorResult
+}
+
+// Result returns the result of the collector.
+func (s Snapshot) Result(collector string) (CollectorResult, bool) {
+	for _, result := range s.Results {
+		if result.Collector == collector {
+			return result, true
+		}
+	}
+	return CollectorResult{}, false
+}
@@ -0,0 +1,424 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/alert"
+	"github.com/Matyjash/Metrigo/internal/check"
+	"github.com/Matyjash/Metrigo/internal/collector"
+	"github.com/Matyjash/Metrigo/internal/config"
+	"github.com/Matyjash/Metrigo/internal/exporter"
+	"github.com/Matyjash/Metrigo/internal/metrigo"
+	"github.com/Matyjash/Metrigo/internal/models"
+	"github.com/Matyjash/Metrigo/internal/server"
+	"github.com/Matyjash/Metrigo/internal/watch"
+	"github.com/Matyjash/Metrigo/pb"
+	"google.golang.org/grpc"
+)
+
+const (
+	shutdownTimeout    = 5 * time.Second
+	configPollInterval = 2 * time.Second
+)
+
+// Agent runs the gRPC server together with the configured exporters, alert rules and process watch.
+// The config is reloaded on SIGHUP or when the config file changes, without restarting the gRPC server.
+type Agent struct {
+	configPath string
+	metrigo    *metrigo.Metrigo
+	registry   *collector.Registry
+	server     *server.Server
+	engine     *alert.Engine
+	watcher    *watch.Watcher
+
+	// reloadMu serializes the reloads, mu only guards the fields below, so that a reload waiting on
+	// a running alert evaluation or process poll does not block the status or the token checks.
+	reloadMu   sync.Mutex
+	mu         sync.RWMutex
+	cfg        config.Config
+	exporters  map[string]*prometheusExporter
+	stopAlerts context.CancelFunc
+	stopWatch  context.CancelFunc
+	status     models.AgentStatus
+	seenHash   [sha256.Size]byte
+}
+
+type prometheusExporter struct {
+	listen     string
+	httpServer *http.Server
+}
+
+func New(cfg config.Config, configPath string, version string) *Agent {
+	m := metrigo.NewMetrigo()
+	now := time.Now()
+	a := &Agent{
+		configPath: configPath,
+		metrigo:    &m,
+		registry:   metrigo.NewRegistry(&m),
+		cfg:        cfg,
+		exporters:  map[string]*prometheusExporter{},
+		status: models.AgentStatus{
+			Version:        version,
+			ConfigPath:     configPath,
+			StartedAt:      now,
+			ConfigLoadedAt: now,
+		},
+	}
+	a.watcher = watch.NewWatcher(a.metrigo, nil)
+	a.server = server.NewServer(a.metrigo, a.registry, cfg.Collectors, a, a.watcher)
+	a.engine = alert.NewEngine(a.metrigo, nil)
+	a.seenHash, _ = a.configFileHash()
+	return a
+}
+
+// Run serves until the context is cancelled or the gRPC server fails.
+func (a *Agent) Run(ctx context.Context) error {
+	if err := ValidateAlertRules(a.cfg.Alerts.Rules); err != nil {
+		return fmt.Errorf("invalid configuration:\n%v", err)
+	}
+	lis, err := net.Listen("tcp", a.cfg.Server.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %v", err)
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(server.TokenAuthInterceptor(a.tokens)),
+		grpc.StreamInterceptor(server.TokenAuthStreamInterceptor(a.tokens)),
+	}
+	if a.cfg.Server.TLS.Enabled() {
+		creds, err := server.TLSCredentials(a.cfg.Server.TLS)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	s := grpc.NewServer(opts...)
+	pb.RegisterMetrigoServer(s, a.server)
+
+	a.reloadMu.Lock()
+	err = a.apply(ctx, a.cfg)
+	a.reloadMu.Unlock()
+	if err != nil {
+		return err
+	}
+	defer a.stopExporters()
+
+	go a.watch(ctx)
+	go func() {
+		<-ctx.Done()
+		stopGRPCServer(s)
+	}()
+
+	log.Printf("Server is running on %s", a.cfg.Server.Listen)
+	if err := s.Serve(lis); err != nil {
+		return fmt.Errorf("failed to serve: %v", err)
+	}
+	return nil
+}
+
+// Reload loads the config again and applies it. An invalid config is rejected and the previous one is kept.
+func (a *Agent) Reload(ctx context.Context) error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	// The file is hashed before it is loaded, so that a change written during the reload is picked up by the next poll.
+	hash, _ := a.configFileHash()
+	cfg, err := config.Load(a.configPath, a.registry.Names())
+	if err == nil {
+		err = ValidateAlertRules(cfg.Alerts.Rules)
+	}
+	a.mu.Lock()
+	a.seenHash = hash
+	previous := a.cfg
+	a.mu.Unlock()
+
+	if err == nil {
+		if err = a.apply(ctx, cfg); err == nil {
+			warnRestartRequired(previous, cfg)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	a.status.LastReloadAt = now
+	if err != nil {
+		a.status.LastReloadError = err.Error()
+		log.Printf("Config reload rejected, keeping the previous config: %v", err)
+		return err
+	}
+
+	a.status.LastReloadError = ""
+	a.status.ConfigLoadedAt = now
+	a.status.ReloadCount++
+	log.Printf("Config reloaded")
+	return nil
+}
+
+func (a *Agent) Status() models.AgentStatus {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.status
+}
+
+func (a *Agent) tokens() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.cfg.Server.Auth.Tokens
+}
+
+func (a *Agent) collectorEnabled(collector string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.cfg.Collectors.IsEnabled(collector)
+}
+
+// apply makes cfg the running config. It must be called with a.reloadMu held.
+// Exporter listeners are opened before anything is changed, so a failing exporter leaves the previous config running.
+// The alert engine and the process watcher are updated without a.mu held, as they keep their own lock while they
+// collect and notify.
+func (a *Agent) apply(ctx context.Context, cfg config.Config) error {
+	a.mu.RLock()
+	previous := a.exporters
+	a.mu.RUnlock()
+
+	exporters := map[string]*prometheusExporter{}
+	var started []*prometheusExporter
+	notifiers := []alert.Notifier{alert.LogNotifier{}}
+
+	for _, exporterCfg := range cfg.Exporters {
+		switch exporterCfg.Type {
+		case config.ExporterPrometheus:
+			if running, ok := previous[exporterCfg.Name]; ok && running.listen == exporterCfg.Listen {
+				exporters[exporterCfg.Name] = running
+				continue
+			}
+			running, err := a.startPrometheusExporter(exporterCfg)
+			if err != nil {
+				for _, s := range started {
+					s.httpServer.Close()
+				}
+				return err
+			}
+			exporters[exporterCfg.Name] = running
+			started = append(started, running)
+		case config.ExporterWebhook:
+			notifiers = append(notifiers, exporter.NewWebhook(exporterCfg.URL))
+		}
+	}
+
+	a.mu.Lock()
+	a.exporters = exporters
+	a.cfg = cfg
+	stopAlerts, stopWatch := a.stopAlerts, a.stopWatch
+	a.stopAlerts, a.stopWatch = nil, nil
+	a.mu.Unlock()
+
+	for name, running := range previous {
+		if exporters[name] != running {
+			running.httpServer.Close()
+		}
+	}
+
+	if stopAlerts != nil {
+		stopAlerts()
+	}
+	a.engine.Update(alertRules(cfg.Alerts.Rules), notifiers)
+	if len(cfg.Alerts.Rules) > 0 {
+		alertsCtx, cancel := context.WithCancel(ctx)
+		a.mu.Lock()
+		a.stopAlerts = cancel
+		a.mu.Unlock()
+		go a.engine.Run(alertsCtx, cfg.Alerts.Interval)
+	}
+
+	if stopWatch != nil {
+		stopWatch()
+	}
+	a.watcher.Update(watchedProcesses(cfg.ProcessWatch.Processes), notifiers)
+	if len(cfg.ProcessWatch.Processes) > 0 {
+		watchCtx, cancel := context.WithCancel(ctx)
+		a.mu.Lock()
+		a.stopWatch = cancel
+		a.mu.Unlock()
+		go a.watcher.Run(watchCtx, cfg.ProcessWatch.Interval)
+	}
+
+	a.server.SetCollectors(cfg.Collectors)
+	a.metrigo.Configure(cfg.Collectors)
+	return nil
+}
+
+func (a *Agent) startPrometheusExporter(exporterCfg config.ExporterConfig) (*prometheusExporter, error) {
+	lis, err := net.Listen("tcp", exporterCfg.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for exporter %s: %v", exporterCfg.Name, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter.NewPrometheusHandler(a.registry, a.collectorEnabled))
+	httpServer := &http.Server{Handler: mux}
+	go func() {
+		if err := httpServer.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("exporter %s stopped: %v", exporterCfg.Name, err)
+		}
+	}()
+	log.Printf("Exporter %s is serving metrics on %s/metrics", exporterCfg.Name, exporterCfg.Listen)
+	return &prometheusExporter{listen: exporterCfg.Listen, httpServer: httpServer}, nil
+}
+
+func (a *Agent) stopExporters() {
+	a.mu.Lock()
+	exporters := a.exporters
+	a.exporters = map[string]*prometheusExporter{}
+	a.mu.Unlock()
+
+	for _, running := range exporters {
+		shutdownHTTPServer(running.httpServer)
+	}
+}
+
+// watch reloads the config on SIGHUP and when the content of the config file changes.
+func (a *Agent) watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var poll <-chan time.Time
+	if a.configPath != "" {
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Printf("Received SIGHUP, reloading config")
+			a.Reload(ctx)
+		case <-poll:
+			if !a.configChanged() {
+				continue
+			}
+			log.Printf("Config file %s changed, reloading config", a.configPath)
+			a.Reload(ctx)
+		}
+	}
+}
+
+// configChanged reports whether the content of the config file changed since it was last loaded.
+func (a *Agent) configChanged() bool {
+	hash, err := a.configFileHash()
+	if err != nil {
+		return false
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return hash != a.seenHash
+}
+
+func (a *Agent) configFileHash() ([sha256.Size]byte, error) {
+	if a.configPath == "" {
+		return [sha256.Size]byte{}, nil
+	}
+	data, err := os.ReadFile(a.configPath)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// warnRestartRequired logs the changed settings that only take effect after a restart.
+func warnRestartRequired(previous config.Config, cfg config.Config) {
+	if previous.Server.Listen != cfg.Server.Listen {
+		log.Printf("server.listen changed to %s, restart required to apply it", cfg.Server.Listen)
+	}
+	if previous.Server.TLS != cfg.Server.TLS {
+		log.Printf("server.tls changed, restart required to apply it")
+	}
+}
+
+// stopGRPCServer waits for in-flight calls to complete, and cancels the ones still running after
+// shutdownTimeout, such as the process event streams, which only end when the client cancels.
+func stopGRPCServer(s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	timer := time.NewTimer(shutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+	case <-timer.C:
+		s.Stop()
+	}
+}
+
+func shutdownHTTPServer(httpServer *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Printf("failed to shut down exporter: %v", err)
+	}
+}
+
+// ValidateAlertRules returns the errors of the alert rules that config.Validate leaves to the checks:
+// unknown families and warning thresholds above the critical ones.
+func ValidateAlertRules(rulesCfg []config.AlertRule) error {
+	var errs []error
+	for i, ruleCfg := range rulesCfg {
+		key := fmt.Sprintf("alerts.rules[%d]", i)
+		if !slices.Contains(check.Families, ruleCfg.Family) {
+			errs = append(errs, fmt.Errorf("%s.family: unknown family %q, available: %s", key, ruleCfg.Family, strings.Join(check.Families, ", ")))
+		}
+		if err := (check.Thresholds{Warn: ruleCfg.Warn, Crit: ruleCfg.Crit}).Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s.warn: %v", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func alertRules(rulesCfg []config.AlertRule) []alert.Rule {
+	rules := make([]alert.Rule, len(rulesCfg))
+	for i, ruleCfg := range rulesCfg {
+		rules[i] = alert.Rule{
+			Name:       ruleCfg.Name,
+			Family:     ruleCfg.Family,
+			Thresholds: check.Thresholds{Warn: ruleCfg.Warn, Crit: ruleCfg.Crit},
+		}
+	}
+	return rules
+}
+
+func watchedProcesses(processesCfg []config.WatchedProcessConfig) []watch.Process {
+	processes := make([]watch.Process, len(processesCfg))
+	for i, processCfg := range processesCfg {
+		processes[i] = watch.Process{
+			Spec: models.ProcessGroupSpec{
+				Name:    processCfg.Name,
+				Process: processCfg.Process,
+				User:    processCfg.User,
+				Cgroup:  processCfg.Cgroup,
+				Unit:    processCfg.Unit,
+			},
+			MaxRestarts:   processCfg.MaxRestarts,
+			RestartWindow: processCfg.RestartWindow,
+		}
+	}
+	return processes
+}
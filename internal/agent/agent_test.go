@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/config"
+	"github.com/Matyjash/Metrigo/internal/metrigo"
+)
+
+func writeConfig(t *testing.T, path string, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+func Test_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrigo.yaml")
+	writeConfig(t, path, "collectors:\n  enabled: [cpu]\n")
+	cfg, err := config.Load(path, []string{"cpu", "mem"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a := New(cfg, path, "test")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writeConfig(t, path, `
+server:
+  auth:
+    tokens: [rotated]
+collectors:
+  enabled: [cpu, mem]
+  intervals:
+    cpu_sample: 1s
+alerts:
+  rules:
+    - name: high-cpu
+      family: cpu
+      warn: 80
+      crit: 90
+`)
+	if err := a.Reload(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.configChanged() {
+		t.Errorf("expected the reloaded config file not to be reported as changed")
+	}
+
+	status := a.Status()
+	if status.ReloadCount != 1 || status.LastReloadError != "" {
+		t.Errorf("expected a successful reload, got %+v", status)
+	}
+	if !reflect.DeepEqual(a.tokens(), []string{"rotated"}) {
+		t.Errorf("expected rotated tokens, got %v", a.tokens())
+	}
+	if !a.collectorEnabled(metrigo.CollectorMem) {
+		t.Errorf("expected mem collector to be enabled after reload")
+	}
+
+	writeConfig(t, path, "collectors:\n  enabled: [gpu]\n")
+	err = a.Reload(ctx)
+	if err == nil || !strings.Contains(err.Error(), "collectors.enabled[0]") {
+		t.Fatalf("expected validation error pointing at collectors.enabled[0], got %v", err)
+	}
+
+	status = a.Status()
+	if status.ReloadCount != 1 || !strings.Contains(status.LastReloadError, "unknown collector") {
+		t.Errorf("expected the rejected reload to be reported in status, got %+v", status)
+	}
+	if !a.collectorEnabled(metrigo.CollectorMem) || a.cfg.Collectors.Intervals.CpuSample != time.Second {
+		t.Errorf("expected previous config to be kept, got %+v", a.cfg.Collectors)
+	}
+}
+
+func Test_ValidateAlertRules(t *testing.T) {
+	err := ValidateAlertRules([]config.AlertRule{
+		{Name: "hot", Family: "temp", Warn: 90, Crit: 80},
+		{Name: "gpu", Family: "gpu", Warn: 80, Crit: 90},
+		{Name: "cpu", Family: "cpu", Warn: 80, Crit: 90},
+	})
+	for _, want := range []string{
+		"alerts.rules[0].warn: warning threshold (90) is greater than critical threshold (80)",
+		"alerts.rules[1].family: unknown family \"gpu\"",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got \"%v\"", want, err)
+		}
+	}
+	if err != nil && strings.Contains(err.Error(), "alerts.rules[2]") {
+		t.Errorf("expected the valid rule to pass, got %v", err)
+	}
+}
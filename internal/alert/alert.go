@@ -0,0 +1,119 @@
+package alert
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/check"
+)
+
+type Rule struct {
+	Name       string
+	Family     string
+	Thresholds check.Thresholds
+}
+
+// Event is emitted when the status of a rule changes.
+type Event struct {
+	Rule     string       `json:"rule"`
+	Family   string       `json:"family"`
+	Status   check.Status `json:"status"`
+	Previous check.Status `json:"previous"`
+	Message  string       `json:"message"`
+	Time     time.Time    `json:"time"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// LogNotifier writes events to the standard logger.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, event Event) error {
+	log.Printf("alert %s: %s -> %s: %s", event.Rule, event.Previous, event.Status, event.Message)
+	return nil
+}
+
+type Engine struct {
+	source    check.Source
+	rules     []Rule
+	notifiers []Notifier
+
+	mu     sync.Mutex
+	states map[string]check.Status
+}
+
+func NewEngine(source check.Source, rules []Rule, notifiers ...Notifier) *Engine {
+	return &Engine{
+		source:    source,
+		rules:     rules,
+		notifiers: notifiers,
+		states:    map[string]check.Status{},
+	}
+}
+
+// Update replaces the rules and notifiers. The state of rules that are kept is preserved.
+func (e *Engine) Update(rules []Rule, notifiers []Notifier) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	states := map[string]check.Status{}
+	for _, rule := range rules {
+		if state, ok := e.states[rule.Name]; ok {
+			states[rule.Name] = state
+		}
+	}
+	e.rules = rules
+	e.notifiers = notifiers
+	e.states = states
+}
+
+// Evaluate runs every rule once and notifies about the rules whose status changed.
+// Rules start in the OK state, so a rule that is OK on its first evaluation emits no event.
+func (e *Engine) Evaluate(ctx context.Context) []Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var events []Event
+	for _, rule := range e.rules {
+		result := check.Run(ctx, e.source, rule.Family, rule.Thresholds, "")
+		previous := e.states[rule.Name]
+		e.states[rule.Name] = result.Status
+		if result.Status == previous {
+			continue
+		}
+
+		event := Event{
+			Rule:     rule.Name,
+			Family:   rule.Family,
+			Status:   result.Status,
+			Previous: previous,
+			Message:  result.String(),
+			Time:     time.Now(),
+		}
+		events = append(events, event)
+		for _, notifier := range e.notifiers {
+			if err := notifier.Notify(ctx, event); err != nil {
+				log.Printf("failed to notify about alert %s: %v", rule.Name, err)
+			}
+		}
+	}
+	return events
+}
+
+// Run evaluates the rules every interval until the context is cancelled.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Evaluate(ctx)
+		}
+	}
+}
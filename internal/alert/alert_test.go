@@ -0,0 +1,85 @@
+package alert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/check"
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+type mockSource struct {
+	totalCpuUsage float64
+}
+
+func (m *mockSource) GetTotalCpuUsage(ctx context.Context) (float64, error) {
+	return m.totalCpuUsage, nil
+}
+func (m *mockSource) GetMemoryUsage(ctx context.Context) (models.MemoryUsage, error) {
+	return models.MemoryUsage{}, nil
+}
+func (m *mockSource) GetTemperatures(ctx context.Context) ([]models.TemperatureSensor, error) {
+	return nil, nil
+}
+func (m *mockSource) GetDisksUsage(ctx context.Context) ([]models.DiskUsage, error) {
+	return nil, nil
+}
+func (m *mockSource) GetLoadAverage(ctx context.Context) (models.LoadAverage, error) {
+	return models.LoadAverage{}, nil
+}
+
+func (m *mockSource) GetSystemdUnitsMatching(ctx context.Context, pattern string) ([]models.SystemdUnit, error) {
+	return nil, nil
+}
+
+func (m *mockSource) GetMounts(ctx context.Context) ([]models.MountStatus, error) {
+	return nil, nil
+}
+
+type recordingNotifier struct {
+	events []Event
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, event Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func Test_EngineEvaluate(t *testing.T) {
+	source := &mockSource{}
+	notifier := &recordingNotifier{}
+	engine := NewEngine(source, []Rule{
+		{Name: "high-cpu", Family: check.FamilyCpu, Thresholds: check.Thresholds{Warn: 80, Crit: 90}},
+	}, notifier)
+
+	steps := []struct {
+		usage        float64
+		wantStatuses []check.Status
+	}{
+		{usage: 10, wantStatuses: nil},
+		{usage: 85, wantStatuses: []check.Status{check.StatusWarning}},
+		{usage: 86, wantStatuses: nil},
+		{usage: 95, wantStatuses: []check.Status{check.StatusCritical}},
+		{usage: 20, wantStatuses: []check.Status{check.StatusOK}},
+	}
+
+	for i, step := range steps {
+		source.totalCpuUsage = step.usage
+		events := engine.Evaluate(context.Background())
+		if len(events) != len(step.wantStatuses) {
+			t.Fatalf("step %d: expected %d events, got %d", i, len(step.wantStatuses), len(events))
+		}
+		for j, event := range events {
+			if event.Status != step.wantStatuses[j] {
+				t.Errorf("step %d: expected status %v, got %v", i, step.wantStatuses[j], event.Status)
+			}
+		}
+	}
+
+	if len(notifier.events) != 3 {
+		t.Errorf("expected 3 notified events, got %d", len(notifier.events))
+	}
+	if notifier.events[2].Previous != check.StatusCritical {
+		t.Errorf("expected recovery from %v, got %v", check.StatusCritical, notifier.events[2].Previous)
+	}
+}
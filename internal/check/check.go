@@ -0,0 +1,389 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+// Status follows the Nagios/Icinga plugin exit code convention.
+type Status int
+
+const (
+	StatusOK Status = iota
+	StatusWarning
+	StatusCritical
+	StatusUnknown
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "OK"
+	case StatusWarning:
+		return "WARNING"
+	case StatusCritical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+const (
+	FamilyCpu  = "cpu"
+	FamilyMem  = "mem"
+	FamilyTemp = "temp"
+	FamilyDisk = "disk"
+	FamilyLoad = "load"
+	// FamilySystemd evaluates the number of failed systemd units.
+	FamilySystemd = "systemd"
+	// FamilyMounts evaluates the highest inode usage of the mounts, stale and remounted read-only mounts are critical.
+	FamilyMounts = "mounts"
+)
+
+var Families = []string{FamilyCpu, FamilyMem, FamilyTemp, FamilyDisk, FamilyLoad, FamilySystemd, FamilyMounts}
+
+// Source is the subset of metrigo.Metrigo used by the checks.
+type Source interface {
+	GetTotalCpuUsage(ctx context.Context) (float64, error)
+	GetMemoryUsage(ctx context.Context) (models.MemoryUsage, error)
+	GetTemperatures(ctx context.Context) ([]models.TemperatureSensor, error)
+	GetDisksUsage(ctx context.Context) ([]models.DiskUsage, error)
+	GetLoadAverage(ctx context.Context) (models.LoadAverage, error)
+	// GetSystemdUnitsMatching returns the systemd units whose name matches the glob pattern, all of them when it is empty.
+	GetSystemdUnitsMatching(ctx context.Context, pattern string) ([]models.SystemdUnit, error)
+	GetMounts(ctx context.Context) ([]models.MountStatus, error)
+}
+
+type Thresholds struct {
+	Warn float64
+	Crit float64
+}
+
+func (t Thresholds) Validate() error {
+	if t.Warn > t.Crit {
+		return fmt.Errorf("warning threshold (%s) is greater than critical threshold (%s)", formatValue(t.Warn), formatValue(t.Crit))
+	}
+	return nil
+}
+
+// Evaluate returns the status of a value, alerting when it is above a threshold.
+func (t Thresholds) Evaluate(value float64) Status {
+	switch {
+	case value > t.Crit:
+		return StatusCritical
+	case value > t.Warn:
+		return StatusWarning
+	default:
+		return StatusOK
+	}
+}
+
+type PerfData struct {
+	Label string
+	Value float64
+	UOM   string
+	Warn  float64
+	Crit  float64
+	Min   *float64
+	Max   *float64
+}
+
+// String formats perfdata as 'label'=value[UOM];warn;crit;min;max. Quotes in the label are doubled,
+// as the plugin guidelines require.
+func (p PerfData) String() string {
+	min, max := "", ""
+	if p.Min != nil {
+		min = formatValue(*p.Min)
+	}
+	if p.Max != nil {
+		max = formatValue(*p.Max)
+	}
+	return fmt.Sprintf("'%s'=%s%s;%s;%s;%s;%s", strings.ReplaceAll(p.Label, "'", "''"), formatValue(p.Value), p.UOM, formatValue(p.Warn), formatValue(p.Crit), min, max)
+}
+
+type Result struct {
+	Family  string
+	Status  Status
+	Summary string
+	Perf    []PerfData
+}
+
+// String formats the result as a plugin status line, e.g. "CPU OK - usage 12.5% | 'usage'=12.5%;80;90;0;100".
+// A "|" in the summary, e.g. from an error or a mount path, is replaced with "/", as it would start the perfdata.
+func (r Result) String() string {
+	summary := strings.ReplaceAll(r.Summary, "|", "/")
+	line := fmt.Sprintf("%s %s - %s", strings.ToUpper(r.Family), r.Status, summary)
+	if len(r.Perf) == 0 {
+		return line
+	}
+	perf := make([]string, len(r.Perf))
+	for i, p := range r.Perf {
+		perf[i] = p.String()
+	}
+	return line + " | " + strings.Join(perf, " ")
+}
+
+// ExitCode returns the process exit code matching the result status.
+func (r Result) ExitCode() int {
+	return int(r.Status)
+}
+
+func unknown(family string, err error) Result {
+	return Result{Family: family, Status: StatusUnknown, Summary: err.Error()}
+}
+
+// Run collects the given metric family and evaluates it against the thresholds. The systemd family
+// only counts the units matching the glob pattern, all of them when it is empty.
+func Run(ctx context.Context, source Source, family string, thresholds Thresholds, pattern string) Result {
+	if err := thresholds.Validate(); err != nil {
+		return unknown(family, err)
+	}
+
+	switch family {
+	case FamilyCpu:
+		return checkCpu(ctx, source, thresholds)
+	case FamilyMem:
+		return checkMem(ctx, source, thresholds)
+	case FamilyTemp:
+		return checkTemp(ctx, source, thresholds)
+	case FamilyDisk:
+		return checkDisk(ctx, source, thresholds)
+	case FamilyLoad:
+		return checkLoad(ctx, source, thresholds)
+	case FamilySystemd:
+		return checkSystemd(ctx, source, thresholds, pattern)
+	case FamilyMounts:
+		return checkMounts(ctx, source, thresholds)
+	default:
+		return unknown(family, fmt.Errorf("unknown check family: %s. Available families: %s", family, strings.Join(Families, ", ")))
+	}
+}
+
+func checkCpu(ctx context.Context, source Source, thresholds Thresholds) Result {
+	usage, err := source.GetTotalCpuUsage(ctx)
+	if err != nil {
+		return unknown(FamilyCpu, err)
+	}
+	return Result{
+		Family:  FamilyCpu,
+		Status:  thresholds.Evaluate(usage),
+		Summary: fmt.Sprintf("usage %s%%", formatPercent(usage)),
+		Perf:    []PerfData{percentPerfData("usage", usage, thresholds)},
+	}
+}
+
+func checkMem(ctx context.Context, source Source, thresholds Thresholds) Result {
+	memoryUsage, err := source.GetMemoryUsage(ctx)
+	if err != nil {
+		return unknown(FamilyMem, err)
+	}
+	if memoryUsage.TotalB == 0 {
+		return unknown(FamilyMem, fmt.Errorf("total memory reported as 0"))
+	}
+	usedPercent := float64(memoryUsage.UsedB) / float64(memoryUsage.TotalB) * 100
+	total := float64(memoryUsage.TotalB)
+	return Result{
+		Family:  FamilyMem,
+		Status:  thresholds.Evaluate(usedPercent),
+		Summary: fmt.Sprintf("usage %s%%, used %d B of %d B", formatPercent(usedPercent), memoryUsage.UsedB, memoryUsage.TotalB),
+		Perf: []PerfData{
+			percentPerfData("usage", usedPercent, thresholds),
+			{
+				Label: "used",
+				Value: float64(memoryUsage.UsedB),
+				UOM:   "B",
+				Warn:  total * thresholds.Warn / 100,
+				Crit:  total * thresholds.Crit / 100,
+				Min:   floatPtr(0),
+				Max:   &total,
+			},
+		},
+	}
+}
+
+func checkTemp(ctx context.Context, source Source, thresholds Thresholds) Result {
+	temps, err := source.GetTemperatures(ctx)
+	if err != nil {
+		return unknown(FamilyTemp, err)
+	}
+	if len(temps) == 0 {
+		return unknown(FamilyTemp, fmt.Errorf("no temperature sensors found"))
+	}
+
+	hottest := temps[0]
+	perf := make([]PerfData, len(temps))
+	for i, temp := range temps {
+		if temp.Value > hottest.Value {
+			hottest = temp
+		}
+		perf[i] = PerfData{Label: temp.Key, Value: temp.Value, Warn: thresholds.Warn, Crit: thresholds.Crit}
+	}
+
+	return Result{
+		Family:  FamilyTemp,
+		Status:  thresholds.Evaluate(hottest.Value),
+		Summary: fmt.Sprintf("hottest sensor %s at %s °C", hottest.Key, formatValue(hottest.Value)),
+		Perf:    perf,
+	}
+}
+
+func checkDisk(ctx context.Context, source Source, thresholds Thresholds) Result {
+	disks, err := source.GetDisksUsage(ctx)
+	if err != nil {
+		return unknown(FamilyDisk, err)
+	}
+	if len(disks) == 0 {
+		return unknown(FamilyDisk, fmt.Errorf("no disk partitions found"))
+	}
+
+	fullest := disks[0]
+	perf := make([]PerfData, len(disks))
+	for i, disk := range disks {
+		if disk.UsedPercent > fullest.UsedPercent {
+			fullest = disk
+		}
+		perf[i] = percentPerfData(disk.Path, disk.UsedPercent, thresholds)
+	}
+
+	return Result{
+		Family:  FamilyDisk,
+		Status:  thresholds.Evaluate(fullest.UsedPercent),
+		Summary: fmt.Sprintf("fullest mount %s at %s%%", fullest.Path, formatPercent(fullest.UsedPercent)),
+		Perf:    perf,
+	}
+}
+
+// checkLoad evaluates the 1 minute load average, reporting all three averages as perfdata.
+func checkLoad(ctx context.Context, source Source, thresholds Thresholds) Result {
+	loadAverage, err := source.GetLoadAverage(ctx)
+	if err != nil {
+		return unknown(FamilyLoad, err)
+	}
+	return Result{
+		Family: FamilyLoad,
+		Status: thresholds.Evaluate(loadAverage.Load1),
+		Summary: fmt.Sprintf("load average %s, %s, %s",
+			formatValue(loadAverage.Load1), formatValue(loadAverage.Load5), formatValue(loadAverage.Load15)),
+		Perf: []PerfData{
+			{Label: "load1", Value: loadAverage.Load1, Warn: thresholds.Warn, Crit: thresholds.Crit, Min: floatPtr(0)},
+			{Label: "load5", Value: loadAverage.Load5, Warn: thresholds.Warn, Crit: thresholds.Crit, Min: floatPtr(0)},
+			{Label: "load15", Value: loadAverage.Load15, Warn: thresholds.Warn, Crit: thresholds.Crit, Min: floatPtr(0)},
+		},
+	}
+}
+
+// checkSystemd evaluates the number of units in the failed state, e.g. with warn 0 and crit 0 any failed unit is critical.
+func checkSystemd(ctx context.Context, source Source, thresholds Thresholds, pattern string) Result {
+	units, err := source.GetSystemdUnitsMatching(ctx, pattern)
+	if err != nil {
+		return unknown(FamilySystemd, err)
+	}
+	if pattern != "" && len(units) == 0 {
+		return unknown(FamilySystemd, fmt.Errorf("no units matching %q", pattern))
+	}
+	var failed []string
+	for _, unit := range units {
+		if unit.ActiveState == models.SystemdUnitFailed {
+			failed = append(failed, unit.Name)
+		}
+	}
+
+	summary := fmt.Sprintf("%d failed units", len(failed))
+	if len(failed) > 0 {
+		summary += ": " + strings.Join(failed, ", ")
+	}
+	total := float64(len(units))
+	return Result{
+		Family:  FamilySystemd,
+		Status:  thresholds.Evaluate(float64(len(failed))),
+		Summary: summary,
+		Perf: []PerfData{
+			{Label: "failed", Value: float64(len(failed)), Warn: thresholds.Warn, Crit: thresholds.Crit, Min: floatPtr(0), Max: &total},
+		},
+	}
+}
+
+// checkMounts evaluates the inode usage of the fullest mount. Stale and remounted read-only mounts are
+// critical whatever the thresholds.
+func checkMounts(ctx context.Context, source Source, thresholds Thresholds) Result {
+	mounts, err := source.GetMounts(ctx)
+	if err != nil {
+		return unknown(FamilyMounts, err)
+	}
+	if len(mounts) == 0 {
+		return unknown(FamilyMounts, fmt.Errorf("no mounts found"))
+	}
+
+	var fullest *models.MountStatus
+	var broken []string
+	var perf []PerfData
+	for i, mount := range mounts {
+		if mount.Health == models.MountHealthStale || mount.Health == models.MountHealthReadOnly {
+			broken = append(broken, fmt.Sprintf("%s %s", mount.Path, mount.Health))
+		}
+		if mount.Stale || mount.Inodes == 0 {
+			continue
+		}
+		if fullest == nil || mount.InodesUsedPercent > fullest.InodesUsedPercent {
+			fullest = &mounts[i]
+		}
+		perf = append(perf, percentPerfData(mount.Path, mount.InodesUsedPercent, thresholds))
+	}
+
+	status := StatusOK
+	var summary []string
+	if len(broken) > 0 {
+		status = StatusCritical
+		summary = append(summary, fmt.Sprintf("%d unhealthy mounts: %s", len(broken), strings.Join(broken, ", ")))
+	}
+	if fullest != nil {
+		status = max(status, thresholds.Evaluate(fullest.InodesUsedPercent))
+		summary = append(summary, fmt.Sprintf("highest inode usage %s at %s%%", fullest.Path, formatPercent(fullest.InodesUsedPercent)))
+	}
+	if len(summary) == 0 {
+		summary = append(summary, fmt.Sprintf("%d mounts without inode usage", len(mounts)))
+	}
+	return Result{
+		Family:  FamilyMounts,
+		Status:  status,
+		Summary: strings.Join(summary, ", "),
+		Perf:    perf,
+	}
+}
+
+func percentPerfData(label string, value float64, thresholds Thresholds) PerfData {
+	return PerfData{
+		Label: label,
+		Value: roundPercent(value),
+		UOM:   "%",
+		Warn:  thresholds.Warn,
+		Crit:  thresholds.Crit,
+		Min:   floatPtr(0),
+		Max:   floatPtr(100),
+	}
+}
+
+func roundPercent(value float64) float64 {
+	rounded, _ := strconv.ParseFloat(formatPercent(value), 64)
+	return rounded
+}
+
+func formatPercent(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
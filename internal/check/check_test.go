@@ -0,0 +1,269 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+type mockSource struct {
+	totalCpuUsage float64
+	memoryUsage   models.MemoryUsage
+	temperatures  []models.TemperatureSensor
+	disksUsage    []models.DiskUsage
+	loadAverage   models.LoadAverage
+	systemdUnits  []models.SystemdUnit
+	mounts        []models.MountStatus
+	err           error
+}
+
+func (m *mockSource) GetTotalCpuUsage(ctx context.Context) (float64, error) {
+	return m.totalCpuUsage, m.err
+}
+func (m *mockSource) GetMemoryUsage(ctx context.Context) (models.MemoryUsage, error) {
+	return m.memoryUsage, m.err
+}
+func (m *mockSource) GetTemperatures(ctx context.Context) ([]models.TemperatureSensor, error) {
+	return m.temperatures, m.err
+}
+func (m *mockSource) GetDisksUsage(ctx context.Context) ([]models.DiskUsage, error) {
+	return m.disksUsage, m.err
+}
+func (m *mockSource) GetLoadAverage(ctx context.Context) (models.LoadAverage, error) {
+	return m.loadAverage, m.err
+}
+
+func (m *mockSource) GetSystemdUnitsMatching(ctx context.Context, pattern string) ([]models.SystemdUnit, error) {
+	if pattern == "" {
+		return m.systemdUnits, m.err
+	}
+	var units []models.SystemdUnit
+	for _, unit := range m.systemdUnits {
+		if matched, _ := path.Match(pattern, unit.Name); matched {
+			units = append(units, unit)
+		}
+	}
+	return units, m.err
+}
+
+func (m *mockSource) GetMounts(ctx context.Context) ([]models.MountStatus, error) {
+	return m.mounts, m.err
+}
+
+func Test_Run(t *testing.T) {
+	defaultThresholds := Thresholds{Warn: 80, Crit: 90}
+
+	tests := []struct {
+		name         string
+		source       *mockSource
+		family       string
+		thresholds   Thresholds
+		pattern      string
+		wantStatus   Status
+		wantContains []string
+	}{
+		{
+			name:         "cpu ok",
+			source:       &mockSource{totalCpuUsage: 12.5},
+			family:       FamilyCpu,
+			thresholds:   defaultThresholds,
+			wantStatus:   StatusOK,
+			wantContains: []string{"CPU OK - usage 12.50%", "| 'usage'=12.5%;80;90;0;100"},
+		},
+		{
+			name:         "cpu warning",
+			source:       &mockSource{totalCpuUsage: 85},
+			family:       FamilyCpu,
+			thresholds:   defaultThresholds,
+			wantStatus:   StatusWarning,
+			wantContains: []string{"CPU WARNING"},
+		},
+		{
+			name:         "cpu value equal to threshold is not alerting",
+			source:       &mockSource{totalCpuUsage: 80},
+			family:       FamilyCpu,
+			thresholds:   defaultThresholds,
+			wantStatus:   StatusOK,
+			wantContains: []string{"CPU OK"},
+		},
+		{
+			name:         "mem critical",
+			source:       &mockSource{memoryUsage: models.MemoryUsage{UsedB: 950, TotalB: 1000}},
+			family:       FamilyMem,
+			thresholds:   defaultThresholds,
+			wantStatus:   StatusCritical,
+			wantContains: []string{"MEM CRITICAL - usage 95.00%", "'usage'=95%;80;90;0;100", "'used'=950B;800;900;0;1000"},
+		},
+		{
+			name:         "mem with zero total is unknown",
+			source:       &mockSource{memoryUsage: models.MemoryUsage{UsedB: 950}},
+			family:       FamilyMem,
+			thresholds:   defaultThresholds,
+			wantStatus:   StatusUnknown,
+			wantContains: []string{"MEM UNKNOWN"},
+		},
+		{
+			name: "temp uses hottest sensor",
+			source: &mockSource{temperatures: []models.TemperatureSensor{
+				{Key: "core0", Value: 45},
+				{Key: "core1", Value: 72},
+			}},
+			family:       FamilyTemp,
+			thresholds:   Thresholds{Warn: 70, Crit: 85},
+			wantStatus:   StatusWarning,
+			wantContains: []string{"TEMP WARNING - hottest sensor core1 at 72 °C", "'core0'=45;70;85;;", "'core1'=72;70;85;;"},
+		},
+		{
+			name: "disk uses fullest mount",
+			source: &mockSource{disksUsage: []models.DiskUsage{
+				{Path: "/", UsedPercent: 40},
+				{Path: "/boot", UsedPercent: 91},
+			}},
+			family:       FamilyDisk,
+			thresholds:   defaultThresholds,
+			wantStatus:   StatusCritical,
+			wantContains: []string{"DISK CRITICAL - fullest mount /boot at 91.00%", "'/'=40%;80;90;0;100", "'/boot'=91%;80;90;0;100"},
+		},
+		{
+			name:         "load evaluates 1 minute average",
+			source:       &mockSource{loadAverage: models.LoadAverage{Load1: 0.5, Load5: 4, Load15: 8}},
+			family:       FamilyLoad,
+			thresholds:   Thresholds{Warn: 2, Crit: 4},
+			wantStatus:   StatusOK,
+			wantContains: []string{"LOAD OK - load average 0.5, 4, 8", "'load1'=0.5;2;4;0; 'load5'=4;2;4;0; 'load15'=8;2;4;0;"},
+		},
+		{
+			name: "systemd failed units are critical",
+			source: &mockSource{systemdUnits: []models.SystemdUnit{
+				{Name: "backup.service", ActiveState: "failed"},
+				{Name: "nginx.service", ActiveState: "active"},
+				{Name: "home.mount", ActiveState: "failed"},
+			}},
+			family:       FamilySystemd,
+			thresholds:   Thresholds{Warn: 0, Crit: 0},
+			wantStatus:   StatusCritical,
+			wantContains: []string{"SYSTEMD CRITICAL - 2 failed units: backup.service, home.mount", "'failed'=2;0;0;0;3"},
+		},
+		{
+			name: "systemd units matching the pattern",
+			source: &mockSource{systemdUnits: []models.SystemdUnit{
+				{Name: "backup.service", ActiveState: "failed"},
+				{Name: "nginx.service", ActiveState: "active"},
+				{Name: "nginx-exporter.service", ActiveState: "failed"},
+			}},
+			family:       FamilySystemd,
+			thresholds:   Thresholds{Warn: 0, Crit: 0},
+			pattern:      "nginx*",
+			wantStatus:   StatusCritical,
+			wantContains: []string{"SYSTEMD CRITICAL - 1 failed units: nginx-exporter.service", "'failed'=1;0;0;0;2"},
+		},
+		{
+			name:         "systemd without units matching the pattern",
+			source:       &mockSource{systemdUnits: []models.SystemdUnit{{Name: "nginx.service", ActiveState: "active"}}},
+			family:       FamilySystemd,
+			thresholds:   Thresholds{Warn: 0, Crit: 0},
+			pattern:      "postgres*",
+			wantStatus:   StatusUnknown,
+			wantContains: []string{"SYSTEMD UNKNOWN - no units matching \"postgres*\""},
+		},
+		{
+			name:         "systemd without failed units",
+			source:       &mockSource{systemdUnits: []models.SystemdUnit{{Name: "nginx.service", ActiveState: "active"}}},
+			family:       FamilySystemd,
+			thresholds:   Thresholds{Warn: 0, Crit: 0},
+			wantStatus:   StatusOK,
+			wantContains: []string{"SYSTEMD OK - 0 failed units"},
+		},
+		{
+			name: "mounts stale and remounted read-only are critical",
+			source: &mockSource{mounts: []models.MountStatus{
+				{Path: "/", Inodes: 100, InodesUsedPercent: 40, Health: models.MountHealthOK},
+				{Path: "/data", ReadOnly: true, Inodes: 100, InodesUsedPercent: 10, Health: models.MountHealthReadOnly},
+				{Path: "/mnt/share", Stale: true, Health: models.MountHealthStale},
+			}},
+			family:       FamilyMounts,
+			thresholds:   defaultThresholds,
+			wantStatus:   StatusCritical,
+			wantContains: []string{"MOUNTS CRITICAL - 2 unhealthy mounts: /data read-only, /mnt/share stale, highest inode usage / at 40.00%", "'/'=40%;80;90;0;100 '/data'=10%;80;90;0;100"},
+		},
+		{
+			name: "mounts inode usage",
+			source: &mockSource{mounts: []models.MountStatus{
+				{Path: "/", Inodes: 100, InodesUsedPercent: 40, Health: models.MountHealthOK},
+				{Path: "/var", Inodes: 100, InodesUsedPercent: 85, Health: models.MountHealthOK},
+				{Path: "/media/cdrom", ReadOnly: true, Health: models.MountHealthOK},
+			}},
+			family:       FamilyMounts,
+			thresholds:   defaultThresholds,
+			wantStatus:   StatusWarning,
+			wantContains: []string{"MOUNTS WARNING - highest inode usage /var at 85.00%"},
+		},
+		{
+			name:         "collection error is unknown",
+			source:       &mockSource{err: fmt.Errorf("failed to get CPU usage")},
+			family:       FamilyCpu,
+			thresholds:   defaultThresholds,
+			wantStatus:   StatusUnknown,
+			wantContains: []string{"CPU UNKNOWN - failed to get CPU usage"},
+		},
+		{
+			name:         "unknown family",
+			source:       &mockSource{},
+			family:       "gpu",
+			thresholds:   defaultThresholds,
+			wantStatus:   StatusUnknown,
+			wantContains: []string{"GPU UNKNOWN - unknown check family: gpu"},
+		},
+		{
+			name:         "warning threshold above critical is unknown",
+			source:       &mockSource{totalCpuUsage: 10},
+			family:       FamilyCpu,
+			thresholds:   Thresholds{Warn: 95, Crit: 90},
+			wantStatus:   StatusUnknown,
+			wantContains: []string{"warning threshold (95) is greater than critical threshold (90)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Run(context.Background(), tt.source, tt.family, tt.thresholds, tt.pattern)
+			if result.Status != tt.wantStatus {
+				t.Errorf("expected status %v, got %v", tt.wantStatus, result.Status)
+			}
+			if result.ExitCode() != int(tt.wantStatus) {
+				t.Errorf("expected exit code %d, got %d", int(tt.wantStatus), result.ExitCode())
+			}
+			got := result.String()
+			for _, substr := range tt.wantContains {
+				if !strings.Contains(got, substr) {
+					t.Errorf("Result.String() = %v, want contains %v", got, substr)
+				}
+			}
+		})
+	}
+}
+
+func Test_ResultString(t *testing.T) {
+	result := Result{
+		Family:  FamilyDisk,
+		Status:  StatusWarning,
+		Summary: "fullest mount /mnt/a|b at 85%",
+		Perf: []PerfData{
+			{Label: "/mnt/it's", Value: 85, UOM: "%", Warn: 80, Crit: 90},
+			{Label: "/mnt/a|b", Value: 10, UOM: "%", Warn: 80, Crit: 90},
+		},
+	}
+	want := "DISK WARNING - fullest mount /mnt/a/b at 85% | '/mnt/it''s'=85%;80;90;; '/mnt/a|b'=10%;80;90;;"
+	if got := result.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	unknown := unknown(FamilyCpu, fmt.Errorf("failed | broken"))
+	if got, want := unknown.String(), "CPU UNKNOWN - failed / broken"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type MetricType int
+
+const (
+	Gauge MetricType = iota
+	Counter
+)
+
+func (t MetricType) String() string {
+	if t == Counter {
+		return "counter"
+	}
+	return "gauge"
+}
+
+// Descriptor describes a metric reported by a collector.
+type Descriptor struct {
+	Name   string
+	Help   string
+	Unit   string
+	Type   MetricType
+	Labels []string
+}
+
+type Sample struct {
+	Metric string
+	Labels map[string]string
+	Value  float64
+}
+
+// Collector is a self-contained metric family. Collectors are discovered through a Registry
+// by the CLI, the gRPC server and the exporters.
+type Collector interface {
+	Name() string
+	Description() string
+	Descriptors() []Descriptor
+	Collect(ctx context.Context) ([]Sample, error)
+}
+
+type funcCollector struct {
+	name        string
+	description string
+	descriptors []Descriptor
+	collect     func(ctx context.Context) ([]Sample, error)
+}
+
+// New builds a Collector from its metadata and a collect function.
+func New(name string, description string, descriptors []Descriptor, collect func(ctx context.Context) ([]Sample, error)) Collector {
+	return &funcCollector{
+		name:        name,
+		description: description,
+		descriptors: descriptors,
+		collect:     collect,
+	}
+}
+
+func (c *funcCollector) Name() string {
+	return c.name
+}
+
+func (c *funcCollector) Description() string {
+	return c.description
+}
+
+func (c *funcCollector) Descriptors() []Descriptor {
+	return c.descriptors
+}
+
+func (c *funcCollector) Collect(ctx context.Context) ([]Sample, error) {
+	return c.collect(ctx)
+}
+
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+	byName     map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		byName: map[string]Collector{},
+	}
+}
+
+func (r *Registry) Register(c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byName[c.Name()]; ok {
+		return fmt.Errorf("collector %s is already registered", c.Name())
+	}
+	r.collectors = append(r.collectors, c)
+	r.byName[c.Name()] = c
+	return nil
+}
+
+// MustRegister registers the collectors, panicking on a duplicate name.
+func (r *Registry) MustRegister(collectors ...Collector) {
+	for _, c := range collectors {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (r *Registry) Get(name string) (Collector, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.byName[name]
+	return c, ok
+}
+
+// Collectors returns the registered collectors in registration order.
+func (r *Registry) Collectors() []Collector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Collector(nil), r.collectors...)
+}
+
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, len(r.collectors))
+	for i, c := range r.collectors {
+		names[i] = c.Name()
+	}
+	return names
+}
@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestCollector(name string) Collector {
+	return New(name, "test collector", []Descriptor{{Name: "value", Type: Gauge}}, func(ctx context.Context) ([]Sample, error) {
+		return []Sample{{Metric: "value", Value: 1}}, nil
+	})
+}
+
+func Test_Registry(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(newTestCollector("b"), newTestCollector("a"))
+
+	if !reflect.DeepEqual(r.Names(), []string{"b", "a"}) {
+		t.Errorf("expected registration order, got %v", r.Names())
+	}
+
+	err := r.Register(newTestCollector("a"))
+	if err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("expected duplicate registration error, got %v", err)
+	}
+
+	c, ok := r.Get("a")
+	if !ok {
+		t.Fatalf("expected collector a to be registered")
+	}
+	samples, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(samples, []Sample{{Metric: "value", Value: 1}}) {
+		t.Errorf("unexpected samples %v", samples)
+	}
+
+	if _, ok := r.Get("missing"); ok {
+		t.Errorf("expected missing collector not to be found")
+	}
+}
@@ -0,0 +1,387 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ExporterPrometheus = "prometheus"
+	ExporterWebhook    = "webhook"
+)
+
+var ExporterTypes = []string{ExporterPrometheus, ExporterWebhook}
+
+type Config struct {
+	Server     ServerConfig     `yaml:"server"`
+	Collectors CollectorsConfig `yaml:"collectors"`
+	Exporters  []ExporterConfig `yaml:"exporters"`
+	Alerts     AlertsConfig     `yaml:"alerts"`
+	// ProcessWatch reports the starts, stops and restarts of critical processes to the alert notifiers.
+	ProcessWatch ProcessWatchConfig `yaml:"process_watch"`
+}
+
+type ServerConfig struct {
+	Listen string     `yaml:"listen"`
+	TLS    TLSConfig  `yaml:"tls"`
+	Auth   AuthConfig `yaml:"auth"`
+}
+
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile enables mutual TLS when set.
+	ClientCAFile string `yaml:"client_ca_file"`
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+type AuthConfig struct {
+	// Tokens accepted as "authorization: Bearer <token>" metadata. Auth is disabled when empty.
+	Tokens []string `yaml:"tokens"`
+}
+
+type CollectorsConfig struct {
+	// Enabled lists the enabled collectors. All registered collectors are enabled when it is empty.
+	Enabled   []string            `yaml:"enabled"`
+	Intervals CollectorsIntervals `yaml:"intervals"`
+	// Timeout limits how long a single collection may take, 0 disables the limit.
+	Timeout time.Duration `yaml:"timeout"`
+	// Timeouts overrides Timeout per collector.
+	Timeouts   map[string]time.Duration `yaml:"timeouts"`
+	Cgroup     CgroupConfig             `yaml:"cgroup"`
+	Containers ContainersConfig         `yaml:"containers"`
+	Systemd    SystemdConfig            `yaml:"systemd"`
+	Mounts     MountsConfig             `yaml:"mounts"`
+	// ProcessGroups are the groups of processes whose usage is aggregated by the procgroups collector.
+	ProcessGroups []ProcessGroupConfig `yaml:"process_groups"`
+	Roots         RootsConfig          `yaml:"roots"`
+}
+
+// RootsConfig sets where the host filesystems are mounted when the agent runs in a container,
+// e.g. proc: /host/proc. Empty paths use the defaults.
+type RootsConfig struct {
+	// Root is the host's root filesystem, used to reach mount points for disk usage.
+	Root string `yaml:"root"`
+	Proc string `yaml:"proc"`
+	Sys  string `yaml:"sys"`
+	// Etc is also used to read the host's hostname.
+	Etc string `yaml:"etc"`
+	Run string `yaml:"run"`
+	// Var is used to read the logged-in user sessions from <var>/run/utmp.
+	Var string `yaml:"var"`
+}
+
+type ContainersConfig struct {
+	// Socket is the Unix socket of the Docker compatible container runtime API.
+	Socket string `yaml:"socket"`
+}
+
+type SystemdConfig struct {
+	// Units are the glob patterns of the units to read, e.g. "*.service". All loaded units are read when empty.
+	Units []string `yaml:"units"`
+}
+
+type MountsConfig struct {
+	// StatTimeout is how long the usage of a mount is waited for before it is reported as stale, e.g. a hung NFS mount.
+	StatTimeout time.Duration `yaml:"stat_timeout"`
+}
+
+// ProcessGroupConfig selects the processes of a group. Every criterion set must match, at least one is required.
+type ProcessGroupConfig struct {
+	Name string `yaml:"name"`
+	// Process is a regular expression matched against the process name and its command line, e.g. "^postgres".
+	Process string `yaml:"process"`
+	User    string `yaml:"user"`
+	// Cgroup matches the processes of the cgroup, relative to the cgroupfs root, and of its descendants.
+	Cgroup string `yaml:"cgroup"`
+	// Unit is a glob pattern of the systemd unit of the processes, e.g. "postgresql@*.service".
+	Unit string `yaml:"unit"`
+}
+
+type CgroupConfig struct {
+	// Path of the cgroup to read, relative to the cgroupfs root. The agent's own cgroup is read when empty.
+	Path string `yaml:"path"`
+}
+
+type CollectorsIntervals struct {
+	// CpuSample is the window over which CPU usage is measured.
+	CpuSample time.Duration `yaml:"cpu_sample"`
+}
+
+func (c CollectorsConfig) IsEnabled(collector string) bool {
+	return len(c.Enabled) == 0 || slices.Contains(c.Enabled, collector)
+}
+
+// TimeoutFor returns the collection timeout of the collector.
+func (c CollectorsConfig) TimeoutFor(collector string) time.Duration {
+	if timeout, ok := c.Timeouts[collector]; ok {
+		return timeout
+	}
+	return c.Timeout
+}
+
+type ExporterConfig struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Listen is the HTTP address of a prometheus exporter.
+	Listen string `yaml:"listen"`
+	// URL is the endpoint alert events are posted to by a webhook exporter.
+	URL string `yaml:"url"`
+}
+
+type AlertsConfig struct {
+	Interval time.Duration `yaml:"interval"`
+	Rules    []AlertRule   `yaml:"rules"`
+}
+
+type ProcessWatchConfig struct {
+	Interval  time.Duration          `yaml:"interval"`
+	Processes []WatchedProcessConfig `yaml:"processes"`
+}
+
+// WatchedProcessConfig selects a process like a process group. The oldest matching process is watched,
+// e.g. the master process of a daemon with worker processes.
+type WatchedProcessConfig struct {
+	ProcessGroupConfig `yaml:",inline"`
+	// MaxRestarts reports the process as flapping when it restarts more often within RestartWindow, 0 disables it.
+	MaxRestarts   int           `yaml:"max_restarts"`
+	RestartWindow time.Duration `yaml:"restart_window"`
+}
+
+type AlertRule struct {
+	Name   string  `yaml:"name"`
+	Family string  `yaml:"family"`
+	Warn   float64 `yaml:"warn"`
+	Crit   float64 `yaml:"crit"`
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Listen: ":50051",
+		},
+		Collectors: CollectorsConfig{
+			Intervals: CollectorsIntervals{
+				CpuSample: 200 * time.Millisecond,
+			},
+			Timeout: 10 * time.Second,
+			Containers: ContainersConfig{
+				Socket: "/var/run/docker.sock",
+			},
+			Mounts: MountsConfig{
+				StatTimeout: 5 * time.Second,
+			},
+		},
+		Alerts: AlertsConfig{
+			Interval: 30 * time.Second,
+		},
+		ProcessWatch: ProcessWatchConfig{
+			Interval: 10 * time.Second,
+		},
+	}
+}
+
+// Load reads the YAML config file at path on top of the defaults and applies METRIGO_* environment overrides.
+// An empty path loads the defaults with environment overrides only. Enabled collectors are validated against knownCollectors.
+func Load(path string, knownCollectors []string) (Config, error) {
+	var data []byte
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read config file: %v", err)
+		}
+	}
+	return Parse(data, os.LookupEnv, knownCollectors)
+}
+
+// Parse decodes YAML data on top of the defaults, applies environment overrides and validates the result.
+func Parse(data []byte, lookupEnv func(string) (string, bool), knownCollectors []string) (Config, error) {
+	cfg := Default()
+	if len(bytes.TrimSpace(data)) > 0 {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil {
+			return Config{}, fmt.Errorf("failed to parse config: %v", err)
+		}
+	}
+	if err := applyEnvOverrides(&cfg, lookupEnv); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(knownCollectors); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate returns all validation errors, each prefixed with the offending key.
+func (c Config) Validate(knownCollectors []string) error {
+	var errs []error
+	addErr := func(key string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Server.Listen == "" {
+		addErr("server.listen", "must not be empty")
+	}
+	tls := c.Server.TLS
+	if tls.CertFile != "" && tls.KeyFile == "" {
+		addErr("server.tls.key_file", "required when server.tls.cert_file is set")
+	}
+	if tls.KeyFile != "" && tls.CertFile == "" {
+		addErr("server.tls.cert_file", "required when server.tls.key_file is set")
+	}
+	if tls.ClientCAFile != "" && !tls.Enabled() {
+		addErr("server.tls.client_ca_file", "requires server.tls.cert_file and server.tls.key_file")
+	}
+	for i, token := range c.Server.Auth.Tokens {
+		if strings.TrimSpace(token) == "" {
+			addErr(fmt.Sprintf("server.auth.tokens[%d]", i), "must not be empty")
+		}
+	}
+
+	for i, collector := range c.Collectors.Enabled {
+		if !slices.Contains(knownCollectors, collector) {
+			addErr(fmt.Sprintf("collectors.enabled[%d]", i), "unknown collector %q, available: %s", collector, strings.Join(knownCollectors, ", "))
+		}
+	}
+	if c.Collectors.Intervals.CpuSample <= 0 {
+		addErr("collectors.intervals.cpu_sample", "must be positive")
+	}
+	if c.Collectors.Containers.Socket == "" {
+		addErr("collectors.containers.socket", "must not be empty")
+	}
+	for i, pattern := range c.Collectors.Systemd.Units {
+		if _, err := path.Match(pattern, ""); err != nil {
+			addErr(fmt.Sprintf("collectors.systemd.units[%d]", i), "invalid pattern %q: %v", pattern, err)
+		}
+	}
+	if c.Collectors.Mounts.StatTimeout <= 0 {
+		addErr("collectors.mounts.stat_timeout", "must be positive")
+	}
+	groupNames := map[string]bool{}
+	for i, group := range c.Collectors.ProcessGroups {
+		validateProcessGroup(fmt.Sprintf("collectors.process_groups[%d]", i), group, groupNames, addErr)
+	}
+	roots := c.Collectors.Roots
+	for _, root := range []struct{ key, path string }{
+		{"root", roots.Root}, {"proc", roots.Proc}, {"sys", roots.Sys}, {"etc", roots.Etc}, {"run", roots.Run}, {"var", roots.Var},
+	} {
+		if root.path != "" && !filepath.IsAbs(root.path) {
+			addErr("collectors.roots."+root.key, "must be an absolute path")
+		}
+	}
+	if c.Collectors.Timeout < 0 {
+		addErr("collectors.timeout", "must not be negative")
+	}
+	for _, collector := range slices.Sorted(maps.Keys(c.Collectors.Timeouts)) {
+		key := fmt.Sprintf("collectors.timeouts.%s", collector)
+		if !slices.Contains(knownCollectors, collector) {
+			addErr(key, "unknown collector %q, available: %s", collector, strings.Join(knownCollectors, ", "))
+		}
+		if c.Collectors.Timeouts[collector] < 0 {
+			addErr(key, "must not be negative")
+		}
+	}
+	if timeout := c.Collectors.TimeoutFor("cpu"); timeout > 0 && timeout <= c.Collectors.Intervals.CpuSample {
+		addErr("collectors.timeouts.cpu", "must be greater than collectors.intervals.cpu_sample (%v)", c.Collectors.Intervals.CpuSample)
+	}
+	// A stale mount is only reported when its stat times out before the collection does.
+	if timeout := c.Collectors.TimeoutFor("mounts"); c.Collectors.IsEnabled("mounts") && timeout > 0 && c.Collectors.Mounts.StatTimeout >= timeout {
+		addErr("collectors.mounts.stat_timeout", "must be shorter than the mounts collector timeout (%v)", timeout)
+	}
+
+	exporterNames := map[string]bool{}
+	for i, exporter := range c.Exporters {
+		key := fmt.Sprintf("exporters[%d]", i)
+		if exporter.Name == "" {
+			addErr(key+".name", "must not be empty")
+		} else if exporterNames[exporter.Name] {
+			addErr(key+".name", "duplicate exporter name %q", exporter.Name)
+		}
+		exporterNames[exporter.Name] = true
+
+		switch exporter.Type {
+		case ExporterPrometheus:
+			if exporter.Listen == "" {
+				addErr(key+".listen", "required for %s exporter", exporter.Type)
+			}
+		case ExporterWebhook:
+			if u, err := url.Parse(exporter.URL); err != nil || u.Scheme == "" || u.Host == "" {
+				addErr(key+".url", "must be an absolute URL for %s exporter", exporter.Type)
+			}
+		default:
+			addErr(key+".type", "unknown exporter type %q, available: %s", exporter.Type, strings.Join(ExporterTypes, ", "))
+		}
+	}
+
+	if len(c.Alerts.Rules) > 0 && c.Alerts.Interval <= 0 {
+		addErr("alerts.interval", "must be positive")
+	}
+	ruleNames := map[string]bool{}
+	for i, rule := range c.Alerts.Rules {
+		key := fmt.Sprintf("alerts.rules[%d]", i)
+		if rule.Name == "" {
+			addErr(key+".name", "must not be empty")
+		} else if ruleNames[rule.Name] {
+			addErr(key+".name", "duplicate rule name %q", rule.Name)
+		}
+		ruleNames[rule.Name] = true
+
+		// The family and the thresholds are validated by the agent, which knows the check families.
+		if slices.Contains(knownCollectors, rule.Family) && !c.Collectors.IsEnabled(rule.Family) {
+			addErr(key+".family", "collector %q is not enabled", rule.Family)
+		}
+	}
+
+	if len(c.ProcessWatch.Processes) > 0 && c.ProcessWatch.Interval <= 0 {
+		addErr("process_watch.interval", "must be positive")
+	}
+	watchedNames := map[string]bool{}
+	for i, process := range c.ProcessWatch.Processes {
+		key := fmt.Sprintf("process_watch.processes[%d]", i)
+		validateProcessGroup(key, process.ProcessGroupConfig, watchedNames, addErr)
+		if process.MaxRestarts < 0 {
+			addErr(key+".max_restarts", "must not be negative")
+		}
+		if process.MaxRestarts > 0 && process.RestartWindow <= 0 {
+			addErr(key+".restart_window", "must be positive when max_restarts is set")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateProcessGroup validates the selection of a process group or a watched process, whose names must be unique within names.
+func validateProcessGroup(key string, group ProcessGroupConfig, names map[string]bool, addErr func(key string, format string, args ...any)) {
+	if group.Name == "" {
+		addErr(key+".name", "must not be empty")
+	} else if names[group.Name] {
+		addErr(key+".name", "duplicate name %q", group.Name)
+	}
+	names[group.Name] = true
+	if group.Process == "" && group.User == "" && group.Cgroup == "" && group.Unit == "" {
+		addErr(key, "at least one of process, user, cgroup or unit is required")
+	}
+	if _, err := regexp.Compile(group.Process); err != nil {
+		addErr(key+".process", "invalid regular expression %q: %v", group.Process, err)
+	}
+	if _, err := path.Match(group.Unit, ""); err != nil {
+		addErr(key+".unit", "invalid pattern %q: %v", group.Unit, err)
+	}
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+var knownCollectors = []string{"cpu", "temp", "mem", "host", "net", "disk", "load"}
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            string
+		env             map[string]string
+		wantReturn      func() Config
+		wantErrContains []string
+	}{
+		{
+			name:       "empty config returns defaults",
+			data:       "",
+			wantReturn: Default,
+		},
+		{
+			name: "full config",
+			data: `
+server:
+  listen: "127.0.0.1:6000"
+  tls:
+    cert_file: /etc/metrigo/cert.pem
+    key_file: /etc/metrigo/key.pem
+  auth:
+    tokens: ["secret"]
+collectors:
+  enabled: [cpu, mem]
+  intervals:
+    cpu_sample: 1s
+  timeout: 5s
+  timeouts:
+    temp: 2s
+  containers:
+    socket: /run/podman/podman.sock
+  systemd:
+    units: ["*.service", "*.mount"]
+  mounts:
+    stat_timeout: 1s
+  process_groups:
+    - name: postgres
+      process: "^postgres"
+      user: postgres
+    - name: nginx
+      unit: nginx.service
+exporters:
+  - name: prom
+    type: prometheus
+    listen: ":9273"
+  - name: hook
+    type: webhook
+    url: http://alerts.local/hook
+alerts:
+  interval: 1m
+  rules:
+    - name: high-cpu
+      family: cpu
+      warn: 80
+      crit: 90
+process_watch:
+  interval: 5s
+  processes:
+    - name: sshd
+      process: "^sshd$"
+      user: root
+      max_restarts: 3
+      restart_window: 10m
+`,
+			wantReturn: func() Config {
+				return Config{
+					Server: ServerConfig{
+						Listen: "127.0.0.1:6000",
+						TLS:    TLSConfig{CertFile: "/etc/metrigo/cert.pem", KeyFile: "/etc/metrigo/key.pem"},
+						Auth:   AuthConfig{Tokens: []string{"secret"}},
+					},
+					Collectors: CollectorsConfig{
+						Enabled:    []string{"cpu", "mem"},
+						Intervals:  CollectorsIntervals{CpuSample: time.Second},
+						Timeout:    5 * time.Second,
+						Timeouts:   map[string]time.Duration{"temp": 2 * time.Second},
+						Containers: ContainersConfig{Socket: "/run/podman/podman.sock"},
+						Systemd:    SystemdConfig{Units: []string{"*.service", "*.mount"}},
+						Mounts:     MountsConfig{StatTimeout: time.Second},
+						ProcessGroups: []ProcessGroupConfig{
+							{Name: "postgres", Process: "^postgres", User: "postgres"},
+							{Name: "nginx", Unit: "nginx.service"},
+						},
+					},
+					Exporters: []ExporterConfig{
+						{Name: "prom", Type: ExporterPrometheus, Listen: ":9273"},
+						{Name: "hook", Type: ExporterWebhook, URL: "http://alerts.local/hook"},
+					},
+					Alerts: AlertsConfig{
+						Interval: time.Minute,
+						Rules:    []AlertRule{{Name: "high-cpu", Family: "cpu", Warn: 80, Crit: 90}},
+					},
+					ProcessWatch: ProcessWatchConfig{
+						Interval: 5 * time.Second,
+						Processes: []WatchedProcessConfig{{
+							ProcessGroupConfig: ProcessGroupConfig{Name: "sshd", Process: "^sshd$", User: "root"},
+							MaxRestarts:        3,
+							RestartWindow:      10 * time.Minute,
+						}},
+					},
+				}
+			},
+		},
+		{
+			name: "environment overrides file values",
+			data: "server:\n  listen: \":6000\"\n",
+			env: map[string]string{
+				"METRIGO_SERVER_LISTEN":                   ":7000",
+				"METRIGO_SERVER_AUTH_TOKENS":              "a, b",
+				"METRIGO_COLLECTORS_ENABLED":              "cpu,temp",
+				"METRIGO_COLLECTORS_INTERVALS_CPU_SAMPLE": "500ms",
+				"METRIGO_COLLECTORS_TIMEOUT":              "3s",
+				"METRIGO_COLLECTORS_ROOTS_PROC":           "/host/proc",
+				"METRIGO_COLLECTORS_ROOTS_VAR":            "/host/var",
+			},
+			wantReturn: func() Config {
+				cfg := Default()
+				cfg.Server.Listen = ":7000"
+				cfg.Server.Auth.Tokens = []string{"a", "b"}
+				cfg.Collectors.Enabled = []string{"cpu", "temp"}
+				cfg.Collectors.Intervals.CpuSample = 500 * time.Millisecond
+				cfg.Collectors.Timeout = 3 * time.Second
+				cfg.Collectors.Roots.Proc = "/host/proc"
+				cfg.Collectors.Roots.Var = "/host/var"
+				return cfg
+			},
+		},
+		{
+			name:            "invalid environment override points at key and variable",
+			env:             map[string]string{"METRIGO_ALERTS_INTERVAL": "soon"},
+			wantErrContains: []string{"alerts.interval (from METRIGO_ALERTS_INTERVAL)"},
+		},
+		{
+			name:            "unknown key is rejected",
+			data:            "server:\n  port: 1\n",
+			wantErrContains: []string{"field port not found"},
+		},
+		{
+			name: "validation errors point at offending keys",
+			data: `
+server:
+  listen: ""
+  tls:
+    cert_file: cert.pem
+collectors:
+  enabled: [cpu, gpu]
+  intervals:
+    cpu_sample: 0s
+  timeout: -1s
+  timeouts:
+    gpu: 1s
+  systemd:
+    units: ["*.service", "[nginx"]
+  mounts:
+    stat_timeout: 0s
+  process_groups:
+    - name: postgres
+      process: "(postgres"
+    - name: postgres
+    - name: ""
+      user: www-data
+  roots:
+    proc: host/proc
+    var: var
+exporters:
+  - name: prom
+    type: graphite
+  - name: hook
+    type: webhook
+    url: not-a-url
+alerts:
+  rules:
+    - name: hot
+      family: temp
+      warn: 90
+      crit: 80
+process_watch:
+  interval: 0s
+  processes:
+    - name: sshd
+      unit: "[ssh"
+      max_restarts: 3
+`,
+			wantErrContains: []string{
+				"server.listen: must not be empty",
+				"server.tls.key_file: required when server.tls.cert_file is set",
+				"collectors.enabled[1]: unknown collector \"gpu\"",
+				"collectors.intervals.cpu_sample: must be positive",
+				"collectors.timeout: must not be negative",
+				"collectors.systemd.units[1]: invalid pattern \"[nginx\"",
+				"collectors.mounts.stat_timeout: must be positive",
+				"collectors.process_groups[0].process: invalid regular expression \"(postgres\"",
+				"collectors.process_groups[1].name: duplicate name \"postgres\"",
+				"collectors.process_groups[1]: at least one of process, user, cgroup or unit is required",
+				"collectors.process_groups[2].name: must not be empty",
+				"collectors.roots.proc: must be an absolute path",
+				"collectors.roots.var: must be an absolute path",
+				"collectors.timeouts.gpu: unknown collector \"gpu\"",
+				"exporters[0].type: unknown exporter type \"graphite\"",
+				"exporters[1].url: must be an absolute URL",
+				"alerts.rules[0].family: collector \"temp\" is not enabled",
+				"process_watch.interval: must be positive",
+				"process_watch.processes[0].unit: invalid pattern \"[ssh\"",
+				"process_watch.processes[0].restart_window: must be positive when max_restarts is set",
+			},
+		},
+		{
+			name:            "cpu timeout must outlast the cpu sample",
+			data:            "collectors:\n  intervals:\n    cpu_sample: 1s\n  timeouts:\n    cpu: 500ms\n",
+			wantErrContains: []string{"collectors.timeouts.cpu: must be greater than collectors.intervals.cpu_sample (1s)"},
+		},
+		{
+			name:            "mount stat timeout must be shorter than the mounts timeout",
+			data:            "collectors:\n  timeout: 3s\n  mounts:\n    stat_timeout: 5s\n",
+			wantErrContains: []string{"collectors.mounts.stat_timeout: must be shorter than the mounts collector timeout (3s)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.data), envFrom(tt.env), knownCollectors)
+			if len(tt.wantErrContains) > 0 {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErrContains)
+				}
+				for _, substr := range tt.wantErrContains {
+					if !strings.Contains(err.Error(), substr) {
+						t.Errorf("expected error containing %q, got \"%v\"", substr, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want := tt.wantReturn(); !reflect.DeepEqual(want, cfg) {
+				t.Errorf("expected %+v, got %+v", want, cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const envPrefix = "METRIGO"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnvOverrides overrides scalar and string list fields with METRIGO_* environment variables.
+// Variable names are built from the YAML keys, e.g. server.tls.cert_file is METRIGO_SERVER_TLS_CERT_FILE.
+// Lists are given as comma separated values. Lists of objects (exporters, alert rules) and maps (collector timeouts) can only be set in the file.
+func applyEnvOverrides(cfg *Config, lookupEnv func(string) (string, bool)) error {
+	return overrideStruct(reflect.ValueOf(cfg).Elem(), envPrefix, "", lookupEnv)
+}
+
+func overrideStruct(v reflect.Value, envName string, key string, lookupEnv func(string) (string, bool)) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		tag := t.Field(i).Tag.Get("yaml")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		fieldEnvName := envName + "_" + strings.ToUpper(tag)
+		fieldKey := tag
+		if key != "" {
+			fieldKey = key + "." + tag
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := overrideStruct(field, fieldEnvName, fieldKey, lookupEnv); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.Kind() == reflect.Map || field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.String {
+			continue
+		}
+
+		value, ok := lookupEnv(fieldEnvName)
+		if !ok {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("%s (from %s): %v", fieldKey, fieldEnvName, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
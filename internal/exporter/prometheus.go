@@ -0,0 +1,87 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Matyjash/Metrigo/internal/collector"
+)
+
+const (
+	prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+	prometheusNamespace   = "metrigo"
+)
+
+// PrometheusHandler serves the samples of the enabled collectors in the Prometheus text exposition format.
+// Metrics are named metrigo_<collector>_<metric>.
+type PrometheusHandler struct {
+	registry *collector.Registry
+	enabled  func(collector string) bool
+}
+
+func NewPrometheusHandler(registry *collector.Registry, enabled func(collector string) bool) *PrometheusHandler {
+	return &PrometheusHandler{
+		registry: registry,
+		enabled:  enabled,
+	}
+}
+
+func (h *PrometheusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	for _, c := range h.registry.Collectors() {
+		if !h.enabled(c.Name()) {
+			continue
+		}
+		samples, err := c.Collect(r.Context())
+		if err != nil {
+			log.Printf("prometheus exporter: collector %s: %v", c.Name(), err)
+			continue
+		}
+		writeCollector(w, c, samples)
+	}
+}
+
+func writeCollector(w io.Writer, c collector.Collector, samples []collector.Sample) {
+	for _, descriptor := range c.Descriptors() {
+		name := prometheusNamespace + "_" + c.Name() + "_" + descriptor.Name
+		fmt.Fprintf(w, "# HELP %s %s\n", name, descriptor.Help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, descriptor.Type)
+		for _, sample := range samples {
+			if sample.Metric != descriptor.Name {
+				continue
+			}
+			fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(sample.Labels), strconv.FormatFloat(sample.Value, 'g', -1, 64))
+		}
+	}
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabelValue(labels[name]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelValueEscaper escapes the only characters the text format allows escaping in label values.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes a label value for the text format. Other characters, e.g. tabs or non-ASCII ones,
+// are written as they are, invalid UTF-8 bytes are replaced with U+FFFD.
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(strings.ToValidUTF8(value, "\uFFFD"))
+}
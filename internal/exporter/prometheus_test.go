@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/collector"
+	"github.com/Matyjash/Metrigo/internal/config"
+)
+
+func Test_PrometheusHandler(t *testing.T) {
+	registry := collector.NewRegistry()
+	registry.MustRegister(
+		collector.New("disk", "disk usage", []collector.Descriptor{
+			{Name: "used_bytes", Help: "Used disk space in bytes.", Labels: []string{"path", "fstype"}},
+		}, func(ctx context.Context) ([]collector.Sample, error) {
+			return []collector.Sample{{Metric: "used_bytes", Labels: map[string]string{"path": "/", "fstype": "ext4"}, Value: 100}}, nil
+		}),
+		collector.New("host", "host info", []collector.Descriptor{
+			{Name: "uptime_seconds", Help: "Host uptime in seconds.", Type: collector.Counter},
+		}, func(ctx context.Context) ([]collector.Sample, error) {
+			return []collector.Sample{{Metric: "uptime_seconds", Value: 120}}, nil
+		}),
+		collector.New("temp", "temperatures", []collector.Descriptor{
+			{Name: "celsius", Help: "Temperature."},
+		}, func(ctx context.Context) ([]collector.Sample, error) {
+			return nil, fmt.Errorf("no temperature sensors found")
+		}),
+		collector.New("load", "load", []collector.Descriptor{
+			{Name: "load1", Help: "1 minute load average."},
+		}, func(ctx context.Context) ([]collector.Sample, error) {
+			return []collector.Sample{{Metric: "load1", Value: 0.5}}, nil
+		}),
+	)
+	enabled := config.CollectorsConfig{Enabled: []string{"disk", "host", "temp"}}
+	handler := NewPrometheusHandler(registry, enabled.IsEnabled)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	wantContains := []string{
+		"# HELP metrigo_disk_used_bytes Used disk space in bytes.",
+		"# TYPE metrigo_disk_used_bytes gauge",
+		`metrigo_disk_used_bytes{fstype="ext4",path="/"} 100`,
+		"# TYPE metrigo_host_uptime_seconds counter",
+		"metrigo_host_uptime_seconds 120",
+	}
+	for _, substr := range wantContains {
+		if !strings.Contains(body, substr) {
+			t.Errorf("body = %v, want contains %v", body, substr)
+		}
+	}
+
+	for _, unwanted := range []string{"metrigo_temp_celsius", "metrigo_load_load1"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("body contains metric %s of a failing or disabled collector", unwanted)
+		}
+	}
+}
+
+func Test_formatLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   string
+	}{
+		{name: "no labels", want: ""},
+		{name: "sorted by name", labels: map[string]string{"path": "/", "fstype": "ext4"}, want: `{fstype="ext4",path="/"}`},
+		{name: "backslash, quote and newline", labels: map[string]string{"description": "C:\\dir \"quoted\"\nnext"}, want: `{description="C:\\dir \"quoted\"\nnext"}`},
+		{name: "tab and non-ASCII written as they are", labels: map[string]string{"path": "/mnt/caf\u00e9\tdata"}, want: "{path=\"/mnt/caf\u00e9\tdata\"}"},
+		{name: "invalid UTF-8", labels: map[string]string{"path": "/mnt/\xff"}, want: "{path=\"/mnt/\ufffd\"}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatLabels(tt.labels); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package metrics
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+// cgroup v1 reports an unlimited memory limit as the largest page aligned int64.
+const cgroupV1UnlimitedMemory = 1 << 62
+
+// ErrNoCgroup is returned when the cgroup does not exist or no controller is mounted for it.
+var ErrNoCgroup = errors.New("cgroup not found")
+
+// cgroupReader reads cgroup v2 or v1 statistics from a cgroupfs mounted at root.
+type cgroupReader struct {
+	root     string
+	procRoot string
+}
+
+// stats returns the statistics of the cgroup at path, relative to the cgroupfs root.
+// An empty path reads the cgroup of the current process. Statistics of controllers
+// that are not enabled for the cgroup are left empty.
+func (r cgroupReader) stats(path string) (models.CgroupStats, error) {
+	if _, err := os.Stat(filepath.Join(r.root, "cgroup.controllers")); err == nil {
+		return r.statsV2(path)
+	}
+	return r.statsV1(path)
+}
+
+func (r cgroupReader) statsV2(path string) (models.CgroupStats, error) {
+	if path == "" {
+		paths, err := r.ownCgroups()
+		if err != nil {
+			return models.CgroupStats{}, err
+		}
+		path = paths[""]
+	}
+	dir := filepath.Join(r.root, path)
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return models.CgroupStats{}, fmt.Errorf("%w: %s", ErrNoCgroup, path)
+		}
+		return models.CgroupStats{}, fmt.Errorf("failed to open cgroup %s: %v", path, err)
+	}
+	stats := models.CgroupStats{Path: path, Version: 2}
+
+	cpuMax, err := readOptionalFile(filepath.Join(dir, "cpu.max"))
+	if err != nil {
+		return models.CgroupStats{}, err
+	}
+	if fields := strings.Fields(cpuMax); len(fields) == 2 && fields[0] != "max" {
+		stats.Cpu.QuotaCores, err = quotaCores(fields[0], fields[1])
+		if err != nil {
+			return models.CgroupStats{}, fmt.Errorf("failed to parse cpu.max: %v", err)
+		}
+	}
+	cpuStat, err := readCgroupKeyValues(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return models.CgroupStats{}, err
+	}
+	stats.Cpu.UsageSeconds = float64(cpuStat["usage_usec"]) / 1e6
+	stats.Cpu.Periods = cpuStat["nr_periods"]
+	stats.Cpu.ThrottledPeriods = cpuStat["nr_throttled"]
+	stats.Cpu.ThrottledSeconds = float64(cpuStat["throttled_usec"]) / 1e6
+
+	memoryMax, err := readOptionalFile(filepath.Join(dir, "memory.max"))
+	if err != nil {
+		return models.CgroupStats{}, err
+	}
+	if memoryMax != "" && memoryMax != "max" {
+		if stats.Memory.LimitB, err = strconv.ParseUint(memoryMax, 10, 64); err != nil {
+			return models.CgroupStats{}, fmt.Errorf("failed to parse memory.max: %v", err)
+		}
+	}
+	if stats.Memory.UsageB, err = readOptionalUint(filepath.Join(dir, "memory.current")); err != nil {
+		return models.CgroupStats{}, err
+	}
+	memoryEvents, err := readCgroupKeyValues(filepath.Join(dir, "memory.events"))
+	if err != nil {
+		return models.CgroupStats{}, err
+	}
+	stats.Memory.OOMEvents = memoryEvents["oom"]
+	stats.Memory.OOMKillEvents = memoryEvents["oom_kill"]
+
+	if stats.IO, err = readIOStatV2(filepath.Join(dir, "io.stat")); err != nil {
+		return models.CgroupStats{}, err
+	}
+	return stats, nil
+}
+
+func (r cgroupReader) statsV1(path string) (models.CgroupStats, error) {
+	controllerPath := func(controller string) string {
+		return path
+	}
+	if path == "" {
+		paths, err := r.ownCgroups()
+		if err != nil {
+			return models.CgroupStats{}, err
+		}
+		controllerPath = func(controller string) string {
+			return paths[controller]
+		}
+		path = cmp.Or(paths["memory"], paths["cpu"])
+	}
+	stats := models.CgroupStats{Path: path, Version: 1}
+	found := false
+
+	if cpuDir := r.controllerDir("cpu", controllerPath("cpu")); cpuDir != "" {
+		found = true
+		quota, err := readOptionalFile(filepath.Join(cpuDir, "cpu.cfs_quota_us"))
+		if err != nil {
+			return models.CgroupStats{}, err
+		}
+		period, err := readOptionalFile(filepath.Join(cpuDir, "cpu.cfs_period_us"))
+		if err != nil {
+			return models.CgroupStats{}, err
+		}
+		if quota != "" && quota != "-1" && period != "" {
+			if stats.Cpu.QuotaCores, err = quotaCores(quota, period); err != nil {
+				return models.CgroupStats{}, fmt.Errorf("failed to parse cpu.cfs_quota_us: %v", err)
+			}
+		}
+		cpuStat, err := readCgroupKeyValues(filepath.Join(cpuDir, "cpu.stat"))
+		if err != nil {
+			return models.CgroupStats{}, err
+		}
+		stats.Cpu.Periods = cpuStat["nr_periods"]
+		stats.Cpu.ThrottledPeriods = cpuStat["nr_throttled"]
+		stats.Cpu.ThrottledSeconds = float64(cpuStat["throttled_time"]) / 1e9
+	}
+	if cpuacctDir := r.controllerDir("cpuacct", controllerPath("cpuacct")); cpuacctDir != "" {
+		found = true
+		usage, err := readOptionalUint(filepath.Join(cpuacctDir, "cpuacct.usage"))
+		if err != nil {
+			return models.CgroupStats{}, err
+		}
+		stats.Cpu.UsageSeconds = float64(usage) / 1e9
+	}
+
+	if memoryDir := r.controllerDir("memory", controllerPath("memory")); memoryDir != "" {
+		found = true
+		limit, err := readOptionalUint(filepath.Join(memoryDir, "memory.limit_in_bytes"))
+		if err != nil {
+			return models.CgroupStats{}, err
+		}
+		if limit < cgroupV1UnlimitedMemory {
+			stats.Memory.LimitB = limit
+		}
+		if stats.Memory.UsageB, err = readOptionalUint(filepath.Join(memoryDir, "memory.usage_in_bytes")); err != nil {
+			return models.CgroupStats{}, err
+		}
+		// memory.failcnt counts the times the usage hit the limit and memory was reclaimed, not OOMs, so OOMEvents is left unset.
+		oomControl, err := readCgroupKeyValues(filepath.Join(memoryDir, "memory.oom_control"))
+		if err != nil {
+			return models.CgroupStats{}, err
+		}
+		stats.Memory.OOMKillEvents = oomControl["oom_kill"]
+	}
+
+	if blkioDir := r.controllerDir("blkio", controllerPath("blkio")); blkioDir != "" {
+		found = true
+		var err error
+		if stats.IO, err = readIOStatV1(blkioDir); err != nil {
+			return models.CgroupStats{}, err
+		}
+	}
+
+	if !found {
+		return models.CgroupStats{}, fmt.Errorf("%w: no cpu, memory or blkio controller found for %s under %s", ErrNoCgroup, path, r.root)
+	}
+	return stats, nil
+}
+
+// controllerDir returns the directory of the cgroup in the v1 hierarchy of the controller,
+// or an empty string when the hierarchy or the cgroup does not exist. Controllers mounted
+// together, e.g. cpu,cpuacct, are found through the symlinks systemd creates for each of them.
+func (r cgroupReader) controllerDir(controller string, path string) string {
+	dir := filepath.Join(r.root, controller, path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// ownCgroups returns the cgroup paths of the current process by controller, the v2 path under the empty key.
+func (r cgroupReader) ownCgroups() (map[string]string, error) {
+	file, err := os.Open(filepath.Join(r.procRoot, "self", "cgroup"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read own cgroup: %w", err)
+	}
+	defer file.Close()
+
+	paths := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[strings.TrimPrefix(controller, "name=")] = parts[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read own cgroup: %v", err)
+	}
+	return paths, nil
+}
+
+func quotaCores(quota string, period string) (float64, error) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil {
+		return 0, err
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil {
+		return 0, err
+	}
+	if p <= 0 {
+		return 0, fmt.Errorf("invalid period %s", period)
+	}
+	return q / p, nil
+}
+
+// readOptionalFile returns the trimmed content of the file, or an empty string when it does not exist.
+func readOptionalFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readOptionalUint(path string) (uint64, error) {
+	content, err := readOptionalFile(path)
+	if err != nil || content == "" {
+		return 0, err
+	}
+	value, err := strconv.ParseUint(content, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return value, nil
+}
+
+// readCgroupKeyValues parses files of "key value" lines such as cpu.stat and memory.events.
+func readCgroupKeyValues(path string) (map[string]uint64, error) {
+	content, err := readOptionalFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]uint64{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		values[fields[0]] = value
+	}
+	return values, nil
+}
+
+// readIOStatV2 parses io.stat lines such as "8:0 rbytes=1024 wbytes=2048 rios=1 wios=2 dbytes=0 dios=0".
+func readIOStatV2(path string) ([]models.CgroupIOStats, error) {
+	content, err := readOptionalFile(path)
+	if err != nil || content == "" {
+		return nil, err
+	}
+	var ioStats []models.CgroupIOStats
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		stat := models.CgroupIOStats{Device: fields[0]}
+		for _, field := range fields[1:] {
+			key, rawValue, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+			value, err := strconv.ParseUint(rawValue, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+			}
+			switch key {
+			case "rbytes":
+				stat.ReadB = value
+			case "wbytes":
+				stat.WriteB = value
+			case "rios":
+				stat.ReadOps = value
+			case "wios":
+				stat.WriteOps = value
+			}
+		}
+		ioStats = append(ioStats, stat)
+	}
+	return ioStats, nil
+}
+
+// readIOStatV1 parses the blkio throttle files of lines such as "8:0 Read 1024", ending with a "Total" line.
+func readIOStatV1(dir string) ([]models.CgroupIOStats, error) {
+	byDevice := map[string]*models.CgroupIOStats{}
+	read := func(file string, setRead, setWrite func(*models.CgroupIOStats, uint64)) error {
+		path := filepath.Join(dir, file)
+		content, err := readOptionalFile(path)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(content, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				continue
+			}
+			value, err := strconv.ParseUint(fields[2], 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %v", path, err)
+			}
+			stat, ok := byDevice[fields[0]]
+			if !ok {
+				stat = &models.CgroupIOStats{Device: fields[0]}
+				byDevice[fields[0]] = stat
+			}
+			switch fields[1] {
+			case "Read":
+				setRead(stat, value)
+			case "Write":
+				setWrite(stat, value)
+			}
+		}
+		return nil
+	}
+	if err := read("blkio.throttle.io_service_bytes",
+		func(s *models.CgroupIOStats, v uint64) { s.ReadB = v },
+		func(s *models.CgroupIOStats, v uint64) { s.WriteB = v },
+	); err != nil {
+		return nil, err
+	}
+	if err := read("blkio.throttle.io_serviced",
+		func(s *models.CgroupIOStats, v uint64) { s.ReadOps = v },
+		func(s *models.CgroupIOStats, v uint64) { s.WriteOps = v },
+	); err != nil {
+		return nil, err
+	}
+
+	var ioStats []models.CgroupIOStats
+	for _, stat := range byDevice {
+		ioStats = append(ioStats, *stat)
+	}
+	sort.Slice(ioStats, func(i, j int) bool { return ioStats[i].Device < ioStats[j].Device })
+	return ioStats, nil
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+// writeTree creates the files under root, keyed by their slash separated path.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		fullPath := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_cgroupReaderStats(t *testing.T) {
+	v2Files := map[string]string{
+		"cgroup/cgroup.controllers":              "cpu io memory pids\n",
+		"cgroup/system.slice/app/cpu.max":        "150000 100000\n",
+		"cgroup/system.slice/app/cpu.stat":       "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\nnr_periods 40\nnr_throttled 4\nthrottled_usec 300000\n",
+		"cgroup/system.slice/app/memory.max":     "536870912\n",
+		"cgroup/system.slice/app/memory.current": "104857600\n",
+		"cgroup/system.slice/app/memory.events":  "low 0\nhigh 0\nmax 7\noom 2\noom_kill 1\n",
+		"cgroup/system.slice/app/io.stat":        "8:0 rbytes=4096 wbytes=8192 rios=1 wios=2 dbytes=0 dios=0\n259:0 rbytes=10 wbytes=20 rios=3 wios=4 dbytes=0 dios=0\n",
+		"cgroup/unlimited/cpu.max":               "max 100000\n",
+		"cgroup/unlimited/memory.max":            "max\n",
+		"cgroup/unlimited/memory.current":        "1024\n",
+		"proc/self/cgroup":                       "0::/system.slice/app\n",
+	}
+	v1Files := map[string]string{
+		"cgroup/cpu,cpuacct/docker/abc/cpu.cfs_quota_us":          "50000\n",
+		"cgroup/cpu,cpuacct/docker/abc/cpu.cfs_period_us":         "100000\n",
+		"cgroup/cpu,cpuacct/docker/abc/cpu.stat":                  "nr_periods 10\nnr_throttled 5\nthrottled_time 2000000000\n",
+		"cgroup/cpu,cpuacct/docker/abc/cpuacct.usage":             "3000000000\n",
+		"cgroup/memory/docker/abc/memory.limit_in_bytes":          "9223372036854771712\n",
+		"cgroup/memory/docker/abc/memory.usage_in_bytes":          "2048\n",
+		"cgroup/memory/docker/abc/memory.failcnt":                 "3\n",
+		"cgroup/memory/docker/abc/memory.oom_control":             "oom_kill_disable 0\nunder_oom 0\noom_kill 1\n",
+		"cgroup/blkio/docker/abc/blkio.throttle.io_service_bytes": "8:0 Read 4096\n8:0 Write 8192\n8:0 Total 12288\nTotal 12288\n",
+		"cgroup/blkio/docker/abc/blkio.throttle.io_serviced":      "8:0 Read 1\n8:0 Write 2\n8:0 Total 3\nTotal 3\n",
+		"proc/self/cgroup": "12:blkio:/docker/abc\n4:memory:/docker/abc\n3:cpu,cpuacct:/docker/abc\n1:name=systemd:/docker/abc\n",
+	}
+
+	tests := []struct {
+		name            string
+		files           map[string]string
+		v1              bool
+		path            string
+		wantReturn      models.CgroupStats
+		wantErrContains string
+	}{
+		{
+			name:  "v2 own cgroup",
+			files: v2Files,
+			wantReturn: models.CgroupStats{
+				Path:    "/system.slice/app",
+				Version: 2,
+				Cpu:     models.CgroupCpuStats{QuotaCores: 1.5, UsageSeconds: 2.5, Periods: 40, ThrottledPeriods: 4, ThrottledSeconds: 0.3},
+				Memory:  models.CgroupMemoryStats{LimitB: 536870912, UsageB: 104857600, OOMEvents: 2, OOMKillEvents: 1},
+				IO: []models.CgroupIOStats{
+					{Device: "8:0", ReadB: 4096, WriteB: 8192, ReadOps: 1, WriteOps: 2},
+					{Device: "259:0", ReadB: 10, WriteB: 20, ReadOps: 3, WriteOps: 4},
+				},
+			},
+		},
+		{
+			name:  "v2 configured cgroup without limits and disabled controllers",
+			files: v2Files,
+			path:  "/unlimited",
+			wantReturn: models.CgroupStats{
+				Path:    "/unlimited",
+				Version: 2,
+				Memory:  models.CgroupMemoryStats{UsageB: 1024},
+			},
+		},
+		{
+			name:            "v2 missing cgroup",
+			files:           v2Files,
+			path:            "/missing",
+			wantErrContains: "cgroup not found: /missing",
+		},
+		{
+			name:  "v1 own cgroup",
+			files: v1Files,
+			v1:    true,
+			wantReturn: models.CgroupStats{
+				Path:    "/docker/abc",
+				Version: 1,
+				Cpu:     models.CgroupCpuStats{QuotaCores: 0.5, UsageSeconds: 3, Periods: 10, ThrottledPeriods: 5, ThrottledSeconds: 2},
+				Memory:  models.CgroupMemoryStats{UsageB: 2048, OOMKillEvents: 1},
+				IO:      []models.CgroupIOStats{{Device: "8:0", ReadB: 4096, WriteB: 8192, ReadOps: 1, WriteOps: 2}},
+			},
+		},
+		{
+			name:            "v1 missing cgroup",
+			files:           v1Files,
+			v1:              true,
+			path:            "/missing",
+			wantErrContains: "no cpu, memory or blkio controller found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			if tt.v1 {
+				// systemd links each co-mounted controller to the shared hierarchy.
+				for _, controller := range []string{"cpu", "cpuacct"} {
+					if err := os.Symlink("cpu,cpuacct", filepath.Join(root, "cgroup", controller)); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			reader := cgroupReader{root: filepath.Join(root, "cgroup"), procRoot: filepath.Join(root, "proc")}
+			stats, err := reader.stats(tt.path)
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, stats) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, stats)
+			}
+		})
+	}
+}
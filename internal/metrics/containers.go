@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+const DefaultContainerSocket = "/var/run/docker.sock"
+
+// ErrNoContainerRuntime is returned when no container runtime listens on the socket. Only runtimes serving
+// the Docker Engine API are supported, so the message names the ones that are not.
+var ErrNoContainerRuntime = errors.New("no container runtime found (only the Docker Engine API is supported, not containerd or CRI-O)")
+
+// containerRuntime is a client of the Docker Engine API, also served by Podman's Docker compatible socket.
+type containerRuntime struct {
+	mu      sync.Mutex
+	clients map[string]*http.Client
+}
+
+type dockerContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type dockerCpuStats struct {
+	CpuUsage struct {
+		TotalUsage uint64 `json:"total_usage"`
+	} `json:"cpu_usage"`
+	SystemCpuUsage uint64 `json:"system_cpu_usage"`
+	OnlineCpus     uint64 `json:"online_cpus"`
+}
+
+type dockerStats struct {
+	CpuStats    dockerCpuStats `json:"cpu_stats"`
+	PreCpuStats dockerCpuStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+	BlkioStats struct {
+		IoServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+}
+
+func (r *containerRuntime) client(socket string) *http.Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if client, ok := r.clients[socket]; ok {
+		return client
+	}
+	if r.clients == nil {
+		r.clients = map[string]*http.Client{}
+	}
+	var dialer net.Dialer
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		},
+	}}
+	r.clients[socket] = client
+	return client
+}
+
+// containers returns the stats of the running containers. Their stats are fetched concurrently,
+// as the runtime samples CPU usage for about a second to compute its percentage. Containers that
+// exited or were removed after being listed are skipped.
+func (r *containerRuntime) containers(ctx context.Context, socket string) ([]models.ContainerStats, error) {
+	client := r.client(socket)
+	var containers []dockerContainer
+	if err := getJSON(ctx, client, socket, "/containers/json", &containers); err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.ContainerStats, len(containers))
+	errs := make([]error, len(containers))
+	found := make([]bool, len(containers))
+	var wg sync.WaitGroup
+	for i, container := range containers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var raw dockerStats
+			err := getJSON(ctx, client, socket, "/containers/"+url.PathEscape(container.ID)+"/stats?stream=false", &raw)
+			var status *statusError
+			if errors.As(err, &status) && (status.code == http.StatusNotFound || status.code == http.StatusConflict) {
+				return
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("container %s: %w", container.ID, err)
+				return
+			}
+			stats[i] = containerStats(container, raw)
+			found[i] = true
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	var running []models.ContainerStats
+	for i := range stats {
+		if found[i] {
+			running = append(running, stats[i])
+		}
+	}
+	return running, nil
+}
+
+func containerStats(container dockerContainer, raw dockerStats) models.ContainerStats {
+	name := container.ID
+	if len(container.Names) > 0 {
+		name = strings.TrimPrefix(container.Names[0], "/")
+	}
+	stats := models.ContainerStats{
+		ID:           container.ID,
+		Name:         name,
+		Image:        container.Image,
+		State:        container.State,
+		Labels:       container.Labels,
+		MemoryLimitB: raw.MemoryStats.Limit,
+	}
+
+	// The previous sample is empty on the first read after the container started.
+	cpuDelta := float64(raw.CpuStats.CpuUsage.TotalUsage) - float64(raw.PreCpuStats.CpuUsage.TotalUsage)
+	systemDelta := float64(raw.CpuStats.SystemCpuUsage) - float64(raw.PreCpuStats.SystemCpuUsage)
+	if raw.PreCpuStats.SystemCpuUsage > 0 && cpuDelta > 0 && systemDelta > 0 {
+		stats.CpuPercent = cpuDelta / systemDelta * float64(raw.CpuStats.OnlineCpus) * 100
+	}
+
+	// Page cache is excluded the same way as by `docker stats`: inactive_file on cgroup v2, cache on v1.
+	cache := raw.MemoryStats.Stats["inactive_file"]
+	if cache == 0 {
+		cache = raw.MemoryStats.Stats["cache"]
+	}
+	if raw.MemoryStats.Usage > cache {
+		stats.MemoryUsageB = raw.MemoryStats.Usage - cache
+	}
+
+	for _, network := range raw.Networks {
+		stats.NetRxB += network.RxBytes
+		stats.NetTxB += network.TxBytes
+	}
+	for _, entry := range raw.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockReadB += entry.Value
+		case "write":
+			stats.BlockWriteB += entry.Value
+		}
+	}
+	return stats
+}
+
+// statusError is returned by getJSON when the runtime answers with a status other than 200 OK.
+type statusError struct {
+	path   string
+	code   int
+	status string
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned %s: %s", e.path, e.status, e.body)
+}
+
+func getJSON(ctx context.Context, client *http.Client, socket string, path string, target any) error {
+	// The host is ignored by the Unix socket dialer.
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost"+path, nil)
+	if err != nil {
+		return err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+			return fmt.Errorf("%w on %s: %v", ErrNoContainerRuntime, socket, err)
+		}
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return &statusError{path: path, code: response.StatusCode, status: response.Status, body: strings.TrimSpace(string(body))}
+	}
+	if err := json.NewDecoder(response.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode %s response: %v", path, err)
+	}
+	return nil
+}
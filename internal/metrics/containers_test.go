@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+const (
+	containersListResponse = `[
+		{"Id": "abc123", "Names": ["/web"], "Image": "nginx:1.27", "State": "running", "Labels": {"tier": "frontend"}},
+		{"Id": "def456", "Names": ["/db"], "Image": "postgres:17", "State": "running", "Labels": {}}
+	]`
+	webStatsResponse = `{
+		"cpu_stats": {"cpu_usage": {"total_usage": 3000}, "system_cpu_usage": 20000, "online_cpus": 4},
+		"precpu_stats": {"cpu_usage": {"total_usage": 1000}, "system_cpu_usage": 10000, "online_cpus": 4},
+		"memory_stats": {"usage": 5000, "limit": 10000, "stats": {"inactive_file": 1000}},
+		"networks": {"eth0": {"rx_bytes": 100, "tx_bytes": 200}, "eth1": {"rx_bytes": 1, "tx_bytes": 2}},
+		"blkio_stats": {"io_service_bytes_recursive": [
+			{"major": 8, "minor": 0, "op": "read", "value": 4096},
+			{"major": 8, "minor": 0, "op": "write", "value": 8192}
+		]}
+	}`
+	dbStatsResponse = `{
+		"cpu_stats": {"cpu_usage": {"total_usage": 500}, "system_cpu_usage": 500, "online_cpus": 2},
+		"memory_stats": {"usage": 3000, "limit": 8000, "stats": {"cache": 500}},
+		"blkio_stats": {"io_service_bytes_recursive": [
+			{"major": 8, "minor": 0, "op": "Read", "value": 10},
+			{"major": 8, "minor": 0, "op": "Write", "value": 20},
+			{"major": 8, "minor": 0, "op": "Total", "value": 30}
+		]}
+	}`
+)
+
+// serveStubRuntime serves the handler on a Unix socket in a temporary directory and returns the socket path.
+func serveStubRuntime(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return socket
+}
+
+func Test_containerRuntimeContainers(t *testing.T) {
+	responses := map[string]string{
+		"/containers/json":         containersListResponse,
+		"/containers/abc123/stats": webStatsResponse,
+		"/containers/def456/stats": dbStatsResponse,
+	}
+
+	tests := []struct {
+		name            string
+		handler         http.HandlerFunc
+		noRuntime       bool
+		wantReturn      []models.ContainerStats
+		wantErrContains string
+		wantErr         error
+	}{
+		{
+			name: "containers with stats",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/stats") && r.URL.Query().Get("stream") != "false" {
+					http.Error(w, "streaming not supported by stub", http.StatusBadRequest)
+					return
+				}
+				w.Write([]byte(responses[r.URL.Path]))
+			},
+			wantReturn: []models.ContainerStats{
+				{
+					ID: "abc123", Name: "web", Image: "nginx:1.27", State: "running",
+					Labels:     map[string]string{"tier": "frontend"},
+					CpuPercent: 80, MemoryUsageB: 4000, MemoryLimitB: 10000,
+					NetRxB: 101, NetTxB: 202, BlockReadB: 4096, BlockWriteB: 8192,
+				},
+				{
+					ID: "def456", Name: "db", Image: "postgres:17", State: "running",
+					Labels:       map[string]string{},
+					MemoryUsageB: 2500, MemoryLimitB: 8000, BlockReadB: 10, BlockWriteB: 20,
+				},
+			},
+		},
+		{
+			name: "runtime error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"message": "client version too old"}`, http.StatusBadRequest)
+			},
+			wantErrContains: "/containers/json returned 400 Bad Request",
+		},
+		{
+			name: "failed container stats",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/containers/json" {
+					w.Write([]byte(containersListResponse))
+					return
+				}
+				http.Error(w, "server error", http.StatusInternalServerError)
+			},
+			wantErrContains: "container abc123",
+		},
+		{
+			name: "container removed after listing",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/containers/abc123/stats":
+					http.Error(w, `{"message": "No such container: abc123"}`, http.StatusNotFound)
+				default:
+					w.Write([]byte(responses[r.URL.Path]))
+				}
+			},
+			wantReturn: []models.ContainerStats{
+				{
+					ID: "def456", Name: "db", Image: "postgres:17", State: "running",
+					Labels:       map[string]string{},
+					MemoryUsageB: 2500, MemoryLimitB: 8000, BlockReadB: 10, BlockWriteB: 20,
+				},
+			},
+		},
+		{
+			name: "container stopped after listing",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/containers/def456/stats":
+					http.Error(w, `{"message": "container def456 is not running"}`, http.StatusConflict)
+				default:
+					w.Write([]byte(responses[r.URL.Path]))
+				}
+			},
+			wantReturn: []models.ContainerStats{
+				{
+					ID: "abc123", Name: "web", Image: "nginx:1.27", State: "running",
+					Labels:     map[string]string{"tier": "frontend"},
+					CpuPercent: 80, MemoryUsageB: 4000, MemoryLimitB: 10000,
+					NetRxB: 101, NetTxB: 202, BlockReadB: 4096, BlockWriteB: 8192,
+				},
+			},
+		},
+		{
+			name:      "no runtime listening",
+			noRuntime: true,
+			wantErr:   ErrNoContainerRuntime,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socket := filepath.Join(t.TempDir(), "missing.sock")
+			if !tt.noRuntime {
+				socket = serveStubRuntime(t, tt.handler)
+			}
+
+			var runtime containerRuntime
+			containers, err := runtime.containers(context.Background(), socket)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected error %v, got \"%v\"", tt.wantErr, err)
+				}
+				return
+			}
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, containers) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, containers)
+			}
+		})
+	}
+}
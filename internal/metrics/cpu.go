@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+	cpu "github.com/shirou/gopsutil/v4/cpu"
+)
+
+// cpuSnapshot holds the cumulative CPU times and event counters at one point in time.
+type cpuSnapshot struct {
+	at         time.Time
+	total      cpu.TimesStat
+	perCpu     []cpu.TimesStat
+	counters   procStatCounters
+	hasCounter bool
+}
+
+// procStatCounters are the cumulative event counters of /proc/stat.
+type procStatCounters struct {
+	contextSwitches uint64
+	interrupts      uint64
+}
+
+// readCpuStats samples the CPU times and the event counters twice, interval apart.
+func readCpuStats(ctx context.Context, interval time.Duration) (models.CpuStats, error) {
+	before, err := takeCpuSnapshot(ctx)
+	if err != nil {
+		return models.CpuStats{}, err
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return models.CpuStats{}, ctx.Err()
+	case <-timer.C:
+	}
+
+	after, err := takeCpuSnapshot(ctx)
+	if err != nil {
+		return models.CpuStats{}, err
+	}
+
+	stats := models.CpuStats{Total: cpuTimesPercent(before.total, after.total)}
+	if len(before.perCpu) == len(after.perCpu) {
+		stats.PerCpu = make([]models.CpuTimes, len(after.perCpu))
+		for i := range after.perCpu {
+			stats.PerCpu[i] = cpuTimesPercent(before.perCpu[i], after.perCpu[i])
+		}
+	}
+	if elapsed := after.at.Sub(before.at).Seconds(); before.hasCounter && after.hasCounter && elapsed > 0 {
+		stats.ContextSwitchesPerSec = counterRate(before.counters.contextSwitches, after.counters.contextSwitches, elapsed)
+		stats.InterruptsPerSec = counterRate(before.counters.interrupts, after.counters.interrupts, elapsed)
+	}
+	return stats, nil
+}
+
+func takeCpuSnapshot(ctx context.Context) (cpuSnapshot, error) {
+	snapshot := cpuSnapshot{at: time.Now()}
+	total, err := cpu.TimesWithContext(ctx, false)
+	if err != nil {
+		return cpuSnapshot{}, platformError(err)
+	}
+	if len(total) != 1 {
+		return cpuSnapshot{}, ErrNoCpus
+	}
+	snapshot.total = total[0]
+	if snapshot.perCpu, err = cpu.TimesWithContext(ctx, true); err != nil {
+		return cpuSnapshot{}, platformError(err)
+	}
+
+	// The event counters are only available on Linux, their rates are reported as 0 elsewhere.
+	path := filepath.Join(rootsFromContext(ctx).Proc, "stat")
+	snapshot.counters, err = readProcStatCounters(path)
+	if err == nil {
+		snapshot.hasCounter = true
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return cpuSnapshot{}, err
+	}
+	return snapshot, nil
+}
+
+func readProcStatCounters(path string) (procStatCounters, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return procStatCounters{}, err
+	}
+	defer file.Close()
+
+	var counters procStatCounters
+	scanner := bufio.NewScanner(file)
+	// The intr line lists every interrupt and can exceed the default buffer size.
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		var target *uint64
+		switch fields[0] {
+		case "ctxt":
+			target = &counters.contextSwitches
+		case "intr":
+			target = &counters.interrupts
+		default:
+			continue
+		}
+		if *target, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+			return procStatCounters{}, fmt.Errorf("failed to parse %s of %s: %v", fields[0], path, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return procStatCounters{}, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return counters, nil
+}
+
+// cpuTimesPercent returns the share of each state in the time elapsed between two cumulative samples.
+func cpuTimesPercent(before, after cpu.TimesStat) models.CpuTimes {
+	// Guest time is already accounted in user and nice time on Linux, so it is left out of the total.
+	total := func(t cpu.TimesStat) float64 {
+		return t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+	}
+	elapsed := total(after) - total(before)
+	if elapsed <= 0 {
+		return models.CpuTimes{}
+	}
+	percent := func(before, after float64) float64 {
+		delta := after - before
+		if delta < 0 {
+			return 0
+		}
+		return delta / elapsed * 100
+	}
+	return models.CpuTimes{
+		User:      percent(before.User, after.User),
+		System:    percent(before.System, after.System),
+		Idle:      percent(before.Idle, after.Idle),
+		Nice:      percent(before.Nice, after.Nice),
+		Iowait:    percent(before.Iowait, after.Iowait),
+		Irq:       percent(before.Irq, after.Irq),
+		Softirq:   percent(before.Softirq, after.Softirq),
+		Steal:     percent(before.Steal, after.Steal),
+		Guest:     percent(before.Guest, after.Guest),
+		GuestNice: percent(before.GuestNice, after.GuestNice),
+	}
+}
+
+func counterRate(before, after uint64, seconds float64) float64 {
+	if after < before {
+		return 0
+	}
+	return float64(after-before) / seconds
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+	cpu "github.com/shirou/gopsutil/v4/cpu"
+)
+
+func Test_cpuTimesPercent(t *testing.T) {
+	tests := []struct {
+		name       string
+		before     cpu.TimesStat
+		after      cpu.TimesStat
+		wantReturn models.CpuTimes
+	}{
+		{
+			name:   "shares of the elapsed time, guest included in user",
+			before: cpu.TimesStat{User: 100, System: 50, Idle: 1000, Iowait: 10, Steal: 5, Guest: 20},
+			after:  cpu.TimesStat{User: 130, System: 60, Idle: 1050, Iowait: 15, Steal: 10, Guest: 30},
+			wantReturn: models.CpuTimes{
+				User:   30,
+				System: 10,
+				Idle:   50,
+				Iowait: 5,
+				Steal:  5,
+				Guest:  10,
+			},
+		},
+		{
+			name:   "no time elapsed",
+			before: cpu.TimesStat{User: 100, Idle: 1000},
+			after:  cpu.TimesStat{User: 100, Idle: 1000},
+		},
+		{
+			name:       "counter going backwards is reported as 0",
+			before:     cpu.TimesStat{User: 100, Iowait: 10, Idle: 1000},
+			after:      cpu.TimesStat{User: 164, Iowait: 0, Idle: 1074},
+			wantReturn: models.CpuTimes{User: 50, Idle: 57.8125},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cpuTimesPercent(tt.before, tt.after); !reflect.DeepEqual(got, tt.wantReturn) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
+
+func Test_readProcStatCounters(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		wantReturn      procStatCounters
+		wantErrContains string
+	}{
+		{
+			name:       "reads context switches and interrupts",
+			content:    "cpu  100 0 50 1000 10 0 0 5 20 0\ncpu0 100 0 50 1000 10 0 0 5 20 0\nintr 123456 0 9 0 0\nctxt 987654\nbtime 1700000000\n",
+			wantReturn: procStatCounters{contextSwitches: 987654, interrupts: 123456},
+		},
+		{
+			name:            "invalid counter",
+			content:         "ctxt many\n",
+			wantErrContains: "failed to parse ctxt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "stat")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readProcStatCounters(path)
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.wantReturn {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
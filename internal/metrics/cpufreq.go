@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+// ErrNoCpuFreq is returned when no CPU exposes cpufreq information, e.g. in most VMs.
+var ErrNoCpuFreq = errors.New("no cpufreq information found")
+
+var cpuDirRegexp = regexp.MustCompile(`^cpu(\d+)$`)
+
+// readCpuFrequencies reads the cpufreq and thermal throttling state of every logical CPU under <sys>/devices/system/cpu.
+func readCpuFrequencies(ctx context.Context) ([]models.CpuFrequency, error) {
+	cpuDir := filepath.Join(rootsFromContext(ctx).Sys, "devices", "system", "cpu")
+	entries, err := os.ReadDir(cpuDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoCpuFreq
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", cpuDir, err)
+	}
+
+	type cpu struct {
+		name  string
+		index int
+	}
+	var cpus []cpu
+	for _, entry := range entries {
+		if match := cpuDirRegexp.FindStringSubmatch(entry.Name()); match != nil {
+			index, _ := strconv.Atoi(match[1])
+			cpus = append(cpus, cpu{name: entry.Name(), index: index})
+		}
+	}
+	sort.Slice(cpus, func(i, j int) bool { return cpus[i].index < cpus[j].index })
+
+	var frequencies []models.CpuFrequency
+	for _, c := range cpus {
+		freqDir := filepath.Join(cpuDir, c.name, "cpufreq")
+		if _, err := os.Stat(freqDir); err != nil {
+			// Offline CPUs and CPUs without a cpufreq driver have no cpufreq directory.
+			continue
+		}
+		frequency := models.CpuFrequency{Cpu: c.name}
+		for file, target := range map[string]*float64{
+			"scaling_min_freq": &frequency.MinMhz,
+			"scaling_max_freq": &frequency.MaxMhz,
+			"cpuinfo_min_freq": &frequency.HardwareMinMhz,
+			"cpuinfo_max_freq": &frequency.HardwareMaxMhz,
+		} {
+			if *target, err = readKhzAsMhz(filepath.Join(freqDir, file)); err != nil {
+				return nil, err
+			}
+		}
+		// scaling_cur_freq is the frequency the governor requested, cpuinfo_cur_freq the one read from the hardware.
+		for _, file := range []string{"cpuinfo_cur_freq", "scaling_cur_freq"} {
+			if frequency.CurrentMhz, err = readKhzAsMhz(filepath.Join(freqDir, file)); err != nil && !errors.Is(err, os.ErrPermission) {
+				return nil, err
+			}
+			if frequency.CurrentMhz > 0 {
+				break
+			}
+		}
+		if frequency.Governor, err = readOptionalFile(filepath.Join(freqDir, "scaling_governor")); err != nil {
+			return nil, err
+		}
+		if frequency.EnergyPerformancePreference, err = readOptionalFile(filepath.Join(freqDir, "energy_performance_preference")); err != nil {
+			return nil, err
+		}
+
+		throttleDir := filepath.Join(cpuDir, c.name, "thermal_throttle")
+		if frequency.CoreThrottleCount, err = readOptionalUint(filepath.Join(throttleDir, "core_throttle_count")); err != nil {
+			return nil, err
+		}
+		if frequency.PackageThrottleCount, err = readOptionalUint(filepath.Join(throttleDir, "package_throttle_count")); err != nil {
+			return nil, err
+		}
+		frequencies = append(frequencies, frequency)
+	}
+	if len(frequencies) == 0 {
+		return nil, ErrNoCpuFreq
+	}
+	return frequencies, nil
+}
+
+// readKhzAsMhz reads a cpufreq file in kHz. Missing files are reported as 0.
+func readKhzAsMhz(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		// cpuinfo_cur_freq is only readable by root.
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	khz, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return khz / 1000, nil
+}
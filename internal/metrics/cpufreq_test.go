@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+func Test_readCpuFrequencies(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           map[string]string
+		wantReturn      []models.CpuFrequency
+		wantErr         error
+		wantErrContains string
+	}{
+		{
+			name: "reads cpufreq and thermal throttling per cpu",
+			files: map[string]string{
+				"devices/system/cpu/cpu10/cpufreq/scaling_cur_freq":               "3400000\n",
+				"devices/system/cpu/cpu10/cpufreq/scaling_governor":               "performance\n",
+				"devices/system/cpu/cpu0/cpufreq/scaling_cur_freq":                "800000\n",
+				"devices/system/cpu/cpu0/cpufreq/cpuinfo_cur_freq":                "799500\n",
+				"devices/system/cpu/cpu0/cpufreq/scaling_min_freq":                "800000\n",
+				"devices/system/cpu/cpu0/cpufreq/scaling_max_freq":                "3500000\n",
+				"devices/system/cpu/cpu0/cpufreq/cpuinfo_min_freq":                "400000\n",
+				"devices/system/cpu/cpu0/cpufreq/cpuinfo_max_freq":                "4200000\n",
+				"devices/system/cpu/cpu0/cpufreq/scaling_governor":                "powersave\n",
+				"devices/system/cpu/cpu0/cpufreq/energy_performance_preference":   "balance_power\n",
+				"devices/system/cpu/cpu0/thermal_throttle/core_throttle_count":    "3\n",
+				"devices/system/cpu/cpu0/thermal_throttle/package_throttle_count": "7\n",
+				"devices/system/cpu/cpu1/online":                                  "0\n",
+				"devices/system/cpu/cpufreq/boost":                                "1\n",
+			},
+			wantReturn: []models.CpuFrequency{
+				{
+					Cpu:                         "cpu0",
+					CurrentMhz:                  799.5,
+					MinMhz:                      800,
+					MaxMhz:                      3500,
+					HardwareMinMhz:              400,
+					HardwareMaxMhz:              4200,
+					Governor:                    "powersave",
+					EnergyPerformancePreference: "balance_power",
+					CoreThrottleCount:           3,
+					PackageThrottleCount:        7,
+				},
+				{Cpu: "cpu10", CurrentMhz: 3400, Governor: "performance"},
+			},
+		},
+		{
+			name:    "no cpufreq driver",
+			files:   map[string]string{"devices/system/cpu/cpu0/online": "1\n"},
+			wantErr: ErrNoCpuFreq,
+		},
+		{
+			name: "invalid frequency",
+			files: map[string]string{
+				"devices/system/cpu/cpu0/cpufreq/scaling_max_freq": "<unknown>\n",
+			},
+			wantErrContains: "failed to parse",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			got, err := readCpuFrequencies(WithRoots(context.Background(), Roots{Sys: root}))
+			if tt.wantErr != nil || tt.wantErrContains != "" {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected error %v, got %v", tt.wantErr, err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error to contain %q, got %v", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantReturn) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrNoCpus               = errors.New("no CPUs found")
+	ErrNoTemperatureSensors = errors.New("no temperature sensors found")
+	ErrNoDiskPartitions     = errors.New("no disk partitions found")
+	// ErrNotSupported is returned when a metric is not available on the current platform.
+	ErrNotSupported = errors.New("not supported on this platform")
+)
+
+// gopsutil reports unsupported platforms with an error of its internal package, so it can only be matched by message.
+const gopsutilNotImplemented = "not implemented yet"
+
+// platformError wraps gopsutil errors caused by an unsupported platform with ErrNotSupported.
+func platformError(err error) error {
+	if err != nil && strings.Contains(err.Error(), gopsutilNotImplemented) {
+		return fmt.Errorf("%w: %v", ErrNotSupported, err)
+	}
+	return err
+}
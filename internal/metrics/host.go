@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+	"github.com/shirou/gopsutil/v4/host"
+)
+
+// readUserSessions returns the logged-in user sessions, none when the host keeps no utmp file,
+// e.g. inside a container, or on platforms where they cannot be read, e.g. Windows.
+func readUserSessions(ctx context.Context) ([]models.UserSession, error) {
+	users, err := host.UsersWithContext(ctx)
+	err = platformError(err)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, ErrNotSupported) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]models.UserSession, len(users))
+	for i, user := range users {
+		sessions[i] = models.UserSession{
+			User:     user.User,
+			Terminal: user.Terminal,
+			Host:     user.Host,
+			Started:  uint64(user.Started),
+		}
+	}
+	return sessions, nil
+}
+
+// readTimezone returns the zone name of the <etc>/localtime symlink, or the content of <etc>/timezone.
+// When neither names the zone of the local host, the abbreviation of the agent's zone is returned.
+func readTimezone(ctx context.Context) (string, error) {
+	roots := rootsFromContext(ctx)
+	target, err := os.Readlink(filepath.Join(roots.Etc, "localtime"))
+	if err == nil {
+		if _, name, ok := strings.Cut(filepath.ToSlash(target), "zoneinfo/"); ok && name != "" {
+			return name, nil
+		}
+	}
+	name, err := readOptionalFile(filepath.Join(roots.Etc, "timezone"))
+	if err != nil {
+		return "", err
+	}
+	if name != "" {
+		return name, nil
+	}
+	if roots.Etc != "/etc" {
+		// The zone of the agent's container says nothing about the one of the host.
+		return "", nil
+	}
+	abbreviation, _ := time.Now().Zone()
+	return abbreviation, nil
+}
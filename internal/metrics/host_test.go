@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_readTimezone(t *testing.T) {
+	tests := []struct {
+		name       string
+		symlink    string
+		files      map[string]string
+		wantReturn string
+	}{
+		{
+			name:       "localtime symlink",
+			symlink:    "/usr/share/zoneinfo/Europe/Warsaw",
+			files:      map[string]string{"timezone": "Etc/UTC\n"},
+			wantReturn: "Europe/Warsaw",
+		},
+		{
+			name:       "relative localtime symlink",
+			symlink:    "../usr/share/zoneinfo/America/New_York",
+			wantReturn: "America/New_York",
+		},
+		{
+			name:       "localtime copy falls back to timezone file",
+			files:      map[string]string{"localtime": "TZif2", "timezone": "Asia/Tokyo\n"},
+			wantReturn: "Asia/Tokyo",
+		},
+		{
+			name:       "unknown zone of the host",
+			files:      map[string]string{"localtime": "TZif2"},
+			wantReturn: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			if tt.symlink != "" {
+				if err := os.Symlink(tt.symlink, filepath.Join(root, "localtime")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := readTimezone(WithRoots(context.Background(), Roots{Etc: root}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.wantReturn {
+				t.Errorf("expected %q, got %q", tt.wantReturn, got)
+			}
+		})
+	}
+}
+
+func Test_readUserSessions(t *testing.T) {
+	root := t.TempDir()
+	ctx := WithRoots(context.Background(), Roots{Var: root})
+
+	sessions, err := readUserSessions(ctx)
+	if err != nil || sessions != nil {
+		t.Errorf("expected no sessions without utmp, got %v, %v", sessions, err)
+	}
+
+	// A directory in place of utmp fails to read, showing that the file is looked up under the var root.
+	writeTree(t, root, map[string]string{"run/utmp/unreadable": ""})
+	if _, err := readUserSessions(ctx); err == nil || !strings.Contains(err.Error(), filepath.Join(root, "run", "utmp")) {
+		t.Errorf("expected an error reading %s, got %v", filepath.Join(root, "run", "utmp"), err)
+	}
+}
@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+// Hwmon sensor types.
+const (
+	HwmonFan     = "fan"
+	HwmonVoltage = "voltage"
+	HwmonPower   = "power"
+	HwmonCurrent = "current"
+)
+
+// ErrNoHwmonSensors is returned when no fan, voltage, power or current sensor is found.
+var ErrNoHwmonSensors = errors.New("no hardware monitoring sensors found")
+
+type hwmonKind struct {
+	sensorType string
+	unit       string
+	// scale converts the sysfs value to the unit, e.g. millivolts to volts.
+	scale float64
+}
+
+// hwmonKinds maps the sysfs file prefixes to the sensor types.
+var hwmonKinds = map[string]hwmonKind{
+	"fan":   {sensorType: HwmonFan, unit: "rpm", scale: 1},
+	"in":    {sensorType: HwmonVoltage, unit: "V", scale: 1e-3},
+	"power": {sensorType: HwmonPower, unit: "W", scale: 1e-6},
+	"curr":  {sensorType: HwmonCurrent, unit: "A", scale: 1e-3},
+}
+
+var hwmonTypeOrder = []string{"fan", "in", "power", "curr"}
+
+var hwmonInputRegexp = regexp.MustCompile(`^(fan|in|power|curr)(\d+)_input$`)
+
+// readHwmonSensors reads the sensors of every chip under <sys>/class/hwmon.
+func readHwmonSensors(ctx context.Context) ([]models.HwmonSensor, error) {
+	hwmonDir := filepath.Join(rootsFromContext(ctx).Sys, "class", "hwmon")
+	chips, err := os.ReadDir(hwmonDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoHwmonSensors
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", hwmonDir, err)
+	}
+	sort.Slice(chips, func(i, j int) bool { return hwmonIndex(chips[i].Name()) < hwmonIndex(chips[j].Name()) })
+
+	var sensors []models.HwmonSensor
+	for _, chip := range chips {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chipSensors, err := readHwmonChip(filepath.Join(hwmonDir, chip.Name()), chip.Name())
+		if err != nil {
+			return nil, err
+		}
+		sensors = append(sensors, chipSensors...)
+	}
+	if len(sensors) == 0 {
+		return nil, ErrNoHwmonSensors
+	}
+	return sensors, nil
+}
+
+func readHwmonChip(dir string, fallbackName string) ([]models.HwmonSensor, error) {
+	// Older drivers keep the attributes in the device directory.
+	if _, err := os.Stat(filepath.Join(dir, "name")); err != nil {
+		if _, err := os.Stat(filepath.Join(dir, "device", "name")); err == nil {
+			dir = filepath.Join(dir, "device")
+		}
+	}
+	chip, err := readOptionalFile(filepath.Join(dir, "name"))
+	if err != nil {
+		return nil, err
+	}
+	if chip == "" {
+		chip = fallbackName
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+	type input struct {
+		prefix string
+		index  int
+	}
+	var inputs []input
+	for _, entry := range entries {
+		match := hwmonInputRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		index, _ := strconv.Atoi(match[2])
+		inputs = append(inputs, input{prefix: match[1], index: index})
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		if inputs[i].prefix != inputs[j].prefix {
+			return slices.Index(hwmonTypeOrder, inputs[i].prefix) < slices.Index(hwmonTypeOrder, inputs[j].prefix)
+		}
+		return inputs[i].index < inputs[j].index
+	})
+
+	sensors := make([]models.HwmonSensor, 0, len(inputs))
+	for _, in := range inputs {
+		kind := hwmonKinds[in.prefix]
+		name := in.prefix + strconv.Itoa(in.index)
+		// Some drivers expose attributes of disconnected channels that fail to read, e.g. with EIO or ENODATA,
+		// they are treated as missing.
+		attribute := func(suffix string) (float64, bool, error) {
+			path := filepath.Join(dir, name+"_"+suffix)
+			content, err := readOptionalFile(path)
+			if err != nil || content == "" {
+				return 0, false, nil
+			}
+			value, err := strconv.ParseFloat(content, 64)
+			if err != nil {
+				return 0, false, fmt.Errorf("failed to parse %s: %v", path, err)
+			}
+			return value * kind.scale, true, nil
+		}
+
+		value, ok, err := attribute("input")
+		if err != nil || !ok {
+			continue
+		}
+		label, err := readOptionalFile(filepath.Join(dir, name+"_label"))
+		if err != nil {
+			return nil, err
+		}
+		if label == "" {
+			label = name
+		}
+		sensor := models.HwmonSensor{
+			Chip:   chip,
+			Sensor: name,
+			Label:  label,
+			Type:   kind.sensorType,
+			Unit:   kind.unit,
+			Value:  value,
+		}
+		limits := []struct {
+			suffix string
+			target **float64
+		}{{"min", &sensor.Min}, {"max", &sensor.Max}, {"crit", &sensor.Crit}}
+		for _, limit := range limits {
+			value, ok, err := attribute(limit.suffix)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				*limit.target = &value
+			}
+		}
+		// An alarm that fails to read is treated as not raised, like the attributes above.
+		alarm, _ := readOptionalFile(filepath.Join(dir, name+"_alarm"))
+		sensor.Alarm = alarm == "1"
+		sensors = append(sensors, sensor)
+	}
+	return sensors, nil
+}
+
+// hwmonIndex returns the number of a hwmon<N> directory, so that hwmon10 sorts after hwmon2.
+func hwmonIndex(name string) int {
+	index, err := strconv.Atoi(strings.TrimPrefix(name, "hwmon"))
+	if err != nil {
+		return -1
+	}
+	return index
+}
+
+// hwmonChipNames returns the names of the hardware monitoring chips, or nil when they cannot be read.
+func hwmonChipNames(ctx context.Context) []string {
+	hwmonDir := filepath.Join(rootsFromContext(ctx).Sys, "class", "hwmon")
+	chips, err := os.ReadDir(hwmonDir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, chip := range chips {
+		for _, path := range []string{filepath.Join(hwmonDir, chip.Name(), "name"), filepath.Join(hwmonDir, chip.Name(), "device", "name")} {
+			if name, err := readOptionalFile(path); err == nil && name != "" {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// temperatureChip returns the chip of a gopsutil sensor key, which joins the chip name and the
+// sensor label with an underscore, e.g. coretemp_core_0. Chip names may contain underscores
+// themselves, so the key is matched against the known chips first.
+func temperatureChip(key string, chips []string) string {
+	chip := ""
+	for _, name := range chips {
+		if (key == name || strings.HasPrefix(key, name+"_")) && len(name) > len(chip) {
+			chip = name
+		}
+	}
+	if chip != "" {
+		return chip
+	}
+	// Thermal zones are reported by their type only, e.g. x86_pkg_temp.
+	return key
+}
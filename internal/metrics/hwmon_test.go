@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+func Test_readHwmonSensors(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           map[string]string
+		wantReturn      []models.HwmonSensor
+		wantErr         error
+		wantErrContains string
+	}{
+		{
+			name: "reads fans, voltages, power and current with limits in sensor units",
+			files: map[string]string{
+				"class/hwmon/hwmon10/name":             "ina3221\n",
+				"class/hwmon/hwmon10/curr1_input":      "1500\n",
+				"class/hwmon/hwmon10/curr1_crit":       "3000\n",
+				"class/hwmon/hwmon10/power1_input":     "12500000\n",
+				"class/hwmon/hwmon2/name":              "nct6775\n",
+				"class/hwmon/hwmon2/fan2_input":        "0\n",
+				"class/hwmon/hwmon2/fan2_min":          "300\n",
+				"class/hwmon/hwmon2/fan2_alarm":        "1\n",
+				"class/hwmon/hwmon2/fan10_input":       "900\n",
+				"class/hwmon/hwmon2/fan1_input":        "1200\n",
+				"class/hwmon/hwmon2/fan1_label":        "CPU Fan\n",
+				"class/hwmon/hwmon2/fan1_min":          "0\n",
+				"class/hwmon/hwmon2/in0_input":         "1200\n",
+				"class/hwmon/hwmon2/in0_min":           "800\n",
+				"class/hwmon/hwmon2/in0_max":           "1500\n",
+				"class/hwmon/hwmon2/in0_label":         "Vcore\n",
+				"class/hwmon/hwmon2/temp1_input":       "45000\n",
+				"class/hwmon/hwmon2/fan1_pulses":       "2\n",
+				"class/hwmon/hwmon3/device/name":       "it87\n",
+				"class/hwmon/hwmon3/device/fan1_input": "2000\n",
+			},
+			wantReturn: []models.HwmonSensor{
+				{Chip: "nct6775", Sensor: "fan1", Label: "CPU Fan", Type: HwmonFan, Unit: "rpm", Value: 1200, Min: floatPtr(0)},
+				{Chip: "nct6775", Sensor: "fan2", Label: "fan2", Type: HwmonFan, Unit: "rpm", Value: 0, Min: floatPtr(300), Alarm: true},
+				{Chip: "nct6775", Sensor: "fan10", Label: "fan10", Type: HwmonFan, Unit: "rpm", Value: 900},
+				{Chip: "nct6775", Sensor: "in0", Label: "Vcore", Type: HwmonVoltage, Unit: "V", Value: 1.2, Min: floatPtr(0.8), Max: floatPtr(1.5)},
+				{Chip: "it87", Sensor: "fan1", Label: "fan1", Type: HwmonFan, Unit: "rpm", Value: 2000},
+				{Chip: "ina3221", Sensor: "power1", Label: "power1", Type: HwmonPower, Unit: "W", Value: 12.5},
+				{Chip: "ina3221", Sensor: "curr1", Label: "curr1", Type: HwmonCurrent, Unit: "A", Value: 1.5, Crit: floatPtr(3)},
+			},
+		},
+		{
+			name:    "no hwmon class",
+			files:   map[string]string{"class/thermal/thermal_zone0/temp": "45000\n"},
+			wantErr: ErrNoHwmonSensors,
+		},
+		{
+			name: "temperature sensors only",
+			files: map[string]string{
+				"class/hwmon/hwmon0/name":        "coretemp\n",
+				"class/hwmon/hwmon0/temp1_input": "45000\n",
+			},
+			wantErr: ErrNoHwmonSensors,
+		},
+		{
+			// A directory in place of an attribute fails to read, as EIO does on disconnected channels.
+			name: "unreadable input and limits are skipped",
+			files: map[string]string{
+				"class/hwmon/hwmon0/name":                  "nct6775\n",
+				"class/hwmon/hwmon0/fan1_input":            "1200\n",
+				"class/hwmon/hwmon0/fan1_min/unreadable":   "",
+				"class/hwmon/hwmon0/fan1_max":              "5000\n",
+				"class/hwmon/hwmon0/fan1_alarm/unreadable": "",
+				"class/hwmon/hwmon0/fan2_input/unreadable": "",
+			},
+			wantReturn: []models.HwmonSensor{
+				{Chip: "nct6775", Sensor: "fan1", Label: "fan1", Type: HwmonFan, Unit: "rpm", Value: 1200, Max: floatPtr(5000)},
+			},
+		},
+		{
+			name: "invalid limit",
+			files: map[string]string{
+				"class/hwmon/hwmon0/name":       "nct6775\n",
+				"class/hwmon/hwmon0/fan1_input": "1200\n",
+				"class/hwmon/hwmon0/fan1_min":   "n/a\n",
+			},
+			wantErrContains: "failed to parse",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			ctx := WithRoots(context.Background(), Roots{Sys: root})
+
+			got, err := readHwmonSensors(ctx)
+			if tt.wantErr != nil || tt.wantErrContains != "" {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected error %v, got %v", tt.wantErr, err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error to contain %q, got %v", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantReturn) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func Test_temperatureChip(t *testing.T) {
+	chips := []string{"coretemp", "nvme", "iwlwifi", "iwlwifi_1"}
+	tests := []struct {
+		name       string
+		key        string
+		wantReturn string
+	}{
+		{name: "chip with label", key: "coretemp_package_id_0", wantReturn: "coretemp"},
+		{name: "chip without label", key: "nvme", wantReturn: "nvme"},
+		{name: "longest matching chip", key: "iwlwifi_1", wantReturn: "iwlwifi_1"},
+		{name: "thermal zone", key: "x86_pkg_temp", wantReturn: "x86_pkg_temp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := temperatureChip(tt.key, chips); got != tt.wantReturn {
+				t.Errorf("expected %q, got %q", tt.wantReturn, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+// readKernelLimits reads the kernel table usage from <proc>/sys and the file descriptors of every process
+// the agent may inspect, skipping the processes of other users when it does not run as root.
+func readKernelLimits(ctx context.Context) (models.KernelLimits, error) {
+	proc := rootsFromContext(ctx).Proc
+	fileNr, err := readProcFields(filepath.Join(proc, "sys", "fs", "file-nr"))
+	if err != nil {
+		return models.KernelLimits{}, err
+	}
+	if fileNr == nil {
+		return models.KernelLimits{}, fmt.Errorf("%w: /proc/sys/fs/file-nr is not available", ErrNotSupported)
+	}
+	if len(fileNr) != 3 {
+		return models.KernelLimits{}, fmt.Errorf("failed to parse %s: expected 3 fields, got %d", filepath.Join(proc, "sys", "fs", "file-nr"), len(fileNr))
+	}
+	// The second field, the allocated but unused handles, is always 0 since Linux 2.6.
+	limits := models.KernelLimits{OpenFiles: fileNr[0] - fileNr[1], MaxFiles: fileNr[2]}
+
+	inodeNr, err := readProcFields(filepath.Join(proc, "sys", "fs", "inode-nr"))
+	if err != nil {
+		return models.KernelLimits{}, err
+	}
+	if len(inodeNr) >= 2 {
+		limits.Inodes, limits.FreeInodes = inodeNr[0], inodeNr[1]
+	}
+
+	conntrackDir := filepath.Join(proc, "sys", "net", "netfilter")
+	if _, err := os.Stat(filepath.Join(conntrackDir, "nf_conntrack_count")); err == nil {
+		limits.HasConntrack = true
+		if limits.ConntrackEntries, err = readOptionalUint(filepath.Join(conntrackDir, "nf_conntrack_count")); err != nil {
+			return models.KernelLimits{}, err
+		}
+		if limits.ConntrackMax, err = readOptionalUint(filepath.Join(conntrackDir, "nf_conntrack_max")); err != nil {
+			return models.KernelLimits{}, err
+		}
+	}
+
+	if limits.Pids, err = readThreadCount(filepath.Join(proc, "loadavg")); err != nil {
+		return models.KernelLimits{}, err
+	}
+	for _, value := range []struct {
+		path   string
+		target *uint64
+	}{
+		{filepath.Join(proc, "sys", "kernel", "pid_max"), &limits.PidMax},
+		{filepath.Join(proc, "sys", "kernel", "threads-max"), &limits.ThreadsMax},
+		{filepath.Join(proc, "sys", "kernel", "random", "entropy_avail"), &limits.EntropyAvailable},
+		{filepath.Join(proc, "sys", "kernel", "random", "poolsize"), &limits.EntropyPoolSize},
+	} {
+		if *value.target, err = readOptionalUint(value.path); err != nil {
+			return models.KernelLimits{}, err
+		}
+	}
+
+	if limits.Processes, err = readProcessFds(ctx, proc); err != nil {
+		return models.KernelLimits{}, err
+	}
+	return limits, nil
+}
+
+// readProcFields reads a file of whitespace separated numbers, nil when it does not exist.
+func readProcFields(path string) ([]uint64, error) {
+	content, err := readOptionalFile(path)
+	if err != nil || content == "" {
+		return nil, err
+	}
+	fields := strings.Fields(content)
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		if values[i], err = strconv.ParseUint(field, 10, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+	}
+	return values, nil
+}
+
+// readThreadCount reads the number of scheduling entities, the total of the "running/total" field of loadavg.
+func readThreadCount(path string) (uint64, error) {
+	content, err := readOptionalFile(path)
+	if err != nil || content == "" {
+		return 0, err
+	}
+	fields := strings.Fields(content)
+	if len(fields) < 4 {
+		return 0, fmt.Errorf("failed to parse %s: expected at least 4 fields, got %d", path, len(fields))
+	}
+	_, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return 0, fmt.Errorf("failed to parse %s: invalid field %q", path, fields[3])
+	}
+	count, err := strconv.ParseUint(total, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return count, nil
+}
+
+// readProcessFds counts the open file descriptors of each process and reads its "Max open files" limit.
+// Processes that exit meanwhile or cannot be inspected are skipped.
+func readProcessFds(ctx context.Context, proc string) ([]models.ProcessFds, error) {
+	entries, err := os.ReadDir(proc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", proc, err)
+	}
+	var processes []models.ProcessFds
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pid, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(proc, entry.Name())
+		fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read file descriptors of process %d: %v", pid, err)
+		}
+		name, err := readOptionalFile(filepath.Join(dir, "comm"))
+		if err != nil {
+			continue
+		}
+		soft, hard, err := readOpenFilesLimit(filepath.Join(dir, "limits"))
+		if err != nil {
+			// Reading a process that exits meanwhile fails with ESRCH.
+			continue
+		}
+		processes = append(processes, models.ProcessFds{
+			Pid:       int32(pid),
+			Name:      name,
+			OpenFds:   uint64(len(fds)),
+			SoftLimit: soft,
+			HardLimit: hard,
+		})
+	}
+	return processes, nil
+}
+
+// readOpenFilesLimit parses the "Max open files" row of /proc/<pid>/limits, 0 for unlimited or a missing file.
+func readOpenFilesLimit(path string) (uint64, uint64, error) {
+	content, err := readOptionalFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, line := range strings.Split(content, "\n") {
+		rest, ok := strings.CutPrefix(line, "Max open files")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 2 {
+			return 0, 0, fmt.Errorf("failed to parse %s: invalid line %q", path, line)
+		}
+		var limits [2]uint64
+		for i, field := range fields[:2] {
+			if field == "unlimited" {
+				continue
+			}
+			if limits[i], err = strconv.ParseUint(field, 10, 64); err != nil {
+				return 0, 0, fmt.Errorf("failed to parse %s: %v", path, err)
+			}
+		}
+		return limits[0], limits[1], nil
+	}
+	return 0, 0, nil
+}
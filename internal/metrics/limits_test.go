@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+func Test_readKernelLimits(t *testing.T) {
+	limits := "Limit                     Soft Limit           Hard Limit           Units     \n" +
+		"Max cpu time              unlimited            unlimited            seconds   \n" +
+		"Max open files            1024                 524288               files     \n"
+	baseFiles := map[string]string{
+		"sys/fs/file-nr":                  "9344\t0\t9223372036854775807\n",
+		"sys/fs/inode-nr":                 "36847\t512\n",
+		"loadavg":                         "0.47 0.29 0.17 2/812 29768\n",
+		"sys/kernel/pid_max":              "4194304\n",
+		"sys/kernel/threads-max":          "254163\n",
+		"sys/kernel/random/entropy_avail": "256\n",
+		"sys/kernel/random/poolsize":      "256\n",
+		"1/comm":                          "systemd\n",
+		"1/limits":                        limits,
+		"1/fd/0":                          "",
+		"1/fd/1":                          "",
+		"1/fd/2":                          "",
+		"42/comm":                         "nginx\n",
+		"42/limits":                       "Max open files            unlimited            unlimited            files     \n",
+		"42/fd/0":                         "",
+		"self/comm":                       "metrigo\n",
+	}
+	merge := func(extra map[string]string) map[string]string {
+		merged := map[string]string{}
+		for k, v := range baseFiles {
+			merged[k] = v
+		}
+		for k, v := range extra {
+			merged[k] = v
+		}
+		return merged
+	}
+	base := models.KernelLimits{
+		OpenFiles: 9344, MaxFiles: 9223372036854775807,
+		Inodes: 36847, FreeInodes: 512,
+		Pids: 812, PidMax: 4194304, ThreadsMax: 254163,
+		EntropyAvailable: 256, EntropyPoolSize: 256,
+		Processes: []models.ProcessFds{
+			{Pid: 1, Name: "systemd", OpenFds: 3, SoftLimit: 1024, HardLimit: 524288},
+			{Pid: 42, Name: "nginx", OpenFds: 1},
+		},
+	}
+	withConntrack := base
+	withConntrack.HasConntrack, withConntrack.ConntrackEntries, withConntrack.ConntrackMax = true, 1200, 262144
+
+	tests := []struct {
+		name            string
+		files           map[string]string
+		wantReturn      models.KernelLimits
+		wantUnsupported bool
+		wantErrContains string
+	}{
+		{
+			name:       "without conntrack",
+			files:      baseFiles,
+			wantReturn: base,
+		},
+		{
+			name: "with conntrack",
+			files: merge(map[string]string{
+				"sys/net/netfilter/nf_conntrack_count": "1200\n",
+				"sys/net/netfilter/nf_conntrack_max":   "262144\n",
+			}),
+			wantReturn: withConntrack,
+		},
+		{
+			name:            "not linux",
+			files:           map[string]string{"stat": "cpu 0\n"},
+			wantUnsupported: true,
+			wantErrContains: "/proc/sys/fs/file-nr is not available",
+		},
+		{
+			name:            "invalid file-nr",
+			files:           merge(map[string]string{"sys/fs/file-nr": "9344 0\n"}),
+			wantErrContains: "expected 3 fields, got 2",
+		},
+		{
+			name:            "invalid loadavg",
+			files:           merge(map[string]string{"loadavg": "0.47 0.29 0.17 812 29768\n"}),
+			wantErrContains: "invalid field \"812\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			got, err := readKernelLimits(WithRoots(context.Background(), Roots{Proc: root}))
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Fatalf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				if tt.wantUnsupported && !errors.Is(err, ErrNotSupported) {
+					t.Errorf("expected ErrNotSupported, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, got) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+var (
+	hugePagesDirRegexp = regexp.MustCompile(`^hugepages-(\d+)kB$`)
+	nodeDirRegexp      = regexp.MustCompile(`^node(\d+)$`)
+	// thpSelectedRegexp matches the selected mode of the transparent hugepage settings, e.g. "always [madvise] never".
+	thpSelectedRegexp = regexp.MustCompile(`\[([^\]]+)\]`)
+)
+
+// readMemoryDetails reads <proc>/meminfo together with the hugepage pools, the transparent hugepage settings
+// and the NUMA nodes under <sys>. Machines without NUMA report no nodes.
+func readMemoryDetails(ctx context.Context) (models.MemoryDetails, error) {
+	roots := rootsFromContext(ctx)
+	path := filepath.Join(roots.Proc, "meminfo")
+	content, err := readOptionalFile(path)
+	if err != nil {
+		return models.MemoryDetails{}, err
+	}
+	if content == "" {
+		return models.MemoryDetails{}, fmt.Errorf("%w: /proc/meminfo is not available", ErrNotSupported)
+	}
+	values, err := parseMeminfo(content, "")
+	if err != nil {
+		return models.MemoryDetails{}, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	details := models.MemoryDetails{
+		TotalB:             values["MemTotal"],
+		FreeB:              values["MemFree"],
+		AvailableB:         values["MemAvailable"],
+		BuffersB:           values["Buffers"],
+		CachedB:            values["Cached"],
+		SwapTotalB:         values["SwapTotal"],
+		SwapFreeB:          values["SwapFree"],
+		DirtyB:             values["Dirty"],
+		WritebackB:         values["Writeback"],
+		SlabB:              values["Slab"],
+		SlabReclaimableB:   values["SReclaimable"],
+		SlabUnreclaimableB: values["SUnreclaim"],
+		CommittedAsB:       values["Committed_AS"],
+		CommitLimitB:       values["CommitLimit"],
+		AnonHugePagesB:     values["AnonHugePages"],
+	}
+
+	mmDir := filepath.Join(roots.Sys, "kernel", "mm")
+	if details.HugePages, err = readHugePagePools(filepath.Join(mmDir, "hugepages")); err != nil {
+		return models.MemoryDetails{}, err
+	}
+	thpDir := filepath.Join(mmDir, "transparent_hugepage")
+	for file, target := range map[string]*string{
+		"enabled": &details.TransparentHugePages.Enabled,
+		"defrag":  &details.TransparentHugePages.Defrag,
+	} {
+		setting, err := readOptionalFile(filepath.Join(thpDir, file))
+		if err != nil {
+			return models.MemoryDetails{}, err
+		}
+		*target = selectedMode(setting)
+	}
+
+	if details.NumaNodes, err = readNumaNodes(filepath.Join(roots.Sys, "devices", "system", "node")); err != nil {
+		return models.MemoryDetails{}, err
+	}
+	return details, nil
+}
+
+// parseMeminfo parses the "Key: value kB" lines of a meminfo file into bytes. Counts without a unit,
+// such as HugePages_Total, are kept as they are. The per node files prefix every line with prefix, e.g. "Node 0".
+func parseMeminfo(content string, prefix string) (map[string]uint64, error) {
+	values := map[string]uint64{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, prefix))
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		parsed, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %v", key, err)
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			parsed *= 1024
+		}
+		values[key] = parsed
+	}
+	return values, nil
+}
+
+// readHugePagePools reads the hugepage pools of each page size from the hugepages-<size>kB directories of dir.
+// The per node directories have no resv_hugepages, their Reserved is left at 0.
+func readHugePagePools(dir string) ([]models.HugePagePool, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+
+	var pools []models.HugePagePool
+	for _, entry := range entries {
+		match := hugePagesDirRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		sizeKb, _ := strconv.ParseUint(match[1], 10, 64)
+		pool := models.HugePagePool{SizeB: sizeKb * 1024}
+		for file, target := range map[string]*uint64{
+			"nr_hugepages":      &pool.Total,
+			"free_hugepages":    &pool.Free,
+			"resv_hugepages":    &pool.Reserved,
+			"surplus_hugepages": &pool.Surplus,
+		} {
+			if *target, err = readOptionalUint(filepath.Join(dir, entry.Name(), file)); err != nil {
+				return nil, err
+			}
+		}
+		pools = append(pools, pool)
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].SizeB < pools[j].SizeB })
+	return pools, nil
+}
+
+// readNumaNodes reads the memory and the hugepage pools of each node<N> directory of dir.
+func readNumaNodes(dir string) ([]models.NumaNode, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+
+	var nodes []models.NumaNode
+	for _, entry := range entries {
+		match := nodeDirRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		node := models.NumaNode{}
+		node.ID, _ = strconv.Atoi(match[1])
+
+		path := filepath.Join(dir, entry.Name(), "meminfo")
+		content, err := readOptionalFile(path)
+		if err != nil {
+			return nil, err
+		}
+		values, err := parseMeminfo(content, "Node "+match[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		node.TotalB, node.FreeB = values["MemTotal"], values["MemFree"]
+
+		if node.HugePages, err = readHugePagePools(filepath.Join(dir, entry.Name(), "hugepages")); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// selectedMode returns the bracketed mode of a transparent hugepage setting, or the setting itself
+// when none is bracketed.
+func selectedMode(setting string) string {
+	if match := thpSelectedRegexp.FindStringSubmatch(setting); match != nil {
+		return match[1]
+	}
+	return setting
+}
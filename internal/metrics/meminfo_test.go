@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+func Test_readMemoryDetails(t *testing.T) {
+	meminfo := "MemTotal:       16318460 kB\n" +
+		"MemFree:         1204312 kB\n" +
+		"MemAvailable:    9876540 kB\n" +
+		"Buffers:          412304 kB\n" +
+		"Cached:          7820096 kB\n" +
+		"SwapTotal:       2097148 kB\n" +
+		"SwapFree:        2097148 kB\n" +
+		"Dirty:               412 kB\n" +
+		"Writeback:             8 kB\n" +
+		"AnonHugePages:    206848 kB\n" +
+		"Slab:             812332 kB\n" +
+		"SReclaimable:     601220 kB\n" +
+		"SUnreclaim:       211112 kB\n" +
+		"CommitLimit:    10256376 kB\n" +
+		"Committed_AS:   14020044 kB\n" +
+		"HugePages_Total:      16\n"
+	baseFiles := map[string]string{
+		"proc/meminfo": meminfo,
+		"sys/kernel/mm/hugepages/hugepages-2048kB/nr_hugepages":      "16\n",
+		"sys/kernel/mm/hugepages/hugepages-2048kB/free_hugepages":    "10\n",
+		"sys/kernel/mm/hugepages/hugepages-2048kB/resv_hugepages":    "2\n",
+		"sys/kernel/mm/hugepages/hugepages-2048kB/surplus_hugepages": "0\n",
+		"sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages":   "0\n",
+		"sys/kernel/mm/transparent_hugepage/enabled":                 "always [madvise] never\n",
+		"sys/kernel/mm/transparent_hugepage/defrag":                  "always defer defer+madvise [madvise] never\n",
+	}
+	merge := func(extra map[string]string) map[string]string {
+		merged := map[string]string{}
+		for k, v := range baseFiles {
+			merged[k] = v
+		}
+		for k, v := range extra {
+			merged[k] = v
+		}
+		return merged
+	}
+	base := models.MemoryDetails{
+		TotalB: 16318460 * 1024, FreeB: 1204312 * 1024, AvailableB: 9876540 * 1024,
+		BuffersB: 412304 * 1024, CachedB: 7820096 * 1024,
+		SwapTotalB: 2097148 * 1024, SwapFreeB: 2097148 * 1024,
+		DirtyB: 412 * 1024, WritebackB: 8 * 1024,
+		SlabB: 812332 * 1024, SlabReclaimableB: 601220 * 1024, SlabUnreclaimableB: 211112 * 1024,
+		CommittedAsB: 14020044 * 1024, CommitLimitB: 10256376 * 1024,
+		AnonHugePagesB: 206848 * 1024,
+		HugePages: []models.HugePagePool{
+			{SizeB: 2 << 20, Total: 16, Free: 10, Reserved: 2},
+			{SizeB: 1 << 30},
+		},
+		TransparentHugePages: models.TransparentHugePages{Enabled: "madvise", Defrag: "madvise"},
+	}
+	withNuma := base
+	withNuma.NumaNodes = []models.NumaNode{
+		{ID: 0, TotalB: 8159230 * 1024, FreeB: 602156 * 1024, HugePages: []models.HugePagePool{{SizeB: 2 << 20, Total: 8, Free: 5}}},
+		{ID: 1, TotalB: 8159230 * 1024, FreeB: 602156 * 1024},
+	}
+
+	tests := []struct {
+		name            string
+		files           map[string]string
+		wantReturn      models.MemoryDetails
+		wantUnsupported bool
+		wantErrContains string
+	}{
+		{
+			name:       "without NUMA",
+			files:      baseFiles,
+			wantReturn: base,
+		},
+		{
+			name: "with NUMA",
+			files: merge(map[string]string{
+				"sys/devices/system/node/node1/meminfo":                                      "Node 1 MemTotal:        8159230 kB\nNode 1 MemFree:          602156 kB\n",
+				"sys/devices/system/node/node0/meminfo":                                      "Node 0 MemTotal:        8159230 kB\nNode 0 MemFree:          602156 kB\n",
+				"sys/devices/system/node/node0/hugepages/hugepages-2048kB/nr_hugepages":      "8\n",
+				"sys/devices/system/node/node0/hugepages/hugepages-2048kB/free_hugepages":    "5\n",
+				"sys/devices/system/node/node0/hugepages/hugepages-2048kB/surplus_hugepages": "0\n",
+				"sys/devices/system/node/possible":                                           "0-1\n",
+			}),
+			wantReturn: withNuma,
+		},
+		{
+			name:            "not linux",
+			files:           map[string]string{"proc/stat": "cpu 0\n"},
+			wantUnsupported: true,
+			wantErrContains: "/proc/meminfo is not available",
+		},
+		{
+			name:            "invalid meminfo",
+			files:           merge(map[string]string{"proc/meminfo": "MemTotal: lots kB\n"}),
+			wantErrContains: "invalid value of MemTotal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			ctx := WithRoots(context.Background(), Roots{Proc: filepath.Join(root, "proc"), Sys: filepath.Join(root, "sys")})
+			got, err := readMemoryDetails(ctx)
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Fatalf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				if tt.wantUnsupported && !errors.Is(err, ErrNotSupported) {
+					t.Errorf("expected ErrNotSupported, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, got) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// networkFilesystems are reported although /proc/filesystems marks them nodev, as they are the ones going stale.
+var networkFilesystems = []string{"nfs", "nfs4", "cifs", "smb3", "smbfs", "ceph", "glusterfs", "fuse.glusterfs", "fuse.sshfs", "9p"}
+
+// mountStatter reads the usage of mounted filesystems. A stat of an unreachable network filesystem blocks
+// in the kernel and cannot be cancelled, so it is left running in the background and the mount is not
+// stat'ed again until it completes. At most one goroutine per mount point can therefore pile up.
+type mountStatter struct {
+	// stat defaults to disk.UsageWithContext.
+	stat func(ctx context.Context, path string) (*disk.UsageStat, error)
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+}
+
+type statResult struct {
+	usage *disk.UsageStat
+	err   error
+}
+
+// readMounts reads the disk and network filesystems mounted on the host from <proc>/1/mountinfo,
+// and their usage, each stat waiting at most statTimeout.
+func (s *mountStatter) readMounts(ctx context.Context, statTimeout time.Duration) ([]models.MountStatus, error) {
+	roots := rootsFromContext(ctx)
+	content, err := readOptionalFile(filepath.Join(roots.Proc, "1", "mountinfo"))
+	if err != nil || content == "" {
+		// The init process is only readable by root with some hardening settings, the agent's own mounts are read then.
+		if content, err = readOptionalFile(filepath.Join(roots.Proc, "self", "mountinfo")); err != nil {
+			return nil, err
+		}
+	}
+	if content == "" {
+		return nil, fmt.Errorf("%w: /proc/self/mountinfo is not available", ErrNotSupported)
+	}
+	filesystems, err := readOptionalFile(filepath.Join(roots.Proc, "filesystems"))
+	if err != nil {
+		return nil, err
+	}
+	nodev := parseNodevFilesystems(filesystems)
+	fstab, err := readOptionalFile(filepath.Join(roots.Etc, "fstab"))
+	if err != nil {
+		return nil, err
+	}
+	writable := parseFstabWritable(fstab)
+
+	mounts, err := parseMountinfo(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mountinfo: %v", err)
+	}
+	mounts = slices.DeleteFunc(mounts, func(mount models.MountStatus) bool {
+		return nodev[mount.Fstype] && !slices.Contains(networkFilesystems, mount.Fstype)
+	})
+
+	// The mounts are stat'ed concurrently, so that the stale ones together delay the collection by statTimeout at most.
+	results := make([]<-chan statResult, len(mounts))
+	for i, mount := range mounts {
+		mounts[i].FstabWritable = writable[mount.Path]
+		results[i] = s.startStat(roots.hostPath(mount.Path))
+	}
+	timer := time.NewTimer(statTimeout)
+	defer timer.Stop()
+	timedOut := false
+	for i := range mounts {
+		var result statResult
+		if results[i] == nil {
+			mounts[i].Stale = true
+			continue
+		}
+		if !timedOut {
+			select {
+			case result = <-results[i]:
+			case <-timer.C:
+				timedOut = true
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if timedOut {
+			select {
+			case result = <-results[i]:
+			default:
+				mounts[i].Stale = true
+				continue
+			}
+		}
+		if staleError(result.err) {
+			mounts[i].Stale = true
+			continue
+		}
+		if result.err != nil {
+			// Mount points that are not accessible, e.g. missing permissions, are reported without their usage.
+			continue
+		}
+		usage := result.usage
+		mounts[i].TotalB, mounts[i].UsedB, mounts[i].FreeB = usage.Total, usage.Used, usage.Free
+		mounts[i].UsedPercent = usage.UsedPercent
+		mounts[i].Inodes, mounts[i].InodesFree = usage.InodesTotal, usage.InodesFree
+		mounts[i].InodesUsedPercent = usage.InodesUsedPercent
+	}
+	return mounts, nil
+}
+
+// startStat stats the path in the background and returns the channel receiving the result. It returns nil
+// when a stat of the path started earlier has not completed yet.
+func (s *mountStatter) startStat(path string) <-chan statResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil {
+		s.pending = map[string]struct{}{}
+	}
+	if _, ok := s.pending[path]; ok {
+		return nil
+	}
+	s.pending[path] = struct{}{}
+	result := make(chan statResult, 1)
+
+	stat := s.stat
+	if stat == nil {
+		stat = disk.UsageWithContext
+	}
+	go func() {
+		// The context is not cancelled with the collection: the stat would keep blocking anyway.
+		usage, err := stat(context.Background(), path)
+		s.mu.Lock()
+		delete(s.pending, path)
+		s.mu.Unlock()
+		result <- statResult{usage: usage, err: err}
+	}()
+	return result
+}
+
+// staleError reports whether the stat failed because the filesystem is unreachable, e.g. a stale NFS
+// file handle or a FUSE daemon that exited, rather than not accessible.
+func staleError(err error) bool {
+	return errors.Is(err, syscall.ESTALE) || errors.Is(err, syscall.EIO) || errors.Is(err, syscall.ENOTCONN)
+}
+
+// parseMountinfo parses the lines of a mountinfo file, e.g.
+// "36 35 98:0 / /mnt rw,noatime master:1 - ext4 /dev/sda1 rw,errors=remount-ro".
+// A mount point mounted over several times is reported once, with the visible, last mounted filesystem.
+func parseMountinfo(content string) ([]models.MountStatus, error) {
+	var mounts []models.MountStatus
+	index := map[string]int{}
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		separator := slices.Index(fields, "-")
+		if separator < 6 || len(fields) < separator+4 {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		mountOptions, superOptions := strings.Split(fields[5], ","), strings.Split(fields[separator+3], ",")
+		mount := models.MountStatus{
+			Path:     unescapeMountField(fields[4]),
+			Device:   unescapeMountField(fields[separator+2]),
+			Fstype:   fields[separator+1],
+			ReadOnly: slices.Contains(mountOptions, "ro") || slices.Contains(superOptions, "ro"),
+		}
+		// The read-only state is shared by both lists, either one being ro makes the mount read-only.
+		for _, option := range append(mountOptions, superOptions...) {
+			if option == "rw" || option == "ro" || slices.Contains(mount.Options, option) {
+				continue
+			}
+			mount.Options = append(mount.Options, option)
+		}
+		mode := "rw"
+		if mount.ReadOnly {
+			mode = "ro"
+		}
+		mount.Options = append([]string{mode}, mount.Options...)
+
+		if i, ok := index[mount.Path]; ok {
+			mounts[i] = mount
+			continue
+		}
+		index[mount.Path] = len(mounts)
+		mounts = append(mounts, mount)
+	}
+	return mounts, nil
+}
+
+// unescapeMountField decodes the octal escapes of the spaces, tabs, newlines and backslashes of mountinfo fields.
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var unescaped strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if value, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				unescaped.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		unescaped.WriteByte(field[i])
+	}
+	return unescaped.String()
+}
+
+// parseNodevFilesystems returns the filesystem types of /proc/filesystems that need no block device, e.g. proc and tmpfs.
+func parseNodevFilesystems(content string) map[string]bool {
+	nodev := map[string]bool{}
+	for _, line := range strings.Split(content, "\n") {
+		if fstype, ok := strings.CutPrefix(line, "nodev"); ok {
+			nodev[strings.TrimSpace(fstype)] = true
+		}
+	}
+	return nodev
+}
+
+// parseFstabWritable returns the mount points of the fstab that are mounted read-write.
+func parseFstabWritable(content string) map[string]bool {
+	writable := map[string]bool{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || strings.HasPrefix(fields[0], "#") || fields[2] == "swap" {
+			continue
+		}
+		if !slices.Contains(strings.Split(fields[3], ","), "ro") {
+			writable[unescapeMountField(fields[1])] = true
+		}
+	}
+	return writable
+}
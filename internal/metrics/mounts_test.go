@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+func Test_readMounts(t *testing.T) {
+	mountinfo := "22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw,errors=remount-ro\n" +
+		"23 22 0:21 / /proc rw,nosuid,nodev,noexec,relatime shared:12 - proc proc rw\n" +
+		"24 22 8:2 / /var/lib/my\\040data rw,noatime shared:2 - xfs /dev/sda2 ro,attr2,inode64\n" +
+		"25 22 0:45 / /mnt/share rw,relatime shared:3 - nfs4 nas:/export rw,vers=4.2,hard\n" +
+		"26 22 0:22 / /tmp rw,nosuid shared:4 - tmpfs tmpfs rw,size=1024k\n" +
+		"27 22 0:46 / /mnt/backup rw,relatime shared:5 - nfs4 nas:/backup rw,vers=4.2,hard\n"
+	baseFiles := map[string]string{
+		"proc/1/mountinfo": mountinfo,
+		"proc/filesystems": "nodev\tsysfs\nnodev\ttmpfs\nnodev\tproc\nnodev\tnfs4\n\text4\n\txfs\n",
+		"etc/fstab": "# <file system> <mount point> <type> <options> <dump> <pass>\n" +
+			"UUID=1234 / ext4 errors=remount-ro 0 1\n" +
+			"/dev/sda2 /var/lib/my\\040data xfs defaults,noatime 0 2\n" +
+			"/swapfile none swap sw 0 0\n",
+	}
+	stat := func(ctx context.Context, path string) (*disk.UsageStat, error) {
+		switch {
+		case strings.HasSuffix(path, "/mnt/share"):
+			// An unreachable NFS server.
+			time.Sleep(time.Second)
+		case strings.HasSuffix(path, "/mnt/backup"):
+			// The export was removed on the server.
+			return nil, &fs.PathError{Op: "statfs", Path: path, Err: syscall.ESTALE}
+		case strings.HasSuffix(path, "data"):
+			return nil, errors.New("permission denied")
+		}
+		return &disk.UsageStat{Total: 100, Used: 40, Free: 60, UsedPercent: 40, InodesTotal: 10, InodesFree: 1, InodesUsedPercent: 90}, nil
+	}
+	tests := []struct {
+		name            string
+		files           map[string]string
+		wantReturn      []models.MountStatus
+		wantUnsupported bool
+		wantErrContains string
+	}{
+		{
+			name:  "disk and network mounts",
+			files: baseFiles,
+			wantReturn: []models.MountStatus{
+				{Path: "/", Device: "/dev/sda1", Fstype: "ext4", Options: []string{"rw", "relatime", "errors=remount-ro"}, FstabWritable: true,
+					TotalB: 100, UsedB: 40, FreeB: 60, UsedPercent: 40, Inodes: 10, InodesFree: 1, InodesUsedPercent: 90},
+				{Path: "/var/lib/my data", Device: "/dev/sda2", Fstype: "xfs", Options: []string{"ro", "noatime", "attr2", "inode64"}, ReadOnly: true, FstabWritable: true},
+				{Path: "/mnt/share", Device: "nas:/export", Fstype: "nfs4", Options: []string{"rw", "relatime", "vers=4.2", "hard"}, Stale: true},
+				{Path: "/mnt/backup", Device: "nas:/backup", Fstype: "nfs4", Options: []string{"rw", "relatime", "vers=4.2", "hard"}, Stale: true},
+			},
+		},
+		{
+			name:            "not linux",
+			files:           map[string]string{"proc/stat": "cpu 0\n"},
+			wantUnsupported: true,
+			wantErrContains: "/proc/self/mountinfo is not available",
+		},
+		{
+			name:            "invalid mountinfo",
+			files:           map[string]string{"proc/self/mountinfo": "22 1 8:1 / / rw\n"},
+			wantErrContains: "failed to parse mountinfo: invalid line",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			ctx := WithRoots(context.Background(), Roots{Proc: filepath.Join(root, "proc"), Etc: filepath.Join(root, "etc")})
+			statter := &mountStatter{stat: stat}
+			got, err := statter.readMounts(ctx, 50*time.Millisecond)
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Fatalf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				if tt.wantUnsupported && !errors.Is(err, ErrNotSupported) {
+					t.Errorf("expected ErrNotSupported, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, got) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
+
+func Test_readMountsStaleNotStatAgain(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"self/mountinfo": "25 22 0:45 / /mnt/share rw,relatime shared:3 - nfs4 nas:/export rw,hard\n",
+	})
+	ctx := WithRoots(context.Background(), Roots{Proc: root})
+	release := make(chan struct{})
+	var stats atomic.Int32
+	statter := &mountStatter{stat: func(ctx context.Context, path string) (*disk.UsageStat, error) {
+		stats.Add(1)
+		<-release
+		return &disk.UsageStat{Total: 100}, nil
+	}}
+
+	for i := 0; i < 3; i++ {
+		mounts, err := statter.readMounts(ctx, 10*time.Millisecond)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(mounts) != 1 || !mounts[0].Stale {
+			t.Fatalf("expected a stale mount, got %+v", mounts)
+		}
+	}
+	if got := stats.Load(); got != 1 {
+		t.Errorf("expected the blocked stat to be started once, got %d", got)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for {
+		mounts, err := statter.readMounts(ctx, 100*time.Millisecond)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !mounts[0].Stale {
+			if mounts[0].TotalB != 100 {
+				t.Errorf("expected the usage once the mount recovered, got %+v", mounts[0])
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("mount still stale after the stat completed")
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+// pressureResources are the resources reported by PSI, in display order.
+var pressureResources = []string{"cpu", "memory", "io"}
+
+// readPressure reads the host-wide PSI from <proc>/pressure and the PSI of the cgroup at cgroupPath,
+// or of the agent's own cgroup when cgroupPath is empty. The cgroup is skipped when it has no PSI,
+// e.g. on cgroup v1 or for the root cgroup.
+func readPressure(ctx context.Context, cgroupPath string) ([]models.PressureStats, error) {
+	roots := rootsFromContext(ctx)
+	host, err := readPressureFiles(func(resource string) string {
+		return filepath.Join(roots.Proc, "pressure", resource)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(host) == 0 {
+		return nil, fmt.Errorf("%w: pressure stall information is not enabled in the kernel", ErrNotSupported)
+	}
+	pressure := []models.PressureStats{{Resources: host}}
+
+	cgroup := cgroupReader{root: filepath.Join(roots.Sys, "fs", "cgroup"), procRoot: roots.Proc}
+	if _, err := os.Stat(filepath.Join(cgroup.root, "cgroup.controllers")); err != nil {
+		return pressure, nil
+	}
+	if cgroupPath == "" {
+		paths, err := cgroup.ownCgroups()
+		if err != nil {
+			return nil, err
+		}
+		cgroupPath = paths[""]
+	}
+	if cgroupPath == "" || cgroupPath == "/" {
+		// The root cgroup has no pressure files, its pressure is the host-wide one.
+		return pressure, nil
+	}
+	resources, err := readPressureFiles(func(resource string) string {
+		return filepath.Join(cgroup.root, cgroupPath, resource+".pressure")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resources) > 0 {
+		pressure = append(pressure, models.PressureStats{Cgroup: cgroupPath, Resources: resources})
+	}
+	return pressure, nil
+}
+
+// readPressureFiles reads the PSI file of each resource, skipping the missing ones.
+func readPressureFiles(path func(resource string) string) ([]models.ResourcePressure, error) {
+	var resources []models.ResourcePressure
+	for _, resource := range pressureResources {
+		pressure, ok, err := readPressureFile(path(resource))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			pressure.Resource = resource
+			resources = append(resources, pressure)
+		}
+	}
+	return resources, nil
+}
+
+// readPressureFile reads the PSI file at path. It is reported as missing when PSI is disabled.
+func readPressureFile(path string) (models.ResourcePressure, bool, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return models.ResourcePressure{}, false, nil
+	}
+	if psiDisabled(err) {
+		return models.ResourcePressure{}, false, nil
+	}
+	if err != nil {
+		return models.ResourcePressure{}, false, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	defer file.Close()
+	return parsePressure(path, file)
+}
+
+// psiDisabled reports whether err comes from a PSI file of a kernel booted with psi=0. The files exist then,
+// but opening or reading them fails with EOPNOTSUPP, or EPERM on some kernels.
+func psiDisabled(err error) bool {
+	return errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EOPNOTSUPP)
+}
+
+// parsePressure parses lines such as "some avg10=0.12 avg60=0.05 avg300=0.01 total=123456".
+func parsePressure(path string, r io.Reader) (models.ResourcePressure, bool, error) {
+	var pressure models.ResourcePressure
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var stall *models.PressureStall
+		switch fields[0] {
+		case "some":
+			stall = &pressure.Some
+		case "full":
+			stall = &pressure.Full
+			pressure.HasFull = true
+		default:
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return models.ResourcePressure{}, false, fmt.Errorf("failed to parse %s: invalid field %q", path, field)
+			}
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return models.ResourcePressure{}, false, fmt.Errorf("failed to parse %s: %v", path, err)
+			}
+			switch key {
+			case "avg10":
+				stall.Avg10 = number
+			case "avg60":
+				stall.Avg60 = number
+			case "avg300":
+				stall.Avg300 = number
+			case "total":
+				stall.TotalSeconds = number / 1e6
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if psiDisabled(err) {
+			return models.ResourcePressure{}, false, nil
+		}
+		return models.ResourcePressure{}, false, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return pressure, true, nil
+}
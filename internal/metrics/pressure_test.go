@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/iotest"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+func Test_readPressure(t *testing.T) {
+	hostFiles := map[string]string{
+		"proc/pressure/cpu":    "some avg10=1.50 avg60=0.75 avg300=0.10 total=2500000\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+		"proc/pressure/memory": "some avg10=3.00 avg60=2.00 avg300=1.00 total=1000000\nfull avg10=2.00 avg60=1.00 avg300=0.50 total=500000\n",
+		"proc/pressure/io":     "some avg10=0.00 avg60=0.00 avg300=0.00 total=0\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+	}
+	hostPressure := models.PressureStats{Resources: []models.ResourcePressure{
+		{Resource: "cpu", Some: models.PressureStall{Avg10: 1.5, Avg60: 0.75, Avg300: 0.1, TotalSeconds: 2.5}, HasFull: true},
+		{Resource: "memory", Some: models.PressureStall{Avg10: 3, Avg60: 2, Avg300: 1, TotalSeconds: 1}, Full: models.PressureStall{Avg10: 2, Avg60: 1, Avg300: 0.5, TotalSeconds: 0.5}, HasFull: true},
+		{Resource: "io", HasFull: true},
+	}}
+	cgroupFiles := map[string]string{
+		"sys/fs/cgroup/cgroup.controllers":               "cpu memory io\n",
+		"sys/fs/cgroup/system.slice/app/cpu.pressure":    "some avg10=40.00 avg60=20.00 avg300=5.00 total=8000000\n",
+		"sys/fs/cgroup/system.slice/app/memory.pressure": "some avg10=0.00 avg60=0.00 avg300=0.00 total=0\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+		"proc/self/cgroup":                               "0::/system.slice/app\n",
+	}
+	merge := func(maps ...map[string]string) map[string]string {
+		merged := map[string]string{}
+		for _, m := range maps {
+			for k, v := range m {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+
+	tests := []struct {
+		name            string
+		files           map[string]string
+		cgroupPath      string
+		wantReturn      []models.PressureStats
+		wantUnsupported bool
+		wantErrContains string
+	}{
+		{
+			name:       "host only without cgroup v2",
+			files:      hostFiles,
+			wantReturn: []models.PressureStats{hostPressure},
+		},
+		{
+			name:  "host and own cgroup",
+			files: merge(hostFiles, cgroupFiles),
+			wantReturn: []models.PressureStats{hostPressure, {Cgroup: "/system.slice/app", Resources: []models.ResourcePressure{
+				{Resource: "cpu", Some: models.PressureStall{Avg10: 40, Avg60: 20, Avg300: 5, TotalSeconds: 8}},
+				{Resource: "memory", HasFull: true},
+			}}},
+		},
+		{
+			name:       "configured cgroup without pressure files",
+			files:      merge(hostFiles, cgroupFiles),
+			cgroupPath: "/system.slice/other",
+			wantReturn: []models.PressureStats{hostPressure},
+		},
+		{
+			name:       "root cgroup",
+			files:      merge(hostFiles, cgroupFiles, map[string]string{"proc/self/cgroup": "0::/\n"}),
+			wantReturn: []models.PressureStats{hostPressure},
+		},
+		{
+			name:            "psi not enabled",
+			files:           map[string]string{"proc/stat": "cpu 0 0 0 0\n"},
+			wantUnsupported: true,
+			wantErrContains: "pressure stall information is not enabled",
+		},
+		{
+			name:            "invalid value",
+			files:           map[string]string{"proc/pressure/cpu": "some avg10=abc avg60=0.00 avg300=0.00 total=0\n"},
+			wantErrContains: "failed to parse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			ctx := WithRoots(context.Background(), Roots{Sys: filepath.Join(root, "sys"), Proc: filepath.Join(root, "proc")})
+			got, err := readPressure(ctx, tt.cgroupPath)
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Fatalf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				if tt.wantUnsupported && !errors.Is(err, ErrNotSupported) {
+					t.Errorf("expected ErrNotSupported, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, got) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
+
+func Test_parsePressure(t *testing.T) {
+	tests := []struct {
+		name            string
+		reader          io.Reader
+		wantReturn      models.ResourcePressure
+		wantOk          bool
+		wantErrContains string
+	}{
+		{
+			name:       "some and full",
+			reader:     strings.NewReader("some avg10=1.50 avg60=0.75 avg300=0.10 total=2500000\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"),
+			wantReturn: models.ResourcePressure{Some: models.PressureStall{Avg10: 1.5, Avg60: 0.75, Avg300: 0.1, TotalSeconds: 2.5}, HasFull: true},
+			wantOk:     true,
+		},
+		{
+			// Kernels booted with psi=0 open the file and fail the read.
+			name:   "read not supported",
+			reader: iotest.ErrReader(&fs.PathError{Op: "read", Path: "/proc/pressure/cpu", Err: syscall.EOPNOTSUPP}),
+		},
+		{
+			name:            "read error",
+			reader:          iotest.ErrReader(&fs.PathError{Op: "read", Path: "/proc/pressure/cpu", Err: syscall.EIO}),
+			wantErrContains: "failed to read /proc/pressure/cpu",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parsePressure("/proc/pressure/cpu", tt.reader)
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Fatalf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tt.wantOk || !reflect.DeepEqual(tt.wantReturn, got) {
+				t.Errorf("expected %+v (%t), got %+v (%t)", tt.wantReturn, tt.wantOk, got, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+// clockTicks is USER_HZ, the unit of the CPU times of /proc/<pid>/stat, which is 100 on every Linux architecture.
+const clockTicks = 100
+
+// processSample holds the cumulative counters of a process at one point in time.
+type processSample struct {
+	stats      models.ProcessStats
+	startTicks uint64
+	cpuTicks   uint64
+	readB      uint64
+	writeB     uint64
+}
+
+// readProcesses reads every process the agent may inspect twice, interval apart, to measure its CPU usage
+// and I/O rates. Processes that start or exit meanwhile are reported with the usage measured while they ran.
+// A zero interval reads the processes once, without their CPU usage and I/O rates.
+func readProcesses(ctx context.Context, interval time.Duration) ([]models.ProcessStats, error) {
+	roots := rootsFromContext(ctx)
+	bootTime, err := readBootTime(filepath.Join(roots.Proc, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	users, err := readUserNames(filepath.Join(roots.Etc, "passwd"))
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	var before map[int32]processSample
+	if interval > 0 {
+		if before, err = sampleProcesses(ctx, roots.Proc, nil); err != nil {
+			return nil, err
+		}
+
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	after, err := sampleProcesses(ctx, roots.Proc, users)
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start).Seconds()
+
+	processes := make([]models.ProcessStats, 0, len(after))
+	for pid, sample := range after {
+		stats := sample.stats
+		stats.StartTime = bootTime + sample.startTicks/clockTicks
+		if previous, ok := before[pid]; ok && previous.startTicks == sample.startTicks {
+			stats.CpuPercent = counterRate(previous.cpuTicks, sample.cpuTicks, elapsed) / clockTicks * 100
+			stats.ReadBps = counterRate(previous.readB, sample.readB, elapsed)
+			stats.WriteBps = counterRate(previous.writeB, sample.writeB, elapsed)
+		}
+		processes = append(processes, stats)
+	}
+	return processes, nil
+}
+
+// sampleProcesses reads the counters of each process. The details only reported once, such as the user
+// and the cgroup, are read when users is not nil.
+func sampleProcesses(ctx context.Context, proc string, users map[string]string) (map[int32]processSample, error) {
+	entries, err := os.ReadDir(proc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", proc, err)
+	}
+	samples := make(map[int32]processSample, len(entries))
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pid, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		// Reading a process that exits meanwhile fails, it is then left out.
+		sample, err := readProcessSample(filepath.Join(proc, entry.Name()), users != nil)
+		if err != nil {
+			continue
+		}
+		sample.stats.Pid = int32(pid)
+		if users != nil {
+			// Users missing from the passwd file are reported by their uid.
+			if name, ok := users[sample.stats.User]; ok {
+				sample.stats.User = name
+			}
+		}
+		samples[int32(pid)] = sample
+	}
+	return samples, nil
+}
+
+func readProcessSample(dir string, details bool) (processSample, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return processSample{}, err
+	}
+	sample, err := parseProcessStat(string(data))
+	if err != nil {
+		return processSample{}, fmt.Errorf("failed to parse %s: %v", filepath.Join(dir, "stat"), err)
+	}
+	// The I/O counters of other users' processes are only readable by root.
+	if data, err := os.ReadFile(filepath.Join(dir, "io")); err == nil {
+		sample.readB, sample.writeB = parseProcessIO(string(data))
+	}
+	if !details {
+		return sample, nil
+	}
+
+	if data, err = os.ReadFile(filepath.Join(dir, "status")); err != nil {
+		return processSample{}, err
+	}
+	sample.stats.User = parseProcessUid(string(data))
+	if data, err = os.ReadFile(filepath.Join(dir, "cmdline")); err != nil {
+		return processSample{}, err
+	}
+	sample.stats.Cmdline = strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " "))
+	if data, err = os.ReadFile(filepath.Join(dir, "cgroup")); err == nil {
+		sample.stats.Cgroup = parseProcessCgroup(string(data))
+		sample.stats.Unit = systemdUnitOfCgroup(sample.stats.Cgroup)
+	}
+	if fds, err := os.ReadDir(filepath.Join(dir, "fd")); err == nil {
+		sample.stats.OpenFds = uint64(len(fds))
+	}
+	return sample, nil
+}
+
+// parseProcessStat parses /proc/<pid>/stat. The name is enclosed in parentheses and may contain spaces
+// and parentheses itself, so the fields are counted from the last closing one.
+func parseProcessStat(content string) (processSample, error) {
+	open, end := strings.IndexByte(content, '('), strings.LastIndexByte(content, ')')
+	if open < 0 || end < open {
+		return processSample{}, fmt.Errorf("missing process name")
+	}
+	// fields[0] is the state, the third field of the file.
+	fields := strings.Fields(content[end+1:])
+	if len(fields) < 22 {
+		return processSample{}, fmt.Errorf("expected at least 24 fields, got %d", len(fields)+2)
+	}
+	var values [5]uint64
+	for i, index := range []int{11, 12, 17, 19, 21} {
+		value, err := strconv.ParseUint(fields[index], 10, 64)
+		if err != nil {
+			return processSample{}, err
+		}
+		values[i] = value
+	}
+	utime, stime, threads, startTicks, rssPages := values[0], values[1], values[2], values[3], values[4]
+	return processSample{
+		stats: models.ProcessStats{
+			Name:    content[open+1 : end],
+			RssB:    rssPages * uint64(os.Getpagesize()),
+			Threads: threads,
+		},
+		startTicks: startTicks,
+		cpuTicks:   utime + stime,
+	}, nil
+}
+
+// parseProcessIO returns the bytes read from and written to storage by the process.
+func parseProcessIO(content string) (uint64, uint64) {
+	var readB, writeB uint64
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "read_bytes":
+			readB = parsed
+		case "write_bytes":
+			writeB = parsed
+		}
+	}
+	return readB, writeB
+}
+
+// parseProcessUid returns the real uid of the "Uid:" line of /proc/<pid>/status.
+func parseProcessUid(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if rest, ok := strings.CutPrefix(line, "Uid:"); ok {
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				return fields[0]
+			}
+		}
+	}
+	return ""
+}
+
+// parseProcessCgroup returns the cgroup v2 path of /proc/<pid>/cgroup, or the path of the systemd
+// hierarchy on cgroup v1.
+func parseProcessCgroup(content string) string {
+	var v1 string
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			return parts[2]
+		}
+		if parts[1] == "name=systemd" {
+			v1 = parts[2]
+		}
+	}
+	return v1
+}
+
+// systemdUnitOfCgroup returns the innermost service or scope of a cgroup path, e.g. "nginx.service"
+// for "/system.slice/nginx.service".
+func systemdUnitOfCgroup(cgroup string) string {
+	segments := strings.Split(cgroup, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if strings.HasSuffix(segments[i], ".service") || strings.HasSuffix(segments[i], ".scope") {
+			return segments[i]
+		}
+	}
+	return ""
+}
+
+// readBootTime reads the boot time, in seconds since the epoch, from the btime line of /proc/stat.
+func readBootTime(path string) (uint64, error) {
+	content, err := readOptionalFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if content == "" {
+		return 0, fmt.Errorf("%w: %s is not available", ErrNotSupported, path)
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if rest, ok := strings.CutPrefix(line, "btime "); ok {
+			bootTime, err := strconv.ParseUint(strings.TrimSpace(rest), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("failed to parse btime of %s: %v", path, err)
+			}
+			return bootTime, nil
+		}
+	}
+	return 0, fmt.Errorf("failed to parse %s: missing btime", path)
+}
+
+// readUserNames maps the uids of the passwd file to the user names. A missing file maps none.
+func readUserNames(path string) (map[string]string, error) {
+	content, err := readOptionalFile(path)
+	if err != nil {
+		return nil, err
+	}
+	users := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if _, ok := users[fields[2]]; !ok {
+			users[fields[2]] = fields[0]
+		}
+	}
+	return users, nil
+}
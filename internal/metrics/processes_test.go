@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+func Test_readProcesses(t *testing.T) {
+	pageSize := uint64(os.Getpagesize())
+	baseFiles := map[string]string{
+		"proc/stat":       "cpu  1 2 3 4\nbtime 1700000000\nprocesses 100\n",
+		"proc/42/stat":    "42 (nginx: worker (1)) S 1 42 42 0 -1 4194624 100 0 0 0 250 50 0 0 20 0 4 0 12345 100000000 2560 18446744073709551615\n",
+		"proc/42/io":      "rchar: 1000\nwchar: 2000\nread_bytes: 4096\nwrite_bytes: 8192\ncancelled_write_bytes: 0\n",
+		"proc/42/status":  "Name:\tnginx\nUid:\t33\t33\t33\t33\nGid:\t33\t33\t33\t33\n",
+		"proc/42/cmdline": "nginx: worker process\x00",
+		"proc/42/cgroup":  "0::/system.slice/nginx.service\n",
+		"proc/42/fd/0":    "",
+		"proc/42/fd/1":    "",
+		"proc/7/stat":     "7 (postgres) S 1 7 7 0 -1 4194624 100 0 0 0 10 5 0 0 20 0 1 0 500 100000000 100 18446744073709551615\n",
+		"proc/7/status":   "Name:\tpostgres\nUid:\t1001\t1001\t1001\t1001\n",
+		"proc/7/cmdline":  "/usr/lib/postgresql/16/bin/postgres\x00-D\x00/var/lib/postgresql/16/main\x00",
+		"proc/7/cgroup":   "12:pids:/system.slice/postgresql@16-main.service\n1:name=systemd:/system.slice/postgresql@16-main.service\n",
+		"etc/passwd":      "root:x:0:0:root:/root:/bin/bash\nwww-data:x:33:33:www-data:/var/www:/usr/sbin/nologin\n",
+		"proc/99/comm":    "exited\n",
+	}
+	tests := []struct {
+		name            string
+		files           map[string]string
+		wantReturn      []models.ProcessStats
+		wantErrContains string
+		wantErr         error
+	}{
+		{
+			name:  "processes with their user, cgroup and unit",
+			files: baseFiles,
+			wantReturn: []models.ProcessStats{
+				{
+					Pid: 7, Name: "postgres", Cmdline: "/usr/lib/postgresql/16/bin/postgres -D /var/lib/postgresql/16/main",
+					User: "1001", Cgroup: "/system.slice/postgresql@16-main.service", Unit: "postgresql@16-main.service",
+					StartTime: 1700000005, RssB: 100 * pageSize, Threads: 1,
+				},
+				{
+					Pid: 42, Name: "nginx: worker (1)", Cmdline: "nginx: worker process",
+					User: "www-data", Cgroup: "/system.slice/nginx.service", Unit: "nginx.service",
+					StartTime: 1700000123, RssB: 2560 * pageSize, Threads: 4, OpenFds: 2,
+				},
+			},
+		},
+		{
+			name:    "no procfs",
+			files:   map[string]string{"etc/passwd": ""},
+			wantErr: ErrNotSupported,
+		},
+		{
+			name:            "missing boot time",
+			files:           map[string]string{"proc/stat": "cpu  1 2 3 4\n"},
+			wantErrContains: "missing btime",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			ctx := WithRoots(context.Background(), Roots{Proc: root + "/proc", Etc: root + "/etc"})
+			processes, err := readProcesses(ctx, 0)
+			if tt.wantErr != nil || tt.wantErrContains != "" {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected error %v, got %v", tt.wantErr, err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got %q", tt.wantErrContains, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sort.Slice(processes, func(i, j int) bool { return processes[i].Pid < processes[j].Pid })
+			if !reflect.DeepEqual(processes, tt.wantReturn) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, processes)
+			}
+		})
+	}
+}
+
+func Test_parseProcessCgroup(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantCgroup string
+		wantUnit   string
+	}{
+		{
+			name:       "cgroup v2",
+			content:    "0::/system.slice/docker-0123abcd.scope\n",
+			wantCgroup: "/system.slice/docker-0123abcd.scope",
+			wantUnit:   "docker-0123abcd.scope",
+		},
+		{
+			name:       "user service",
+			content:    "0::/user.slice/user-1000.slice/user@1000.service/app.slice/syncthing.service\n",
+			wantCgroup: "/user.slice/user-1000.slice/user@1000.service/app.slice/syncthing.service",
+			wantUnit:   "syncthing.service",
+		},
+		{
+			name:       "cgroup v1 systemd hierarchy",
+			content:    "4:memory:/system.slice/cron.service\n1:name=systemd:/system.slice/cron.service\n",
+			wantCgroup: "/system.slice/cron.service",
+			wantUnit:   "cron.service",
+		},
+		{
+			name:       "outside of systemd",
+			content:    "0::/\n",
+			wantCgroup: "/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cgroup := parseProcessCgroup(tt.content)
+			if cgroup != tt.wantCgroup {
+				t.Errorf("expected cgroup %q, got %q", tt.wantCgroup, cgroup)
+			}
+			if unit := systemdUnitOfCgroup(cgroup); unit != tt.wantUnit {
+				t.Errorf("expected unit %q, got %q", tt.wantUnit, unit)
+			}
+		})
+	}
+}
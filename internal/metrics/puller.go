@@ -1,69 +1,110 @@
 package metrics
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Matyjash/Metrigo/internal/models"
 	cpu "github.com/shirou/gopsutil/v4/cpu"
+	disk "github.com/shirou/gopsutil/v4/disk"
 	"github.com/shirou/gopsutil/v4/host"
+	load "github.com/shirou/gopsutil/v4/load"
 	mem "github.com/shirou/gopsutil/v4/mem"
 	net "github.com/shirou/gopsutil/v4/net"
 	sensors "github.com/shirou/gopsutil/v4/sensors"
 )
 
 type MetricsPuller interface {
-	GetCpuUsage(perCpu bool, interval time.Duration) ([]float64, error)
-	GetPhysicalCpuCount() (int, error)
-	GetLogicalCpuCount() (int, error)
-	GetCpusSpec() ([]models.CpuSpec, error)
-	GetVMMemoryUsage() (models.MemoryUsage, error)
-	GetTemperatures() ([]models.TemperatureSensor, error)
-	GetHostInfo() (models.HostInfo, error)
-	GetNetInterfaces() ([]models.NetInterface, error)
+	GetCpuUsage(ctx context.Context, perCpu bool, interval time.Duration) ([]float64, error)
+	GetPhysicalCpuCount(ctx context.Context) (int, error)
+	GetLogicalCpuCount(ctx context.Context) (int, error)
+	GetCpusSpec(ctx context.Context) ([]models.CpuSpec, error)
+	// GetCpuStats measures the time spent in each CPU state and the rate of context switches and interrupts over the interval.
+	GetCpuStats(ctx context.Context, interval time.Duration) (models.CpuStats, error)
+	// GetCpuFrequencies returns the live cpufreq state of each logical CPU.
+	GetCpuFrequencies(ctx context.Context) ([]models.CpuFrequency, error)
+	GetVMMemoryUsage(ctx context.Context) (models.MemoryUsage, error)
+	GetTemperatures(ctx context.Context) ([]models.TemperatureSensor, error)
+	GetHostInfo(ctx context.Context) (models.HostInfo, error)
+	GetNetInterfaces(ctx context.Context) ([]models.NetInterface, error)
+	GetDisksUsage(ctx context.Context) ([]models.DiskUsage, error)
+	GetLoadAverage(ctx context.Context) (models.LoadAverage, error)
+	// GetCgroupStats reads the cgroup at path, relative to the cgroupfs root, or the agent's own cgroup when path is empty.
+	GetCgroupStats(ctx context.Context, path string) (models.CgroupStats, error)
+	// GetContainers returns the running containers of the runtime listening on the Unix socket.
+	GetContainers(ctx context.Context, socket string) ([]models.ContainerStats, error)
+	// GetPressure returns the host-wide Pressure Stall Information, followed by the one of the cgroup at path,
+	// relative to the cgroupfs root, or of the agent's own cgroup when path is empty.
+	GetPressure(ctx context.Context, cgroupPath string) ([]models.PressureStats, error)
+	// GetSocketStats returns the TCP and UDP sockets with their owning process and the protocol counters of the network stack.
+	GetSocketStats(ctx context.Context) (models.SocketStats, error)
+	// GetSystemdUnits returns the loaded systemd units matching the glob patterns, all of them when there are none.
+	GetSystemdUnits(ctx context.Context, patterns []string) ([]models.SystemdUnit, error)
+	// GetKernelLimits returns the usage of the kernel tables and the file descriptors of each process against its limit.
+	GetKernelLimits(ctx context.Context) (models.KernelLimits, error)
+	// GetProcesses returns the processes with their CPU usage and I/O rates measured over the interval,
+	// without them when the interval is 0.
+	GetProcesses(ctx context.Context, interval time.Duration) ([]models.ProcessStats, error)
+	// GetMemoryDetails returns the breakdown of /proc/meminfo with the hugepage pools, the transparent hugepage settings
+	// and the memory of each NUMA node.
+	GetMemoryDetails(ctx context.Context) (models.MemoryDetails, error)
+	// GetMounts returns the disk and network filesystems mounted on the host with their options and usage.
+	// Mounts whose usage is not read within statTimeout are reported as stale.
+	GetMounts(ctx context.Context, statTimeout time.Duration) ([]models.MountStatus, error)
+	// GetHwmonSensors returns the fan, voltage, power and current sensors of the hardware monitoring chips.
+	GetHwmonSensors(ctx context.Context) ([]models.HwmonSensor, error)
 }
 
+// GopsutilPuller reads the metrics of the host with gopsutil, from the filesystems of the Roots set
+// in the context with WithRoots.
 type GopsutilPuller struct {
+	containers containerRuntime
+	mounts     mountStatter
 }
 
 func NewGopsutilPuller() *GopsutilPuller {
 	return &GopsutilPuller{}
 }
 
-func (gp *GopsutilPuller) GetCpuUsage(perCpu bool, interval time.Duration) ([]float64, error) {
-	usagePercent, err := cpu.Percent(interval, perCpu)
+func (gp *GopsutilPuller) GetCpuUsage(ctx context.Context, perCpu bool, interval time.Duration) ([]float64, error) {
+	usagePercent, err := cpu.PercentWithContext(ctx, interval, perCpu)
 	if err != nil {
-		return nil, err
+		return nil, platformError(err)
 	}
 	return usagePercent, nil
 }
 
-func (gp *GopsutilPuller) GetPhysicalCpuCount() (int, error) {
-	count, err := cpu.Counts(false)
+func (gp *GopsutilPuller) GetPhysicalCpuCount(ctx context.Context) (int, error) {
+	count, err := cpu.CountsWithContext(ctx, false)
 	if err != nil {
-		return 0, err
+		return 0, platformError(err)
 	}
 	if count < 1 {
-		return 0, fmt.Errorf("no CPUs found")
+		return 0, ErrNoCpus
 	}
 	return count, nil
 }
 
-func (gp *GopsutilPuller) GetLogicalCpuCount() (int, error) {
-	count, err := cpu.Counts(true)
+func (gp *GopsutilPuller) GetLogicalCpuCount(ctx context.Context) (int, error) {
+	count, err := cpu.CountsWithContext(ctx, true)
 	if err != nil {
-		return 0, err
+		return 0, platformError(err)
 	}
 	if count < 1 {
-		return 0, fmt.Errorf("no CPUs found")
+		return 0, ErrNoCpus
 	}
 	return count, nil
 }
 
-func (gp *GopsutilPuller) GetCpusSpec() ([]models.CpuSpec, error) {
-	infoStats, err := cpu.Info()
+func (gp *GopsutilPuller) GetCpusSpec(ctx context.Context) ([]models.CpuSpec, error) {
+	infoStats, err := cpu.InfoWithContext(ctx)
 	if err != nil {
-		return nil, err
+		return nil, platformError(err)
 	}
 
 	var cpusSpec []models.CpuSpec
@@ -71,59 +112,121 @@ func (gp *GopsutilPuller) GetCpusSpec() ([]models.CpuSpec, error) {
 	for _, cpuInfo := range infoStats {
 		cpusSpec = append(cpusSpec, models.CpuSpec{
 			FrequencyMhz: cpuInfo.Mhz,
+			VendorID:     cpuInfo.VendorID,
+			ModelName:    cpuInfo.ModelName,
+			Family:       cpuInfo.Family,
+			Model:        cpuInfo.Model,
+			Stepping:     cpuInfo.Stepping,
+			CacheSizeKB:  cpuInfo.CacheSize,
+			Flags:        cpuInfo.Flags,
+			SocketID:     cpuInfo.PhysicalID,
+			CoreID:       cpuInfo.CoreID,
+			Cores:        cpuInfo.Cores,
 		})
 	}
 
 	return cpusSpec, nil
 }
 
-func (gp *GopsutilPuller) GetTemperatures() ([]models.TemperatureSensor, error) {
-	sensors, err := sensors.SensorsTemperatures()
+func (gp *GopsutilPuller) GetCpuStats(ctx context.Context, interval time.Duration) (models.CpuStats, error) {
+	return readCpuStats(ctx, interval)
+}
+
+func (gp *GopsutilPuller) GetCpuFrequencies(ctx context.Context) ([]models.CpuFrequency, error) {
+	return readCpuFrequencies(ctx)
+}
+
+func (gp *GopsutilPuller) GetTemperatures(ctx context.Context) ([]models.TemperatureSensor, error) {
+	sensors, err := sensors.TemperaturesWithContext(ctx)
 	if err != nil {
-		return []models.TemperatureSensor{}, err
+		return []models.TemperatureSensor{}, platformError(err)
 	}
 	if len(sensors) == 0 {
-		return []models.TemperatureSensor{}, fmt.Errorf("no temperature sensors found")
+		return []models.TemperatureSensor{}, ErrNoTemperatureSensors
 	}
 
+	chips := hwmonChipNames(ctx)
 	temperatureSensors := make([]models.TemperatureSensor, len(sensors))
 	for i, sensor := range sensors {
 		temperatureSensors[i] = models.TemperatureSensor{
-			Key:   sensor.SensorKey,
-			Value: sensor.Temperature,
+			Key:      sensor.SensorKey,
+			Chip:     temperatureChip(sensor.SensorKey, chips),
+			Value:    sensor.Temperature,
+			High:     sensor.High,
+			Critical: sensor.Critical,
 		}
 	}
 
 	return temperatureSensors, nil
 }
 
-func (gp *GopsutilPuller) GetVMMemoryUsage() (models.MemoryUsage, error) {
-	vmStat, err := mem.VirtualMemory()
+func (gp *GopsutilPuller) GetVMMemoryUsage(ctx context.Context) (models.MemoryUsage, error) {
+	vmStat, err := mem.VirtualMemoryWithContext(ctx)
 	if err != nil {
-		return models.MemoryUsage{}, err
+		return models.MemoryUsage{}, platformError(err)
 	}
 	return models.MemoryUsage{UsedB: vmStat.Used, TotalB: vmStat.Total}, nil
 }
 
-func (gp *GopsutilPuller) GetHostInfo() (models.HostInfo, error) {
-	info, err := host.Info()
+func (gp *GopsutilPuller) GetHostInfo(ctx context.Context) (models.HostInfo, error) {
+	info, err := host.InfoWithContext(ctx)
+	if err != nil {
+		return models.HostInfo{}, platformError(err)
+	}
+	hostname, err := gp.hostname(ctx, info.Hostname)
+	if err != nil {
+		return models.HostInfo{}, err
+	}
+	users, err := readUserSessions(ctx)
+	if err != nil {
+		return models.HostInfo{}, err
+	}
+	timezone, err := readTimezone(ctx)
 	if err != nil {
 		return models.HostInfo{}, err
 	}
 	return models.HostInfo{
-		Hostname:        info.Hostname,
-		OS:              info.OS,
-		Platform:        info.Platform,
-		PlatformVersion: info.PlatformVersion,
-		Uptime:          info.Uptime,
+		Hostname:             hostname,
+		OS:                   info.OS,
+		Platform:             info.Platform,
+		PlatformVersion:      info.PlatformVersion,
+		Uptime:               info.Uptime,
+		KernelVersion:        info.KernelVersion,
+		KernelArch:           info.KernelArch,
+		BootTime:             info.BootTime,
+		VirtualizationSystem: info.VirtualizationSystem,
+		VirtualizationRole:   info.VirtualizationRole,
+		HostID:               info.HostID,
+		Procs:                info.Procs,
+		Users:                users,
+		Timezone:             timezone,
 	}, nil
 
 }
 
-func (gp *GopsutilPuller) GetNetInterfaces() ([]models.NetInterface, error) {
-	interfaces, err := net.Interfaces()
+// hostname returns the hostname of the host rather than of the agent's UTS namespace when the etc root is set.
+func (gp *GopsutilPuller) hostname(ctx context.Context, utsHostname string) (string, error) {
+	roots := rootsFromContext(ctx)
+	if roots.Etc == "/etc" {
+		return utsHostname, nil
+	}
+	data, err := os.ReadFile(filepath.Join(roots.Etc, "hostname"))
+	if errors.Is(err, os.ErrNotExist) {
+		return utsHostname, nil
+	}
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to read host hostname: %v", err)
+	}
+	if hostname := strings.TrimSpace(string(data)); hostname != "" {
+		return hostname, nil
+	}
+	return utsHostname, nil
+}
+
+func (gp *GopsutilPuller) GetNetInterfaces(ctx context.Context) ([]models.NetInterface, error) {
+	interfaces, err := net.InterfacesWithContext(ctx)
+	if err != nil {
+		return nil, platformError(err)
 	}
 
 	netInterfaces := make([]models.NetInterface, len(interfaces))
@@ -142,3 +245,88 @@ func (gp *GopsutilPuller) GetNetInterfaces() ([]models.NetInterface, error) {
 	}
 	return netInterfaces, nil
 }
+
+func (gp *GopsutilPuller) GetDisksUsage(ctx context.Context) ([]models.DiskUsage, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return nil, platformError(err)
+	}
+
+	roots := rootsFromContext(ctx)
+	var disksUsage []models.DiskUsage
+	for _, partition := range partitions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		usage, err := disk.UsageWithContext(ctx, roots.hostPath(partition.Mountpoint))
+		if err != nil {
+			// Partitions that are not accessible (e.g. missing permissions) are skipped.
+			continue
+		}
+		disksUsage = append(disksUsage, models.DiskUsage{
+			Path:        partition.Mountpoint,
+			Fstype:      usage.Fstype,
+			TotalB:      usage.Total,
+			UsedB:       usage.Used,
+			FreeB:       usage.Free,
+			UsedPercent: usage.UsedPercent,
+		})
+	}
+	if len(disksUsage) == 0 {
+		return nil, ErrNoDiskPartitions
+	}
+	return disksUsage, nil
+}
+
+func (gp *GopsutilPuller) GetLoadAverage(ctx context.Context) (models.LoadAverage, error) {
+	avg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		return models.LoadAverage{}, platformError(err)
+	}
+	return models.LoadAverage{Load1: avg.Load1, Load5: avg.Load5, Load15: avg.Load15}, nil
+}
+
+func (gp *GopsutilPuller) GetCgroupStats(ctx context.Context, path string) (models.CgroupStats, error) {
+	if err := ctx.Err(); err != nil {
+		return models.CgroupStats{}, err
+	}
+	roots := rootsFromContext(ctx)
+	cgroup := cgroupReader{root: filepath.Join(roots.Sys, "fs", "cgroup"), procRoot: roots.Proc}
+	return cgroup.stats(path)
+}
+
+func (gp *GopsutilPuller) GetContainers(ctx context.Context, socket string) ([]models.ContainerStats, error) {
+	return gp.containers.containers(ctx, socket)
+}
+
+func (gp *GopsutilPuller) GetHwmonSensors(ctx context.Context) ([]models.HwmonSensor, error) {
+	return readHwmonSensors(ctx)
+}
+
+func (gp *GopsutilPuller) GetPressure(ctx context.Context, cgroupPath string) ([]models.PressureStats, error) {
+	return readPressure(ctx, cgroupPath)
+}
+
+func (gp *GopsutilPuller) GetSocketStats(ctx context.Context) (models.SocketStats, error) {
+	return readSocketStats(ctx)
+}
+
+func (gp *GopsutilPuller) GetSystemdUnits(ctx context.Context, patterns []string) ([]models.SystemdUnit, error) {
+	return readSystemdUnits(ctx, patterns)
+}
+
+func (gp *GopsutilPuller) GetKernelLimits(ctx context.Context) (models.KernelLimits, error) {
+	return readKernelLimits(ctx)
+}
+
+func (gp *GopsutilPuller) GetProcesses(ctx context.Context, interval time.Duration) ([]models.ProcessStats, error) {
+	return readProcesses(ctx, interval)
+}
+
+func (gp *GopsutilPuller) GetMemoryDetails(ctx context.Context) (models.MemoryDetails, error) {
+	return readMemoryDetails(ctx)
+}
+
+func (gp *GopsutilPuller) GetMounts(ctx context.Context, statTimeout time.Duration) ([]models.MountStatus, error) {
+	return gp.mounts.readMounts(ctx, statTimeout)
+}
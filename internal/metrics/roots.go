@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/shirou/gopsutil/v4/common"
+)
+
+// defaultProc is where procfs is mounted when no root is set.
+const defaultProc = "/proc"
+
+// Roots are the directories the host filesystems are mounted at, e.g. "/host/proc" when the agent runs
+// in a container. Empty fields use the default paths.
+type Roots struct {
+	// Root is the host's root filesystem, used to reach mount points for disk usage.
+	Root string
+	Proc string
+	Sys  string
+	Etc  string
+	Run  string
+	// Var is where the logged-in user sessions are read from, in <var>/run/utmp.
+	Var string
+}
+
+// WithRoots returns a context making the puller, including the gopsutil calls, read the host filesystems from roots.
+func WithRoots(ctx context.Context, roots Roots) context.Context {
+	env := common.EnvMap{}
+	for key, value := range map[common.EnvKeyType]string{
+		common.HostRootEnvKey: roots.Root,
+		common.HostProcEnvKey: roots.Proc,
+		common.HostSysEnvKey:  roots.Sys,
+		common.HostEtcEnvKey:  roots.Etc,
+		common.HostRunEnvKey:  roots.Run,
+		common.HostVarEnvKey:  roots.Var,
+	} {
+		if value != "" {
+			env[key] = value
+		}
+	}
+	if len(env) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, common.EnvKey, env)
+}
+
+// rootsFromContext returns the roots set with WithRoots, with the default paths for the others.
+func rootsFromContext(ctx context.Context) Roots {
+	env, _ := ctx.Value(common.EnvKey).(common.EnvMap)
+	root := func(key common.EnvKeyType, dfault string) string {
+		if value := env[key]; value != "" {
+			return value
+		}
+		return dfault
+	}
+	return Roots{
+		Root: root(common.HostRootEnvKey, "/"),
+		Proc: root(common.HostProcEnvKey, defaultProc),
+		Sys:  root(common.HostSysEnvKey, "/sys"),
+		Etc:  root(common.HostEtcEnvKey, "/etc"),
+		Run:  root(common.HostRunEnvKey, "/run"),
+		Var:  root(common.HostVarEnvKey, "/var"),
+	}
+}
+
+// hostPath returns the path of the host's absolute path under the root.
+func (r Roots) hostPath(path string) string {
+	return filepath.Join(r.Root, path)
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+func Test_GopsutilPullerRoots(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"proc/loadavg":                     "0.50 1.00 1.50 1/100 4242\n",
+		"proc/meminfo":                     "MemTotal:        1000 kB\nMemFree:          200 kB\nMemAvailable:     400 kB\nBuffers:            0 kB\nCached:             0 kB\n",
+		"proc/self/cgroup":                 "0::/app\n",
+		"sys/fs/cgroup/cgroup.controllers": "memory\n",
+		"sys/fs/cgroup/app/memory.current": "4096\n",
+		"etc/hostname":                     "host-from-etc\n",
+	})
+	ctx := WithRoots(context.Background(), Roots{
+		Proc: filepath.Join(root, "proc"),
+		Sys:  filepath.Join(root, "sys"),
+		Etc:  filepath.Join(root, "etc"),
+	})
+	puller := NewGopsutilPuller()
+
+	loadAverage, err := puller.GetLoadAverage(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (models.LoadAverage{Load1: 0.5, Load5: 1, Load15: 1.5}); loadAverage != want {
+		t.Errorf("expected %v, got %v", want, loadAverage)
+	}
+
+	memoryUsage, err := puller.GetVMMemoryUsage(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if memoryUsage.TotalB != 1000*1024 {
+		t.Errorf("expected total %d, got %d", 1000*1024, memoryUsage.TotalB)
+	}
+
+	cgroupStats, err := puller.GetCgroupStats(ctx, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (models.CgroupStats{Path: "/app", Version: 2, Memory: models.CgroupMemoryStats{UsageB: 4096}}); !reflect.DeepEqual(want, cgroupStats) {
+		t.Errorf("expected %+v, got %+v", want, cgroupStats)
+	}
+
+	hostname, err := puller.hostname(ctx, "container-hostname")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hostname != "host-from-etc" {
+		t.Errorf("expected hostname from the etc root, got %s", hostname)
+	}
+	if hostname, _ := puller.hostname(context.Background(), "container-hostname"); hostname != "container-hostname" {
+		t.Errorf("expected hostname of the UTS namespace without roots, got %s", hostname)
+	}
+}
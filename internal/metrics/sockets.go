@@ -0,0 +1,332 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+	net "github.com/shirou/gopsutil/v4/net"
+)
+
+// States of a socket in addition to the TCP ones.
+const (
+	ConnListen      = "LISTEN"
+	ConnEstablished = "ESTABLISHED"
+	// ConnUnconnected is the state of a UDP socket without a remote address, i.e. a UDP listener.
+	ConnUnconnected = "UNCONN"
+)
+
+// readSocketStats reads the sockets and, where the kernel reports them, the protocol counters. The counters
+// are left empty, with HasCounters unset, on platforms without /proc/net/snmp.
+func readSocketStats(ctx context.Context) (models.SocketStats, error) {
+	stats, err := readConnectionStats(ctx)
+	if err != nil {
+		return models.SocketStats{}, err
+	}
+	connections := make([]models.Connection, len(stats))
+	for i, stat := range stats {
+		connections[i] = connectionFromStat(stat)
+	}
+	socketStats := models.SocketStats{Connections: connections}
+
+	counters, err := readProtocolCounters(ctx)
+	if errors.Is(err, ErrNotSupported) {
+		return socketStats, nil
+	}
+	if err != nil {
+		return models.SocketStats{}, err
+	}
+	socketStats.Counters = counters
+	socketStats.HasCounters = true
+	return socketStats, nil
+}
+
+// netProc returns the procfs directory of the host's network namespace. <proc>/net is the network namespace
+// of the reading process, which is the agent's own one in a container, so under a host procfs mounted elsewhere
+// the one of the host's init process is read.
+func netProc(roots Roots) string {
+	if roots.Proc == defaultProc {
+		return roots.Proc
+	}
+	return filepath.Join(roots.Proc, "1")
+}
+
+// readConnectionStats reads the TCP and UDP sockets with gopsutil, or from the tables of the host's network
+// namespace under a host procfs mounted elsewhere.
+func readConnectionStats(ctx context.Context) ([]net.ConnectionStat, error) {
+	roots := rootsFromContext(ctx)
+	if roots.Proc == defaultProc {
+		stats, err := net.ConnectionsWithContext(ctx, "inet")
+		if err != nil {
+			return nil, platformError(err)
+		}
+		return stats, nil
+	}
+
+	owners, err := socketOwners(ctx, roots.Proc)
+	if err != nil {
+		return nil, err
+	}
+	var stats []net.ConnectionStat
+	for _, table := range socketTables {
+		tableStats, err := readSocketTable(filepath.Join(netProc(roots), "net", table.name), table.family, table.sockType, owners)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, tableStats...)
+	}
+	return stats, nil
+}
+
+// socketTables are the files of <proc>/net listing the TCP and UDP sockets.
+var socketTables = []struct {
+	name     string
+	family   uint32
+	sockType uint32
+}{
+	{"tcp", syscall.AF_INET, syscall.SOCK_STREAM},
+	{"tcp6", syscall.AF_INET6, syscall.SOCK_STREAM},
+	{"udp", syscall.AF_INET, syscall.SOCK_DGRAM},
+	{"udp6", syscall.AF_INET6, syscall.SOCK_DGRAM},
+}
+
+// tcpStates maps the hexadecimal states of the socket tables to their names.
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
+
+// readSocketTable parses a socket table such as <proc>/net/tcp. A missing table, e.g. tcp6 with IPv6 disabled,
+// has no sockets.
+func readSocketTable(path string, family uint32, sockType uint32, owners map[string]int32) ([]net.ConnectionStat, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	lines := strings.Split(string(content), "\n")
+	var stats []net.ConnectionStat
+	// The first line is the header: sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode.
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		local, err := decodeSocketAddress(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		remote, err := decodeSocketAddress(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		status := "NONE"
+		if sockType == syscall.SOCK_STREAM {
+			status = tcpStates[fields[3]]
+		}
+		stats = append(stats, net.ConnectionStat{
+			Family: family,
+			Type:   sockType,
+			Laddr:  local,
+			Raddr:  remote,
+			Status: status,
+			Pid:    owners[fields[9]],
+		})
+	}
+	return stats, nil
+}
+
+// decodeSocketAddress decodes an address of a socket table, e.g. "0100007F:0016" for 127.0.0.1:22.
+// The address is in the byte order of the host, which is little endian on every platform Metrigo supports.
+func decodeSocketAddress(field string) (net.Addr, error) {
+	hexIP, hexPort, ok := strings.Cut(field, ":")
+	if !ok {
+		return net.Addr{}, fmt.Errorf("invalid address %q", field)
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return net.Addr{}, fmt.Errorf("invalid address %q: %v", field, err)
+	}
+	ip, err := hex.DecodeString(hexIP)
+	if err != nil || (len(ip) != 4 && len(ip) != 16) {
+		return net.Addr{}, fmt.Errorf("invalid address %q", field)
+	}
+	// Each 32-bit word is reversed.
+	for word := 0; word < len(ip); word += 4 {
+		slices.Reverse(ip[word : word+4])
+	}
+	addr, _ := netip.AddrFromSlice(ip)
+	return net.Addr{IP: addr.Unmap().String(), Port: uint32(port)}, nil
+}
+
+// socketOwners maps the inodes of the sockets to the pid of a process holding them, read from <proc>/<pid>/fd.
+// Processes that exit or whose descriptors cannot be read are skipped.
+func socketOwners(ctx context.Context, proc string) (map[string]int32, error) {
+	entries, err := os.ReadDir(proc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", proc, err)
+	}
+	owners := make(map[string]int32)
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pid, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(proc, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			if inode, ok := strings.CutPrefix(target, "socket:["); ok {
+				inode = strings.TrimSuffix(inode, "]")
+				if _, seen := owners[inode]; !seen {
+					owners[inode] = int32(pid)
+				}
+			}
+		}
+	}
+	return owners, nil
+}
+
+func connectionFromStat(stat net.ConnectionStat) models.Connection {
+	protocol := "tcp"
+	if stat.Type == syscall.SOCK_DGRAM {
+		protocol = "udp"
+	}
+	if stat.Family == syscall.AF_INET6 {
+		protocol += "6"
+	}
+	state := stat.Status
+	if strings.HasPrefix(protocol, "udp") {
+		// The kernel reports no state for UDP sockets, they are named like ss does.
+		state = ConnEstablished
+		if stat.Raddr.Port == 0 {
+			state = ConnUnconnected
+		}
+	}
+	return models.Connection{
+		Protocol:   protocol,
+		LocalAddr:  stat.Laddr.IP,
+		LocalPort:  stat.Laddr.Port,
+		RemoteAddr: stat.Raddr.IP,
+		RemotePort: stat.Raddr.Port,
+		State:      state,
+		Pid:        stat.Pid,
+	}
+}
+
+// readProtocolCounters reads the TCP and UDP counters from <proc>/net/snmp and the TcpExt ones from <proc>/net/netstat
+// of the host's network namespace.
+func readProtocolCounters(ctx context.Context) (models.ProtocolCounters, error) {
+	proc := netProc(rootsFromContext(ctx))
+	snmp, err := readSnmpFile(filepath.Join(proc, "net", "snmp"))
+	if err != nil {
+		return models.ProtocolCounters{}, err
+	}
+	if snmp == nil {
+		return models.ProtocolCounters{}, fmt.Errorf("%w: /proc/net/snmp is not available", ErrNotSupported)
+	}
+	netstat, err := readSnmpFile(filepath.Join(proc, "net", "netstat"))
+	if err != nil {
+		return models.ProtocolCounters{}, err
+	}
+	tcp, udp, tcpExt := snmp["Tcp"], snmp["Udp"], netstat["TcpExt"]
+	return models.ProtocolCounters{
+		TcpActiveOpens:     tcp["ActiveOpens"],
+		TcpPassiveOpens:    tcp["PassiveOpens"],
+		TcpAttemptFails:    tcp["AttemptFails"],
+		TcpEstabResets:     tcp["EstabResets"],
+		TcpCurrEstab:       tcp["CurrEstab"],
+		TcpInSegs:          tcp["InSegs"],
+		TcpOutSegs:         tcp["OutSegs"],
+		TcpRetransSegs:     tcp["RetransSegs"],
+		TcpInErrs:          tcp["InErrs"],
+		TcpOutRsts:         tcp["OutRsts"],
+		TcpListenOverflows: tcpExt["ListenOverflows"],
+		TcpListenDrops:     tcpExt["ListenDrops"],
+		UdpInDatagrams:     udp["InDatagrams"],
+		UdpOutDatagrams:    udp["OutDatagrams"],
+		UdpNoPorts:         udp["NoPorts"],
+		UdpInErrors:        udp["InErrors"],
+		UdpRcvbufErrors:    udp["RcvbufErrors"],
+		UdpSndbufErrors:    udp["SndbufErrors"],
+	}, nil
+}
+
+// readSnmpFile parses the pairs of header and value lines of /proc/net/snmp and /proc/net/netstat, e.g.
+// "Tcp: RtoAlgorithm RtoMin" followed by "Tcp: 1 200", into counters by protocol and name.
+// It returns nil when the file does not exist. Negative values such as the MaxConn of -1 are skipped.
+func readSnmpFile(path string) (map[string]map[string]uint64, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	defer file.Close()
+
+	counters := make(map[string]map[string]uint64)
+	var header []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*KiB), 1*MiB)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if header == nil || header[0] != fields[0] {
+			header = fields
+			continue
+		}
+		if len(fields) != len(header) {
+			return nil, fmt.Errorf("failed to parse %s: %d values for %d %s counters", path, len(fields)-1, len(header)-1, strings.TrimSuffix(fields[0], ":"))
+		}
+		protocol := strings.TrimSuffix(fields[0], ":")
+		values := make(map[string]uint64, len(fields)-1)
+		for i, field := range fields[1:] {
+			value, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+			}
+			if value >= 0 {
+				values[header[i+1]] = uint64(value)
+			}
+		}
+		counters[protocol] = values
+		header = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return counters, nil
+}
@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+	net "github.com/shirou/gopsutil/v4/net"
+)
+
+func Test_readProtocolCounters(t *testing.T) {
+	snmp := "Ip: Forwarding DefaultTTL\nIp: 1 64\n" +
+		"Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens AttemptFails EstabResets CurrEstab InSegs OutSegs RetransSegs InErrs OutRsts InCsumErrors\n" +
+		"Tcp: 1 200 120000 -1 120 30 4 5 6 1000 900 12 1 3 0\n" +
+		"Udp: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors InCsumErrors IgnoredMulti MemErrors\n" +
+		"Udp: 500 7 2 400 1 0 0 0 0\n"
+	netstat := "TcpExt: SyncookiesSent ListenOverflows ListenDrops\nTcpExt: 0 8 9\nIpExt: InNoRoutes\nIpExt: 0\n"
+
+	tests := []struct {
+		name            string
+		files           map[string]string
+		wantReturn      models.ProtocolCounters
+		wantUnsupported bool
+		wantErrContains string
+	}{
+		{
+			name:  "snmp and netstat",
+			files: map[string]string{"1/net/snmp": snmp, "1/net/netstat": netstat},
+			wantReturn: models.ProtocolCounters{
+				TcpActiveOpens: 120, TcpPassiveOpens: 30, TcpAttemptFails: 4, TcpEstabResets: 5, TcpCurrEstab: 6,
+				TcpInSegs: 1000, TcpOutSegs: 900, TcpRetransSegs: 12, TcpInErrs: 1, TcpOutRsts: 3,
+				TcpListenOverflows: 8, TcpListenDrops: 9,
+				UdpInDatagrams: 500, UdpOutDatagrams: 400, UdpNoPorts: 7, UdpInErrors: 2, UdpRcvbufErrors: 1,
+			},
+		},
+		{
+			name:  "without netstat",
+			files: map[string]string{"1/net/snmp": "Tcp: RetransSegs\nTcp: 12\n"},
+			wantReturn: models.ProtocolCounters{
+				TcpRetransSegs: 12,
+			},
+		},
+		{
+			name:            "without snmp",
+			files:           map[string]string{"stat": "cpu 0 0 0 0\n"},
+			wantUnsupported: true,
+			wantErrContains: "/proc/net/snmp is not available",
+		},
+		{
+			name:            "missing values",
+			files:           map[string]string{"1/net/snmp": "Tcp: ActiveOpens PassiveOpens\nTcp: 1\n"},
+			wantErrContains: "1 values for 2 Tcp counters",
+		},
+		{
+			name:            "invalid value",
+			files:           map[string]string{"1/net/snmp": "Tcp: ActiveOpens\nTcp: abc\n"},
+			wantErrContains: "failed to parse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			got, err := readProtocolCounters(WithRoots(context.Background(), Roots{Proc: root}))
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Fatalf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				if tt.wantUnsupported && !errors.Is(err, ErrNotSupported) {
+					t.Errorf("expected ErrNotSupported, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, got) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
+
+func Test_readSocketStats(t *testing.T) {
+	header := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	files := map[string]string{
+		"1/net/tcp": header +
+			"   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 100 0 0 10 0\n" +
+			"   1: 0100000A:0016 0200000A:C738 01 00000000:00000000 02:000A7B5C 00000000     0        0 1002 4 0000000000000000 20 4 30 10 -1\n",
+		"1/net/tcp6": header +
+			"   0: 0000000000000000FFFF00000100007F:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1004 1 0000000000000000 100 0 0 10 0\n",
+		"1/net/udp6": header +
+			"   0: 00000000000000000000000000000000:0035 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 1003 2 0000000000000000 0\n",
+		// The agent's own network namespace, which must not be read.
+		"net/tcp": header +
+			"   0: 0100007F:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2001 1 0000000000000000 100 0 0 10 0\n",
+	}
+	root := t.TempDir()
+	writeTree(t, root, files)
+	for pid, inodes := range map[string][]string{"812": {"1001"}, "900": {"1001", "1002"}} {
+		fdDir := filepath.Join(root, pid, "fd")
+		if err := os.MkdirAll(fdDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for fd, inode := range inodes {
+			if err := os.Symlink("socket:["+inode+"]", filepath.Join(fdDir, strconv.Itoa(fd+3))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	got, err := readSocketStats(WithRoots(context.Background(), Roots{Proc: root}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := models.SocketStats{Connections: []models.Connection{
+		{Protocol: "tcp", LocalAddr: "0.0.0.0", LocalPort: 22, RemoteAddr: "0.0.0.0", State: "LISTEN", Pid: 812},
+		{Protocol: "tcp", LocalAddr: "10.0.0.1", LocalPort: 22, RemoteAddr: "10.0.0.2", RemotePort: 51000, State: "ESTABLISHED", Pid: 900},
+		{Protocol: "tcp6", LocalAddr: "127.0.0.1", LocalPort: 8080, RemoteAddr: "::", State: "LISTEN"},
+		{Protocol: "udp6", LocalAddr: "::", LocalPort: 53, RemoteAddr: "::", State: ConnUnconnected},
+	}}
+	// Without <proc>/1/net/snmp the sockets are still reported, without the counters.
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func Test_connectionFromStat(t *testing.T) {
+	tests := []struct {
+		name       string
+		stat       net.ConnectionStat
+		wantReturn models.Connection
+	}{
+		{
+			name:       "tcp listener",
+			stat:       net.ConnectionStat{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Laddr: net.Addr{IP: "0.0.0.0", Port: 22}, Status: "LISTEN", Pid: 812},
+			wantReturn: models.Connection{Protocol: "tcp", LocalAddr: "0.0.0.0", LocalPort: 22, State: "LISTEN", Pid: 812},
+		},
+		{
+			name:       "tcp6 connection",
+			stat:       net.ConnectionStat{Family: syscall.AF_INET6, Type: syscall.SOCK_STREAM, Laddr: net.Addr{IP: "::1", Port: 5432}, Raddr: net.Addr{IP: "::1", Port: 40000}, Status: "ESTABLISHED"},
+			wantReturn: models.Connection{Protocol: "tcp6", LocalAddr: "::1", LocalPort: 5432, RemoteAddr: "::1", RemotePort: 40000, State: "ESTABLISHED"},
+		},
+		{
+			name:       "unconnected udp",
+			stat:       net.ConnectionStat{Family: syscall.AF_INET, Type: syscall.SOCK_DGRAM, Laddr: net.Addr{IP: "127.0.0.53", Port: 53}, Status: "NONE"},
+			wantReturn: models.Connection{Protocol: "udp", LocalAddr: "127.0.0.53", LocalPort: 53, State: ConnUnconnected},
+		},
+		{
+			name:       "connected udp6",
+			stat:       net.ConnectionStat{Family: syscall.AF_INET6, Type: syscall.SOCK_DGRAM, Laddr: net.Addr{IP: "::", Port: 40001}, Raddr: net.Addr{IP: "2001:db8::1", Port: 443}, Status: "NONE"},
+			wantReturn: models.Connection{Protocol: "udp6", LocalAddr: "::", LocalPort: 40001, RemoteAddr: "2001:db8::1", RemotePort: 443, State: ConnEstablished},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := connectionFromStat(tt.stat); !reflect.DeepEqual(tt.wantReturn, got) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+// ErrNoSystemd is returned when the host was not booted with systemd or systemctl is not installed.
+var ErrNoSystemd = errors.New("systemd is not running")
+
+// systemdProperties are the unit properties read with systemctl show.
+var systemdProperties = []string{
+	"Id", "Description", "LoadState", "ActiveState", "SubState", "UnitFileState", "NRestarts", "MemoryCurrent", "CPUUsageNSec",
+}
+
+// readSystemdUnits reads the loaded units matching the glob patterns, all of them when there are none, with systemctl show.
+func readSystemdUnits(ctx context.Context, patterns []string) ([]models.SystemdUnit, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("%w: systemd is only available on Linux", ErrNotSupported)
+	}
+	roots := rootsFromContext(ctx)
+	// The same check as sd_booted(3).
+	if _, err := os.Stat(filepath.Join(roots.Run, "systemd", "system")); err != nil {
+		return nil, ErrNoSystemd
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"*"}
+	}
+
+	args := []string{"show", "--no-pager", "--property=" + strings.Join(systemdProperties, ","), "--"}
+	cmd := exec.CommandContext(ctx, "systemctl", append(args, patterns...)...)
+	if roots.Run != "/run" {
+		// Talk to the host's systemd through its D-Bus socket when /run of the host is mounted in the container.
+		cmd.Env = append(os.Environ(), "DBUS_SYSTEM_BUS_ADDRESS=unix:path="+filepath.Join(roots.Run, "dbus", "system_bus_socket"))
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrNoSystemd, err)
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to run systemctl show: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseSystemctlShow(output)
+}
+
+// parseSystemctlShow parses the output of systemctl show: a block of Key=value lines per unit, separated by empty lines.
+func parseSystemctlShow(output []byte) ([]models.SystemdUnit, error) {
+	var units []models.SystemdUnit
+	var unit models.SystemdUnit
+	flush := func() {
+		if unit.Name != "" {
+			units = append(units, unit)
+		}
+		unit = models.SystemdUnit{}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("failed to parse systemctl show output: invalid line %q", line)
+		}
+		var err error
+		switch key {
+		case "Id":
+			unit.Name = value
+		case "Description":
+			unit.Description = value
+		case "LoadState":
+			unit.LoadState = value
+		case "ActiveState":
+			unit.ActiveState = value
+		case "SubState":
+			unit.SubState = value
+		case "UnitFileState":
+			unit.UnitFileState = value
+		case "NRestarts":
+			unit.Restarts, err = parseSystemdUint(value)
+		case "MemoryCurrent":
+			unit.MemoryB, err = parseSystemdUint(value)
+		case "CPUUsageNSec":
+			var nsec uint64
+			nsec, err = parseSystemdUint(value)
+			unit.CpuSeconds = float64(nsec) / 1e9
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s of unit %s: %v", key, unit.Name, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read systemctl show output: %v", err)
+	}
+	flush()
+	return units, nil
+}
+
+// parseSystemdUint parses an accounting value, 0 when it is not tracked: empty, "[not set]" or the maximum uint64.
+func parseSystemdUint(value string) (uint64, error) {
+	if value == "" || value == "[not set]" {
+		return 0, nil
+	}
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if number == ^uint64(0) {
+		return 0, nil
+	}
+	return number, nil
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+func Test_parseSystemctlShow(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "systemctl_show.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		output          string
+		wantReturn      []models.SystemdUnit
+		wantErrContains string
+	}{
+		{
+			name:   "recorded output",
+			output: string(fixture),
+			wantReturn: []models.SystemdUnit{
+				{Name: "nginx.service", Description: "A high performance web server and a reverse proxy server", LoadState: "loaded", ActiveState: "active", SubState: "running", UnitFileState: "enabled", Restarts: 2, MemoryB: 15728640, CpuSeconds: 2.5},
+				{Name: "backup.service", Description: "Nightly backup", LoadState: "loaded", ActiveState: "failed", SubState: "failed", UnitFileState: "static"},
+				{Name: "home.mount", Description: "/home", LoadState: "loaded", ActiveState: "active", SubState: "mounted", UnitFileState: "generated"},
+				{Name: "ghost.service", Description: "ghost.service", LoadState: "not-found", ActiveState: "inactive", SubState: "dead"},
+			},
+		},
+		{
+			name:   "no matching units",
+			output: "",
+		},
+		{
+			name:            "invalid line",
+			output:          "Id=nginx.service\nActiveState\n",
+			wantErrContains: "invalid line \"ActiveState\"",
+		},
+		{
+			name:            "invalid number",
+			output:          "Id=nginx.service\nNRestarts=many\n",
+			wantErrContains: "failed to parse NRestarts of unit nginx.service",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSystemctlShow([]byte(tt.output))
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Fatalf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, got) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,830 @@
+package metrigo
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/Matyjash/Metrigo/internal/collector"
+	"github.com/Matyjash/Metrigo/internal/metrics"
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+const (
+	CollectorCpu        = "cpu"
+	CollectorTemp       = "temp"
+	CollectorMem        = "mem"
+	CollectorHost       = "host"
+	CollectorNet        = "net"
+	CollectorDisk       = "disk"
+	CollectorLoad       = "load"
+	CollectorCgroup     = "cgroup"
+	CollectorContainers = "containers"
+	CollectorHwmon      = "hwmon"
+	CollectorPsi        = "psi"
+	CollectorConns      = "conns"
+	CollectorSystemd    = "systemd"
+	CollectorLimits     = "limits"
+	CollectorProcGroups = "procgroups"
+	CollectorMemInfo    = "meminfo"
+	CollectorMounts     = "mounts"
+)
+
+// NewRegistry returns a registry with the built-in collectors backed by m.
+func NewRegistry(m *Metrigo) *collector.Registry {
+	registry := collector.NewRegistry()
+	registry.MustRegister(
+		cpuCollector(m),
+		tempCollector(m),
+		memCollector(m),
+		hostCollector(m),
+		netCollector(m),
+		diskCollector(m),
+		loadCollector(m),
+		cgroupCollector(m),
+		containersCollector(m),
+		hwmonCollector(m),
+		psiCollector(m),
+		connsCollector(m),
+		systemdCollector(m),
+		limitsCollector(m),
+		procGroupsCollector(m),
+		memInfoCollector(m),
+		mountsCollector(m),
+	)
+	return registry
+}
+
+func cpuCollector(m *Metrigo) collector.Collector {
+	descriptors := []collector.Descriptor{
+		{Name: "usage_percent", Help: "CPU usage in percent.", Unit: "percent", Labels: []string{"cpu"}},
+		{Name: "frequency_mhz", Help: "CPU frequency in MHz.", Unit: "mhz", Labels: []string{"cpu"}},
+		{Name: "time_percent", Help: "Share of time spent in the state in percent, the cpu label is total for all CPUs together.", Unit: "percent", Labels: []string{"cpu", "state"}},
+		{Name: "context_switches_per_second", Help: "Context switches per second."},
+		{Name: "interrupts_per_second", Help: "Interrupts per second."},
+		{Name: "physical_cores", Help: "Number of physical cores."},
+		{Name: "logical_cpus", Help: "Number of logical CPUs."},
+		{Name: "socket_info", Help: "CPU model of the socket, always 1.", Labels: []string{"socket", "vendor", "model_name", "family", "model", "stepping"}},
+		{Name: "scaling_min_mhz", Help: "Minimum frequency allowed to the governor in MHz.", Unit: "mhz", Labels: []string{"cpu"}},
+		{Name: "scaling_max_mhz", Help: "Maximum frequency allowed to the governor in MHz.", Unit: "mhz", Labels: []string{"cpu"}},
+		{Name: "hardware_max_mhz", Help: "Maximum frequency of the CPU in MHz.", Unit: "mhz", Labels: []string{"cpu"}},
+		{Name: "governor_info", Help: "Scaling governor and energy performance preference, always 1.", Labels: []string{"cpu", "governor", "energy_performance_preference"}},
+		{Name: "core_throttles", Help: "Times the core was thermally throttled.", Type: collector.Counter, Labels: []string{"cpu"}},
+		{Name: "package_throttles", Help: "Times the package of the core was thermally throttled.", Type: collector.Counter, Labels: []string{"cpu"}},
+	}
+	return collector.New(CollectorCpu, "CPU usage, frequency and time breakdown per logical CPU", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		cpuInfo, stats, err := m.GetCpuInfoAndStats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var samples []collector.Sample
+		for _, info := range cpuInfo {
+			labels := map[string]string{"cpu": info.ID}
+			samples = append(samples,
+				collector.Sample{Metric: "usage_percent", Labels: labels, Value: info.UsagePercent},
+				collector.Sample{Metric: "frequency_mhz", Labels: labels, Value: info.FrequencyMhz},
+			)
+			samples = append(samples, cpuTimesSamples(info.ID, info.Times)...)
+		}
+		samples = append(samples, cpuTimesSamples("total", stats.Total)...)
+		samples = append(samples,
+			collector.Sample{Metric: "context_switches_per_second", Value: stats.ContextSwitchesPerSec},
+			collector.Sample{Metric: "interrupts_per_second", Value: stats.InterruptsPerSec},
+		)
+		topology, err := m.GetCpuTopology(ctx)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples,
+			collector.Sample{Metric: "physical_cores", Value: float64(topology.PhysicalCores)},
+			collector.Sample{Metric: "logical_cpus", Value: float64(topology.LogicalCpus)},
+		)
+		for _, socket := range topology.Sockets {
+			samples = append(samples, collector.Sample{Metric: "socket_info", Labels: map[string]string{
+				"socket":     socket.ID,
+				"vendor":     socket.VendorID,
+				"model_name": socket.ModelName,
+				"family":     socket.Family,
+				"model":      socket.Model,
+				"stepping":   strconv.Itoa(int(socket.Stepping)),
+			}, Value: 1})
+		}
+		frequencies, err := m.GetCpuFrequencies(ctx)
+		if err != nil && !errors.Is(err, metrics.ErrNoCpuFreq) {
+			return nil, err
+		}
+		for _, frequency := range frequencies {
+			labels := map[string]string{"cpu": frequency.Cpu}
+			samples = append(samples,
+				collector.Sample{Metric: "scaling_min_mhz", Labels: labels, Value: frequency.MinMhz},
+				collector.Sample{Metric: "scaling_max_mhz", Labels: labels, Value: frequency.MaxMhz},
+				collector.Sample{Metric: "hardware_max_mhz", Labels: labels, Value: frequency.HardwareMaxMhz},
+				collector.Sample{Metric: "governor_info", Labels: map[string]string{
+					"cpu":                           frequency.Cpu,
+					"governor":                      frequency.Governor,
+					"energy_performance_preference": frequency.EnergyPerformancePreference,
+				}, Value: 1},
+				collector.Sample{Metric: "core_throttles", Labels: labels, Value: float64(frequency.CoreThrottleCount)},
+				collector.Sample{Metric: "package_throttles", Labels: labels, Value: float64(frequency.PackageThrottleCount)},
+			)
+		}
+		return samples, nil
+	})
+}
+
+func cpuTimesSamples(cpu string, times models.CpuTimes) []collector.Sample {
+	states := []struct {
+		name  string
+		value float64
+	}{
+		{"user", times.User},
+		{"system", times.System},
+		{"idle", times.Idle},
+		{"nice", times.Nice},
+		{"iowait", times.Iowait},
+		{"irq", times.Irq},
+		{"softirq", times.Softirq},
+		{"steal", times.Steal},
+		{"guest", times.Guest},
+		{"guest_nice", times.GuestNice},
+	}
+	samples := make([]collector.Sample, len(states))
+	for i, state := range states {
+		samples[i] = collector.Sample{Metric: "time_percent", Labels: map[string]string{"cpu": cpu, "state": state.name}, Value: state.value}
+	}
+	return samples
+}
+
+func tempCollector(m *Metrigo) collector.Collector {
+	descriptors := []collector.Descriptor{
+		{Name: "celsius", Help: "Temperature sensor value in degrees Celsius.", Unit: "celsius", Labels: []string{"sensor", "chip"}},
+		{Name: "high_celsius", Help: "High threshold reported by the chip in degrees Celsius.", Unit: "celsius", Labels: []string{"sensor", "chip"}},
+		{Name: "critical_celsius", Help: "Critical threshold reported by the chip in degrees Celsius.", Unit: "celsius", Labels: []string{"sensor", "chip"}},
+	}
+	return collector.New(CollectorTemp, "Temperature sensors", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		temps, err := m.GetTemperatures(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var samples []collector.Sample
+		for _, temp := range temps {
+			labels := map[string]string{"sensor": temp.Key, "chip": temp.Chip}
+			samples = append(samples, collector.Sample{Metric: "celsius", Labels: labels, Value: temp.Value})
+			if temp.High > 0 {
+				samples = append(samples, collector.Sample{Metric: "high_celsius", Labels: labels, Value: temp.High})
+			}
+			if temp.Critical > 0 {
+				samples = append(samples, collector.Sample{Metric: "critical_celsius", Labels: labels, Value: temp.Critical})
+			}
+		}
+		return samples, nil
+	})
+}
+
+func memCollector(m *Metrigo) collector.Collector {
+	descriptors := []collector.Descriptor{
+		{Name: "used_bytes", Help: "Used virtual memory in bytes.", Unit: "bytes"},
+		{Name: "total_bytes", Help: "Total virtual memory in bytes.", Unit: "bytes"},
+	}
+	return collector.New(CollectorMem, "Virtual memory usage", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		memoryUsage, err := m.GetMemoryUsage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []collector.Sample{
+			{Metric: "used_bytes", Value: float64(memoryUsage.UsedB)},
+			{Metric: "total_bytes", Value: float64(memoryUsage.TotalB)},
+		}, nil
+	})
+}
+
+func hostCollector(m *Metrigo) collector.Collector {
+	descriptors := []collector.Descriptor{
+		{Name: "uptime_seconds", Help: "Host uptime in seconds.", Unit: "seconds", Type: collector.Counter},
+		{Name: "boot_time_seconds", Help: "Unix time the host booted at in seconds.", Unit: "seconds"},
+		{Name: "processes", Help: "Number of processes."},
+		{Name: "logged_in_users", Help: "Number of logged-in user sessions."},
+		{Name: "info", Help: "Host information, always 1.", Labels: []string{"hostname", "os", "platform", "platform_version", "kernel_version", "kernel_arch", "virtualization_system", "virtualization_role", "host_id", "timezone"}},
+	}
+	return collector.New(CollectorHost, "Host information and uptime", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		hostInfo, err := m.GetHostInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []collector.Sample{
+			{Metric: "uptime_seconds", Value: float64(hostInfo.Uptime)},
+			{Metric: "boot_time_seconds", Value: float64(hostInfo.BootTime)},
+			{Metric: "processes", Value: float64(hostInfo.Procs)},
+			{Metric: "logged_in_users", Value: float64(len(hostInfo.Users))},
+			{Metric: "info", Labels: map[string]string{
+				"hostname":              hostInfo.Hostname,
+				"os":                    hostInfo.OS,
+				"platform":              hostInfo.Platform,
+				"platform_version":      hostInfo.PlatformVersion,
+				"kernel_version":        hostInfo.KernelVersion,
+				"kernel_arch":           hostInfo.KernelArch,
+				"virtualization_system": hostInfo.VirtualizationSystem,
+				"virtualization_role":   hostInfo.VirtualizationRole,
+				"host_id":               hostInfo.HostID,
+				"timezone":              hostInfo.Timezone,
+			}, Value: 1},
+		}, nil
+	})
+}
+
+func netCollector(m *Metrigo) collector.Collector {
+	descriptors := []collector.Descriptor{
+		{Name: "mtu_bytes", Help: "Interface MTU in bytes.", Unit: "bytes", Labels: []string{"interface", "index"}},
+		{Name: "addresses", Help: "Number of addresses assigned to the interface.", Labels: []string{"interface", "index"}},
+	}
+	return collector.New(CollectorNet, "Network interfaces", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		netInterfaces, err := m.GetNetInterfaces(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var samples []collector.Sample
+		for _, iface := range netInterfaces {
+			labels := map[string]string{"interface": iface.Name, "index": strconv.Itoa(iface.Index)}
+			samples = append(samples,
+				collector.Sample{Metric: "mtu_bytes", Labels: labels, Value: float64(iface.MTU)},
+				collector.Sample{Metric: "addresses", Labels: labels, Value: float64(len(iface.Addressess))},
+			)
+		}
+		return samples, nil
+	})
+}
+
+func diskCollector(m *Metrigo) collector.Collector {
+	descriptors := []collector.Descriptor{
+		{Name: "used_bytes", Help: "Used disk space in bytes.", Unit: "bytes", Labels: []string{"path", "fstype"}},
+		{Name: "total_bytes", Help: "Total disk space in bytes.", Unit: "bytes", Labels: []string{"path", "fstype"}},
+		{Name: "used_percent", Help: "Used disk space in percent.", Unit: "percent", Labels: []string{"path", "fstype"}},
+	}
+	return collector.New(CollectorDisk, "Disk space usage per mounted partition", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		disks, err := m.GetDisksUsage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var samples []collector.Sample
+		for _, disk := range disks {
+			labels := map[string]string{"path": disk.Path, "fstype": disk.Fstype}
+			samples = append(samples,
+				collector.Sample{Metric: "used_bytes", Labels: labels, Value: float64(disk.UsedB)},
+				collector.Sample{Metric: "total_bytes", Labels: labels, Value: float64(disk.TotalB)},
+				collector.Sample{Metric: "used_percent", Labels: labels, Value: disk.UsedPercent},
+			)
+		}
+		return samples, nil
+	})
+}
+
+func loadCollector(m *Metrigo) collector.Collector {
+	descriptors := []collector.Descriptor{
+		{Name: "load1", Help: "1 minute load average."},
+		{Name: "load5", Help: "5 minute load average."},
+		{Name: "load15", Help: "15 minute load average."},
+	}
+	return collector.New(CollectorLoad, "System load averages", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		loadAverage, err := m.GetLoadAverage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []collector.Sample{
+			{Metric: "load1", Value: loadAverage.Load1},
+			{Metric: "load5", Value: loadAverage.Load5},
+			{Metric: "load15", Value: loadAverage.Load15},
+		}, nil
+	})
+}
+
+func cgroupCollector(m *Metrigo) collector.Collector {
+	labels := []string{"cgroup"}
+	ioLabels := []string{"cgroup", "device"}
+	descriptors := []collector.Descriptor{
+		{Name: "cpu_quota_cores", Help: "CPU limit in cores, not reported when unlimited.", Labels: labels},
+		{Name: "cpu_usage_seconds", Help: "CPU time consumed in seconds.", Unit: "seconds", Type: collector.Counter, Labels: labels},
+		{Name: "cpu_periods", Help: "Elapsed CPU enforcement periods.", Type: collector.Counter, Labels: labels},
+		{Name: "cpu_throttled_periods", Help: "Enforcement periods in which the cgroup was throttled.", Type: collector.Counter, Labels: labels},
+		{Name: "cpu_throttled_seconds", Help: "Time the cgroup was throttled in seconds.", Unit: "seconds", Type: collector.Counter, Labels: labels},
+		{Name: "memory_limit_bytes", Help: "Memory limit in bytes, not reported when unlimited.", Unit: "bytes", Labels: labels},
+		{Name: "memory_usage_bytes", Help: "Memory usage in bytes.", Unit: "bytes", Labels: labels},
+		{Name: "memory_oom_events", Help: "Times the cgroup ran out of memory, 0 on cgroup v1.", Type: collector.Counter, Labels: labels},
+		{Name: "memory_oom_kill_events", Help: "Processes killed by the OOM killer.", Type: collector.Counter, Labels: labels},
+		{Name: "io_read_bytes", Help: "Bytes read per device.", Unit: "bytes", Type: collector.Counter, Labels: ioLabels},
+		{Name: "io_write_bytes", Help: "Bytes written per device.", Unit: "bytes", Type: collector.Counter, Labels: ioLabels},
+		{Name: "io_read_ops", Help: "Read operations per device.", Type: collector.Counter, Labels: ioLabels},
+		{Name: "io_write_ops", Help: "Write operations per device.", Type: collector.Counter, Labels: ioLabels},
+	}
+	return collector.New(CollectorCgroup, "cgroup v2/v1 CPU, memory and I/O of the agent's or a configured cgroup", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		stats, err := m.GetCgroupStats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		labels := map[string]string{"cgroup": stats.Path}
+		var samples []collector.Sample
+		if stats.Cpu.QuotaCores > 0 {
+			samples = append(samples, collector.Sample{Metric: "cpu_quota_cores", Labels: labels, Value: stats.Cpu.QuotaCores})
+		}
+		samples = append(samples,
+			collector.Sample{Metric: "cpu_usage_seconds", Labels: labels, Value: stats.Cpu.UsageSeconds},
+			collector.Sample{Metric: "cpu_periods", Labels: labels, Value: float64(stats.Cpu.Periods)},
+			collector.Sample{Metric: "cpu_throttled_periods", Labels: labels, Value: float64(stats.Cpu.ThrottledPeriods)},
+			collector.Sample{Metric: "cpu_throttled_seconds", Labels: labels, Value: stats.Cpu.ThrottledSeconds},
+		)
+		if stats.Memory.LimitB > 0 {
+			samples = append(samples, collector.Sample{Metric: "memory_limit_bytes", Labels: labels, Value: float64(stats.Memory.LimitB)})
+		}
+		samples = append(samples,
+			collector.Sample{Metric: "memory_usage_bytes", Labels: labels, Value: float64(stats.Memory.UsageB)},
+			collector.Sample{Metric: "memory_oom_events", Labels: labels, Value: float64(stats.Memory.OOMEvents)},
+			collector.Sample{Metric: "memory_oom_kill_events", Labels: labels, Value: float64(stats.Memory.OOMKillEvents)},
+		)
+		for _, io := range stats.IO {
+			ioLabels := map[string]string{"cgroup": stats.Path, "device": io.Device}
+			samples = append(samples,
+				collector.Sample{Metric: "io_read_bytes", Labels: ioLabels, Value: float64(io.ReadB)},
+				collector.Sample{Metric: "io_write_bytes", Labels: ioLabels, Value: float64(io.WriteB)},
+				collector.Sample{Metric: "io_read_ops", Labels: ioLabels, Value: float64(io.ReadOps)},
+				collector.Sample{Metric: "io_write_ops", Labels: ioLabels, Value: float64(io.WriteOps)},
+			)
+		}
+		return samples, nil
+	})
+}
+
+func containersCollector(m *Metrigo) collector.Collector {
+	labels := []string{"id", "name", "image"}
+	descriptors := []collector.Descriptor{
+		{Name: "info", Help: "Container metadata with its labels prefixed with label_, always 1.", Labels: labels},
+		{Name: "cpu_percent", Help: "CPU usage in percent of a single CPU.", Unit: "percent", Labels: labels},
+		{Name: "memory_usage_bytes", Help: "Memory usage without page cache in bytes.", Unit: "bytes", Labels: labels},
+		{Name: "memory_limit_bytes", Help: "Memory limit in bytes.", Unit: "bytes", Labels: labels},
+		{Name: "network_rx_bytes", Help: "Bytes received on all interfaces.", Unit: "bytes", Type: collector.Counter, Labels: labels},
+		{Name: "network_tx_bytes", Help: "Bytes sent on all interfaces.", Unit: "bytes", Type: collector.Counter, Labels: labels},
+		{Name: "block_read_bytes", Help: "Bytes read from block devices.", Unit: "bytes", Type: collector.Counter, Labels: labels},
+		{Name: "block_write_bytes", Help: "Bytes written to block devices.", Unit: "bytes", Type: collector.Counter, Labels: labels},
+	}
+	return collector.New(CollectorContainers, "Running containers of the local Docker compatible runtime", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		containers, err := m.GetContainers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var samples []collector.Sample
+		for _, container := range containers {
+			labels := map[string]string{"id": container.ID, "name": container.Name, "image": container.Image}
+			infoLabels := maps.Clone(labels)
+			for key, value := range container.Labels {
+				infoLabels["label_"+sanitizeLabel(key)] = value
+			}
+			samples = append(samples,
+				collector.Sample{Metric: "info", Labels: infoLabels, Value: 1},
+				collector.Sample{Metric: "cpu_percent", Labels: labels, Value: container.CpuPercent},
+				collector.Sample{Metric: "memory_usage_bytes", Labels: labels, Value: float64(container.MemoryUsageB)},
+				collector.Sample{Metric: "memory_limit_bytes", Labels: labels, Value: float64(container.MemoryLimitB)},
+				collector.Sample{Metric: "network_rx_bytes", Labels: labels, Value: float64(container.NetRxB)},
+				collector.Sample{Metric: "network_tx_bytes", Labels: labels, Value: float64(container.NetTxB)},
+				collector.Sample{Metric: "block_read_bytes", Labels: labels, Value: float64(container.BlockReadB)},
+				collector.Sample{Metric: "block_write_bytes", Labels: labels, Value: float64(container.BlockWriteB)},
+			)
+		}
+		return samples, nil
+	})
+}
+
+// hwmonMetrics maps the hwmon sensor types to the metrics of their values.
+var hwmonMetrics = map[string]string{
+	metrics.HwmonFan:     "fan_rpm",
+	metrics.HwmonVoltage: "voltage_volts",
+	metrics.HwmonPower:   "power_watts",
+	metrics.HwmonCurrent: "current_amps",
+}
+
+func hwmonCollector(m *Metrigo) collector.Collector {
+	labels := []string{"chip", "sensor", "label"}
+	descriptors := []collector.Descriptor{
+		{Name: "fan_rpm", Help: "Fan speed in RPM.", Unit: "rpm", Labels: labels},
+		{Name: "voltage_volts", Help: "Voltage in volts.", Unit: "volts", Labels: labels},
+		{Name: "power_watts", Help: "Power in watts.", Unit: "watts", Labels: labels},
+		{Name: "current_amps", Help: "Current in amperes.", Unit: "amperes", Labels: labels},
+		{Name: "limit", Help: "Min, max and crit limits reported by the chip, in the unit of the sensor.", Labels: []string{"chip", "sensor", "label", "type", "limit"}},
+		{Name: "alarm", Help: "1 when the chip raised an alarm for the sensor.", Labels: []string{"chip", "sensor", "label", "type"}},
+	}
+	return collector.New(CollectorHwmon, "Fan, voltage, power and current sensors of the hardware monitoring chips", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		sensors, err := m.GetHwmonSensors(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var samples []collector.Sample
+		for _, sensor := range sensors {
+			labels := map[string]string{"chip": sensor.Chip, "sensor": sensor.Sensor, "label": sensor.Label}
+			typeLabels := maps.Clone(labels)
+			typeLabels["type"] = sensor.Type
+			samples = append(samples, collector.Sample{Metric: hwmonMetrics[sensor.Type], Labels: labels, Value: sensor.Value})
+			for _, limit := range []struct {
+				name  string
+				value *float64
+			}{{"min", sensor.Min}, {"max", sensor.Max}, {"crit", sensor.Crit}} {
+				if limit.value == nil {
+					continue
+				}
+				limitLabels := maps.Clone(typeLabels)
+				limitLabels["limit"] = limit.name
+				samples = append(samples, collector.Sample{Metric: "limit", Labels: limitLabels, Value: *limit.value})
+			}
+			alarm := 0.0
+			if sensor.Alarm {
+				alarm = 1
+			}
+			samples = append(samples, collector.Sample{Metric: "alarm", Labels: typeLabels, Value: alarm})
+		}
+		return samples, nil
+	})
+}
+
+type pressureKind struct {
+	kind  string
+	stall models.PressureStall
+}
+
+func psiCollector(m *Metrigo) collector.Collector {
+	descriptors := []collector.Descriptor{
+		{Name: "stall_percent", Help: "Share of time tasks were stalled on the resource over the window in percent, the cgroup label is empty for the host.", Unit: "percent", Labels: []string{"cgroup", "resource", "kind", "window"}},
+		{Name: "stall_seconds", Help: "Total time tasks were stalled on the resource in seconds.", Unit: "seconds", Type: collector.Counter, Labels: []string{"cgroup", "resource", "kind"}},
+	}
+	return collector.New(CollectorPsi, "Pressure Stall Information of cpu, memory and io for the host and the agent's or a configured cgroup", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		pressure, err := m.GetPressure(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var samples []collector.Sample
+		for _, scope := range pressure {
+			for _, resource := range scope.Resources {
+				stalls := []pressureKind{{"some", resource.Some}}
+				if resource.HasFull {
+					stalls = append(stalls, pressureKind{"full", resource.Full})
+				}
+				for _, s := range stalls {
+					labels := map[string]string{"cgroup": scope.Cgroup, "resource": resource.Resource, "kind": s.kind}
+					for _, window := range []struct {
+						name  string
+						value float64
+					}{{"10s", s.stall.Avg10}, {"60s", s.stall.Avg60}, {"300s", s.stall.Avg300}} {
+						windowLabels := maps.Clone(labels)
+						windowLabels["window"] = window.name
+						samples = append(samples, collector.Sample{Metric: "stall_percent", Labels: windowLabels, Value: window.value})
+					}
+					samples = append(samples, collector.Sample{Metric: "stall_seconds", Labels: labels, Value: s.stall.TotalSeconds})
+				}
+			}
+		}
+		return samples, nil
+	})
+}
+
+// protocolCounters are the exported counters of the network stack.
+var protocolCounters = []struct {
+	name  string
+	help  string
+	value func(models.ProtocolCounters) uint64
+}{
+	{"tcp_active_opens", "TCP connections opened by the host.", func(c models.ProtocolCounters) uint64 { return c.TcpActiveOpens }},
+	{"tcp_passive_opens", "TCP connections accepted by the host.", func(c models.ProtocolCounters) uint64 { return c.TcpPassiveOpens }},
+	{"tcp_attempt_fails", "Failed TCP connection attempts.", func(c models.ProtocolCounters) uint64 { return c.TcpAttemptFails }},
+	{"tcp_estab_resets", "Established TCP connections reset.", func(c models.ProtocolCounters) uint64 { return c.TcpEstabResets }},
+	{"tcp_in_segments", "TCP segments received.", func(c models.ProtocolCounters) uint64 { return c.TcpInSegs }},
+	{"tcp_out_segments", "TCP segments sent.", func(c models.ProtocolCounters) uint64 { return c.TcpOutSegs }},
+	{"tcp_retransmits", "TCP segments retransmitted.", func(c models.ProtocolCounters) uint64 { return c.TcpRetransSegs }},
+	{"tcp_in_errors", "TCP segments received with errors.", func(c models.ProtocolCounters) uint64 { return c.TcpInErrs }},
+	{"tcp_out_resets", "TCP segments sent with the RST flag.", func(c models.ProtocolCounters) uint64 { return c.TcpOutRsts }},
+	{"tcp_listen_overflows", "Times the accept queue of a listening socket overflowed.", func(c models.ProtocolCounters) uint64 { return c.TcpListenOverflows }},
+	{"tcp_listen_drops", "Connection requests dropped by listening sockets.", func(c models.ProtocolCounters) uint64 { return c.TcpListenDrops }},
+	{"udp_in_datagrams", "UDP datagrams received.", func(c models.ProtocolCounters) uint64 { return c.UdpInDatagrams }},
+	{"udp_out_datagrams", "UDP datagrams sent.", func(c models.ProtocolCounters) uint64 { return c.UdpOutDatagrams }},
+	{"udp_no_ports", "UDP datagrams received for a port without a socket.", func(c models.ProtocolCounters) uint64 { return c.UdpNoPorts }},
+	{"udp_in_errors", "UDP datagrams that could not be delivered.", func(c models.ProtocolCounters) uint64 { return c.UdpInErrors }},
+	{"udp_receive_buffer_errors", "UDP datagrams dropped because the receive buffer was full.", func(c models.ProtocolCounters) uint64 { return c.UdpRcvbufErrors }},
+	{"udp_send_buffer_errors", "UDP datagrams dropped because the send buffer was full.", func(c models.ProtocolCounters) uint64 { return c.UdpSndbufErrors }},
+}
+
+func connsCollector(m *Metrigo) collector.Collector {
+	descriptors := []collector.Descriptor{
+		{Name: "connections", Help: "Sockets by protocol and state, UNCONN for UDP sockets without a remote address.", Labels: []string{"protocol", "state"}},
+		{Name: "tcp_established", Help: "TCP connections currently established."},
+		{Name: "listener_info", Help: "Listening TCP or unconnected UDP socket, always 1, the pid label is empty when the owner is unknown.", Labels: []string{"protocol", "address", "port", "pid"}},
+	}
+	for _, counter := range protocolCounters {
+		descriptors = append(descriptors, collector.Descriptor{Name: counter.name, Help: counter.help, Type: collector.Counter})
+	}
+	return collector.New(CollectorConns, "TCP and UDP sockets by state, listeners with their process and network stack counters", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		stats, err := m.GetSocketStats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var samples []collector.Sample
+		for _, count := range CountConnections(stats.Connections) {
+			samples = append(samples, collector.Sample{Metric: "connections", Labels: map[string]string{"protocol": count.Protocol, "state": count.State}, Value: float64(count.Count)})
+		}
+		if stats.HasCounters {
+			samples = append(samples, collector.Sample{Metric: "tcp_established", Value: float64(stats.Counters.TcpCurrEstab)})
+		}
+		// Sockets bound with SO_REUSEPORT or shared by the workers of a process are reported once.
+		seen := make(map[models.Connection]bool)
+		for _, conn := range stats.Connections {
+			if !IsListener(conn) || seen[conn] {
+				continue
+			}
+			seen[conn] = true
+			pid := ""
+			if conn.Pid != 0 {
+				pid = strconv.Itoa(int(conn.Pid))
+			}
+			labels := map[string]string{"protocol": conn.Protocol, "address": conn.LocalAddr, "port": strconv.Itoa(int(conn.LocalPort)), "pid": pid}
+			samples = append(samples, collector.Sample{Metric: "listener_info", Labels: labels, Value: 1})
+		}
+		if stats.HasCounters {
+			for _, counter := range protocolCounters {
+				samples = append(samples, collector.Sample{Metric: counter.name, Value: float64(counter.value(stats.Counters))})
+			}
+		}
+		return samples, nil
+	})
+}
+
+func systemdCollector(m *Metrigo) collector.Collector {
+	descriptors := []collector.Descriptor{
+		{Name: "failed_units", Help: "Number of units in the failed state."},
+		{Name: "unit_info", Help: "State of the unit, always 1.", Labels: []string{"unit", "load_state", "active_state", "sub_state"}},
+		{Name: "unit_active", Help: "1 when the unit is active.", Labels: []string{"unit"}},
+		{Name: "unit_failed", Help: "1 when the unit is in the failed state.", Labels: []string{"unit"}},
+		{Name: "unit_restarts", Help: "Automatic restarts of the service.", Type: collector.Counter, Labels: []string{"unit"}},
+		{Name: "unit_memory_bytes", Help: "Memory used by the unit in bytes, when memory accounting is enabled.", Unit: "bytes", Labels: []string{"unit"}},
+		{Name: "unit_cpu_seconds", Help: "CPU time consumed by the unit in seconds, when CPU accounting is enabled.", Unit: "seconds", Type: collector.Counter, Labels: []string{"unit"}},
+	}
+	return collector.New(CollectorSystemd, "State, restarts and resource accounting of the systemd units", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		units, err := m.GetSystemdUnits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		samples := []collector.Sample{{Metric: "failed_units", Value: float64(len(FailedSystemdUnits(units)))}}
+		for _, unit := range units {
+			labels := map[string]string{"unit": unit.Name}
+			active, failed := 0.0, 0.0
+			if unit.ActiveState == "active" {
+				active = 1
+			}
+			if unit.ActiveState == SystemdUnitFailed {
+				failed = 1
+			}
+			samples = append(samples,
+				collector.Sample{Metric: "unit_info", Labels: map[string]string{"unit": unit.Name, "load_state": unit.LoadState, "active_state": unit.ActiveState, "sub_state": unit.SubState}, Value: 1},
+				collector.Sample{Metric: "unit_active", Labels: labels, Value: active},
+				collector.Sample{Metric: "unit_failed", Labels: labels, Value: failed},
+				collector.Sample{Metric: "unit_restarts", Labels: labels, Value: float64(unit.Restarts)},
+				collector.Sample{Metric: "unit_memory_bytes", Labels: labels, Value: float64(unit.MemoryB)},
+				collector.Sample{Metric: "unit_cpu_seconds", Labels: labels, Value: unit.CpuSeconds},
+			)
+		}
+		return samples, nil
+	})
+}
+
+// limitsTopProcesses is the number of processes closest to their file descriptor limit exported by the limits collector.
+const limitsTopProcesses = 10
+
+func limitsCollector(m *Metrigo) collector.Collector {
+	processLabels := []string{"pid", "name"}
+	descriptors := []collector.Descriptor{
+		{Name: "file_handles_open", Help: "Open file handles of the host."},
+		{Name: "file_handles_max", Help: "Maximum number of file handles, fs.file-max."},
+		{Name: "inodes_allocated", Help: "Inodes allocated by the kernel."},
+		{Name: "inodes_free", Help: "Allocated inodes not in use."},
+		{Name: "conntrack_entries", Help: "Entries of the connection tracking table, when nf_conntrack is loaded."},
+		{Name: "conntrack_max", Help: "Size of the connection tracking table, nf_conntrack_max."},
+		{Name: "pids", Help: "Pids in use by processes and threads."},
+		{Name: "pid_max", Help: "Highest pid, kernel.pid_max."},
+		{Name: "threads_max", Help: "Maximum number of threads, kernel.threads-max."},
+		{Name: "entropy_available_bits", Help: "Entropy available in the kernel pool in bits.", Unit: "bits"},
+		{Name: "entropy_pool_size_bits", Help: "Size of the kernel entropy pool in bits.", Unit: "bits"},
+		{Name: "max_process_fd_usage_percent", Help: "Highest share of the open files limit used by a process in percent.", Unit: "percent"},
+		{Name: "process_open_fds", Help: "Open file descriptors of the processes closest to their limit.", Labels: processLabels},
+		{Name: "process_max_fds", Help: "Soft limit of open file descriptors of the process, 0 when unlimited.", Labels: processLabels},
+	}
+	return collector.New(CollectorLimits, "File handles, inodes, conntrack, pids and entropy against their kernel limits, and the file descriptors of the processes", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		limits, err := m.GetKernelLimits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		samples := []collector.Sample{
+			{Metric: "file_handles_open", Value: float64(limits.OpenFiles)},
+			{Metric: "file_handles_max", Value: float64(limits.MaxFiles)},
+			{Metric: "inodes_allocated", Value: float64(limits.Inodes)},
+			{Metric: "inodes_free", Value: float64(limits.FreeInodes)},
+			{Metric: "pids", Value: float64(limits.Pids)},
+			{Metric: "pid_max", Value: float64(limits.PidMax)},
+			{Metric: "threads_max", Value: float64(limits.ThreadsMax)},
+			{Metric: "entropy_available_bits", Value: float64(limits.EntropyAvailable)},
+			{Metric: "entropy_pool_size_bits", Value: float64(limits.EntropyPoolSize)},
+		}
+		if limits.HasConntrack {
+			samples = append(samples,
+				collector.Sample{Metric: "conntrack_entries", Value: float64(limits.ConntrackEntries)},
+				collector.Sample{Metric: "conntrack_max", Value: float64(limits.ConntrackMax)},
+			)
+		}
+		maxUsage := 0.0
+		if len(limits.Processes) > 0 {
+			maxUsage = FdUsagePercent(limits.Processes[0])
+		}
+		samples = append(samples, collector.Sample{Metric: "max_process_fd_usage_percent", Value: maxUsage})
+		for _, process := range limits.Processes[:min(len(limits.Processes), limitsTopProcesses)] {
+			labels := map[string]string{"pid": strconv.Itoa(int(process.Pid)), "name": process.Name}
+			samples = append(samples,
+				collector.Sample{Metric: "process_open_fds", Labels: labels, Value: float64(process.OpenFds)},
+				collector.Sample{Metric: "process_max_fds", Labels: labels, Value: float64(process.SoftLimit)},
+			)
+		}
+		return samples, nil
+	})
+}
+
+func procGroupsCollector(m *Metrigo) collector.Collector {
+	groupLabels := []string{"group"}
+	descriptors := []collector.Descriptor{
+		{Name: "processes", Help: "Processes of the group.", Labels: groupLabels},
+		{Name: "cpu_usage_percent", Help: "CPU usage of the group's processes in percent of one CPU.", Unit: "percent", Labels: groupLabels},
+		{Name: "memory_rss_bytes", Help: "Resident memory of the group's processes.", Unit: "bytes", Labels: groupLabels},
+		{Name: "io_read_bytes_per_second", Help: "Bytes read from storage per second by the group's processes.", Labels: groupLabels},
+		{Name: "io_write_bytes_per_second", Help: "Bytes written to storage per second by the group's processes.", Labels: groupLabels},
+		{Name: "threads", Help: "Threads of the group's processes.", Labels: groupLabels},
+		{Name: "open_fds", Help: "Open file descriptors of the group's processes.", Labels: groupLabels},
+	}
+	return collector.New(CollectorProcGroups, "CPU, memory, I/O, threads and file descriptors of the configured process groups", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		groups, err := m.GetProcessGroups(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var samples []collector.Sample
+		for _, group := range groups {
+			labels := map[string]string{"group": group.Name}
+			samples = append(samples,
+				collector.Sample{Metric: "processes", Labels: labels, Value: float64(len(group.Pids))},
+				collector.Sample{Metric: "cpu_usage_percent", Labels: labels, Value: group.CpuPercent},
+				collector.Sample{Metric: "memory_rss_bytes", Labels: labels, Value: float64(group.RssB)},
+				collector.Sample{Metric: "io_read_bytes_per_second", Labels: labels, Value: group.ReadBps},
+				collector.Sample{Metric: "io_write_bytes_per_second", Labels: labels, Value: group.WriteBps},
+				collector.Sample{Metric: "threads", Labels: labels, Value: float64(group.Threads)},
+				collector.Sample{Metric: "open_fds", Labels: labels, Value: float64(group.OpenFds)},
+			)
+		}
+		return samples, nil
+	})
+}
+
+func memInfoCollector(m *Metrigo) collector.Collector {
+	poolLabels := []string{"size"}
+	nodeLabels := []string{"node"}
+	nodePoolLabels := []string{"node", "size"}
+	descriptors := []collector.Descriptor{
+		{Name: "total_bytes", Help: "Total usable memory, MemTotal.", Unit: "bytes"},
+		{Name: "free_bytes", Help: "Unused memory, MemFree.", Unit: "bytes"},
+		{Name: "available_bytes", Help: "Memory available for new allocations without swapping, MemAvailable.", Unit: "bytes"},
+		{Name: "buffers_bytes", Help: "Memory used by block device buffers.", Unit: "bytes"},
+		{Name: "cached_bytes", Help: "Memory used by the page cache.", Unit: "bytes"},
+		{Name: "swap_total_bytes", Help: "Total swap space.", Unit: "bytes"},
+		{Name: "swap_free_bytes", Help: "Unused swap space.", Unit: "bytes"},
+		{Name: "dirty_bytes", Help: "Memory waiting to be written back to storage.", Unit: "bytes"},
+		{Name: "writeback_bytes", Help: "Memory being written back to storage.", Unit: "bytes"},
+		{Name: "slab_bytes", Help: "Kernel slab memory.", Unit: "bytes"},
+		{Name: "slab_reclaimable_bytes", Help: "Kernel slab memory that can be reclaimed, e.g. the dentry and inode caches.", Unit: "bytes"},
+		{Name: "slab_unreclaimable_bytes", Help: "Kernel slab memory that cannot be reclaimed.", Unit: "bytes"},
+		{Name: "committed_as_bytes", Help: "Memory allocated by processes, Committed_AS.", Unit: "bytes"},
+		{Name: "commit_limit_bytes", Help: "Memory that can be allocated when overcommit is disabled, CommitLimit.", Unit: "bytes"},
+		{Name: "anon_hugepages_bytes", Help: "Anonymous memory backed by transparent hugepages.", Unit: "bytes"},
+		{Name: "hugepages_total", Help: "Hugepages of the pool.", Labels: poolLabels},
+		{Name: "hugepages_free", Help: "Hugepages of the pool not in use.", Labels: poolLabels},
+		{Name: "hugepages_reserved", Help: "Hugepages of the pool promised to mappings but not faulted in yet.", Labels: poolLabels},
+		{Name: "hugepages_surplus", Help: "Hugepages allocated above the size of the pool.", Labels: poolLabels},
+		{Name: "transparent_hugepages_info", Help: "Transparent hugepage settings, always 1.", Labels: []string{"enabled", "defrag"}},
+		{Name: "numa_node_total_bytes", Help: "Total memory of the NUMA node.", Unit: "bytes", Labels: nodeLabels},
+		{Name: "numa_node_free_bytes", Help: "Unused memory of the NUMA node.", Unit: "bytes", Labels: nodeLabels},
+		{Name: "numa_node_hugepages_total", Help: "Hugepages of the pool on the NUMA node.", Labels: nodePoolLabels},
+		{Name: "numa_node_hugepages_free", Help: "Hugepages of the pool on the NUMA node not in use.", Labels: nodePoolLabels},
+	}
+	return collector.New(CollectorMemInfo, "Detailed memory usage, hugepages, slab, writeback, overcommit and NUMA nodes", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		details, err := m.GetMemoryDetails(ctx)
+		if err != nil {
+			return nil, err
+		}
+		samples := []collector.Sample{
+			{Metric: "total_bytes", Value: float64(details.TotalB)},
+			{Metric: "free_bytes", Value: float64(details.FreeB)},
+			{Metric: "available_bytes", Value: float64(details.AvailableB)},
+			{Metric: "buffers_bytes", Value: float64(details.BuffersB)},
+			{Metric: "cached_bytes", Value: float64(details.CachedB)},
+			{Metric: "swap_total_bytes", Value: float64(details.SwapTotalB)},
+			{Metric: "swap_free_bytes", Value: float64(details.SwapFreeB)},
+			{Metric: "dirty_bytes", Value: float64(details.DirtyB)},
+			{Metric: "writeback_bytes", Value: float64(details.WritebackB)},
+			{Metric: "slab_bytes", Value: float64(details.SlabB)},
+			{Metric: "slab_reclaimable_bytes", Value: float64(details.SlabReclaimableB)},
+			{Metric: "slab_unreclaimable_bytes", Value: float64(details.SlabUnreclaimableB)},
+			{Metric: "committed_as_bytes", Value: float64(details.CommittedAsB)},
+			{Metric: "commit_limit_bytes", Value: float64(details.CommitLimitB)},
+			{Metric: "anon_hugepages_bytes", Value: float64(details.AnonHugePagesB)},
+		}
+		for _, pool := range details.HugePages {
+			labels := map[string]string{"size": strconv.FormatUint(pool.SizeB, 10)}
+			samples = append(samples,
+				collector.Sample{Metric: "hugepages_total", Labels: labels, Value: float64(pool.Total)},
+				collector.Sample{Metric: "hugepages_free", Labels: labels, Value: float64(pool.Free)},
+				collector.Sample{Metric: "hugepages_reserved", Labels: labels, Value: float64(pool.Reserved)},
+				collector.Sample{Metric: "hugepages_surplus", Labels: labels, Value: float64(pool.Surplus)},
+			)
+		}
+		if thp := details.TransparentHugePages; thp.Enabled != "" {
+			samples = append(samples, collector.Sample{
+				Metric: "transparent_hugepages_info",
+				Labels: map[string]string{"enabled": thp.Enabled, "defrag": thp.Defrag},
+				Value:  1,
+			})
+		}
+		for _, node := range details.NumaNodes {
+			nodeID := strconv.Itoa(node.ID)
+			labels := map[string]string{"node": nodeID}
+			samples = append(samples,
+				collector.Sample{Metric: "numa_node_total_bytes", Labels: labels, Value: float64(node.TotalB)},
+				collector.Sample{Metric: "numa_node_free_bytes", Labels: labels, Value: float64(node.FreeB)},
+			)
+			for _, pool := range node.HugePages {
+				poolLabels := map[string]string{"node": nodeID, "size": strconv.FormatUint(pool.SizeB, 10)}
+				samples = append(samples,
+					collector.Sample{Metric: "numa_node_hugepages_total", Labels: poolLabels, Value: float64(pool.Total)},
+					collector.Sample{Metric: "numa_node_hugepages_free", Labels: poolLabels, Value: float64(pool.Free)},
+				)
+			}
+		}
+		return samples, nil
+	})
+}
+
+func mountsCollector(m *Metrigo) collector.Collector {
+	mountLabels := []string{"path", "fstype"}
+	descriptors := []collector.Descriptor{
+		{Name: "read_only", Help: "Whether the filesystem is mounted read-only, 1 or 0.", Labels: mountLabels},
+		{Name: "stale", Help: "Whether the usage could not be read within the stat timeout, 1 or 0.", Labels: mountLabels},
+		{Name: "healthy", Help: "Whether the mount is healthy, 1 or 0. It is not when stale, remounted read-only or out of inodes.", Labels: mountLabels},
+		{Name: "inodes_total", Help: "Inodes of the filesystem.", Labels: mountLabels},
+		{Name: "inodes_free", Help: "Free inodes of the filesystem.", Labels: mountLabels},
+		{Name: "inodes_used_percent", Help: "Used inodes in percent.", Unit: "percent", Labels: mountLabels},
+		{Name: "unhealthy_mounts", Help: "Mounts that are stale, remounted read-only or out of inodes."},
+		{Name: "info", Help: "Mount information, always 1.", Labels: []string{"path", "fstype", "device", "options", "health"}},
+	}
+	return collector.New(CollectorMounts, "Read-only remounts, stale network mounts and inode usage of the mounted filesystems", descriptors, func(ctx context.Context) ([]collector.Sample, error) {
+		mounts, err := m.GetMounts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var samples []collector.Sample
+		for _, mount := range mounts {
+			labels := map[string]string{"path": mount.Path, "fstype": mount.Fstype}
+			infoLabels := map[string]string{"path": mount.Path, "fstype": mount.Fstype, "device": mount.Device,
+				"options": strings.Join(mount.Options, ","), "health": mount.Health}
+			samples = append(samples,
+				collector.Sample{Metric: "read_only", Labels: labels, Value: boolValue(mount.ReadOnly)},
+				collector.Sample{Metric: "stale", Labels: labels, Value: boolValue(mount.Stale)},
+				collector.Sample{Metric: "healthy", Labels: labels, Value: boolValue(mount.Health == MountHealthOK)},
+				collector.Sample{Metric: "info", Labels: infoLabels, Value: 1},
+			)
+			if mount.Stale || mount.Inodes == 0 {
+				// Stale mounts have no usage, and some filesystems such as btrfs report no inodes.
+				continue
+			}
+			samples = append(samples,
+				collector.Sample{Metric: "inodes_total", Labels: labels, Value: float64(mount.Inodes)},
+				collector.Sample{Metric: "inodes_free", Labels: labels, Value: float64(mount.InodesFree)},
+				collector.Sample{Metric: "inodes_used_percent", Labels: labels, Value: mount.InodesUsedPercent},
+			)
+		}
+		samples = append(samples, collector.Sample{Metric: "unhealthy_mounts", Value: float64(len(UnhealthyMounts(mounts)))})
+		return samples, nil
+	})
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// sanitizeLabel replaces the characters not allowed in metric label names, e.g. the dots of "com.docker.compose.service".
+func sanitizeLabel(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
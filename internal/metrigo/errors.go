@@ -0,0 +1,87 @@
+package metrigo
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/Matyjash/Metrigo/internal/metrics"
+)
+
+// ErrorKind classifies why a collection failed.
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	// KindNotFound means the host has none of the requested resources, e.g. no temperature sensors.
+	KindNotFound
+	// KindUnsupported means the metric is not available on the current platform.
+	KindUnsupported
+	KindTimeout
+	KindCanceled
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindNotFound:
+		return "NOT_FOUND"
+	case KindUnsupported:
+		return "UNSUPPORTED"
+	case KindTimeout:
+		return "TIMEOUT"
+	case KindCanceled:
+		return "CANCELED"
+	default:
+		return "INTERNAL"
+	}
+}
+
+// CollectorError is returned by the Metrigo getters when a collection fails.
+type CollectorError struct {
+	Collector string
+	Kind      ErrorKind
+	Err       error
+}
+
+func (e *CollectorError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CollectorError) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of a collection error, KindInternal for errors not returned by a collector.
+func KindOf(err error) ErrorKind {
+	var collectorErr *CollectorError
+	if errors.As(err, &collectorErr) {
+		return collectorErr.Kind
+	}
+	return KindInternal
+}
+
+func newCollectorError(collector string, err error) error {
+	if err == nil {
+		return nil
+	}
+	kind := KindInternal
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = KindTimeout
+	case errors.Is(err, context.Canceled):
+		kind = KindCanceled
+	case errors.Is(err, metrics.ErrNotSupported):
+		kind = KindUnsupported
+	case errors.Is(err, metrics.ErrNoCpus),
+		errors.Is(err, metrics.ErrNoTemperatureSensors),
+		errors.Is(err, metrics.ErrNoDiskPartitions),
+		errors.Is(err, metrics.ErrNoContainerRuntime),
+		errors.Is(err, metrics.ErrNoHwmonSensors),
+		errors.Is(err, metrics.ErrNoCpuFreq),
+		errors.Is(err, metrics.ErrNoSystemd),
+		errors.Is(err, metrics.ErrNoCgroup),
+		errors.Is(err, os.ErrNotExist):
+		kind = KindNotFound
+	}
+	return &CollectorError{Collector: collector, Kind: kind, Err: err}
+}
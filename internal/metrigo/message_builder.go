@@ -2,17 +2,40 @@ package metrigo
 
 import (
 	"fmt"
+	"net"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Matyjash/Metrigo/internal/collector"
 	"github.com/Matyjash/Metrigo/internal/models"
 )
 
 const (
 	cpuMessageHeader  = "CPU metrics:\n"
 	cpuMetricsMessage = "ID: %s, Usage: %s, Frequency: %s MHz"
+	cpuTimesRow       = "\tuser: %s%%, system: %s%%, idle: %s%%, iowait: %s%%, irq: %s%%, softirq: %s%%, steal: %s%%, guest: %s%%"
+	cpuTotalRow       = "Total:"
+	cpuRatesRow       = "Context switches/s: %s, Interrupts/s: %s"
+
+	cpuTopologyCountsRow = "Physical cores: %d, Logical CPUs: %d"
+	cpuSocketRow         = "Socket %s: %s"
+	cpuSocketModelRow    = "\tVendor: %s, Family: %s, Model: %s, Stepping: %d, Cache: %d KB"
+	cpuSocketFlagsRow    = "\tFlags: %s"
+	cpuCoreRow           = "\tCore %s: %s"
+
+	cpuFreqMessageHeader = "CPU frequencies:\n"
+	cpuFreqRow           = "%s: %s MHz (scaling %s-%s MHz, hardware %s-%s MHz)"
+	cpuFreqGovernorRow   = "\tGovernor: %s, Energy performance preference: %s"
+	cpuFreqThrottleRow   = "\tThermal throttling: core %d, package %d"
 
 	tempMessageHeader  = "Temperature metrics:\n"
-	tempMetricsMessage = "Sensor: %s, Temperature: %s °C"
+	tempChipRow        = "Chip: %s"
+	tempMetricsMessage = "\tSensor: %s, Temperature: %s °C"
+	tempHighRow        = ", High: %s °C"
+	tempCriticalRow    = ", Critical: %s °C"
+	tempStatusRow      = ", Status: %s"
 
 	memMessageHeader  = "Memory metrics:\n"
 	memMetricsMessage = "Usage %s%%, Used: %s B, Total: %s B"
@@ -23,6 +46,15 @@ const (
 	hostMessagePlatformRow        = "Platform: %s"
 	hostMessagePlatformVersionRow = "Platform version: %s"
 	hostMessageUptimeRow          = "Uptime (s): %s"
+	hostMessageKernelRow          = "Kernel: %s (%s)"
+	hostMessageBootTimeRow        = "Boot time: %s"
+	hostMessageVirtualizationRow  = "Virtualization: %s"
+	hostMessageHostIDRow          = "Host ID: %s"
+	hostMessageProcsRow           = "Processes: %s"
+	hostMessageTimezoneRow        = "Timezone: %s"
+	hostMessageUsersRow           = "Logged-in users: %d"
+	hostMessageSessionRow         = "\t%s on %s since %s"
+	hostMessageSessionHostRow     = " from %s"
 
 	netInterfacesMessageHeader   = "Net Interfaces:\n"
 	netInterfacesNameRow         = "Name: %s"
@@ -30,6 +62,87 @@ const (
 	netInterfacesAdressessHeader = "\tAdressess: \n"
 	netInterfacesAdressRow       = "\tIP: %s"
 	netInterfacesMTURow          = "\tMTU: %s"
+
+	containersMessageHeader = "Containers:\n"
+	containersNameRow       = "Name: %s (%s)"
+	containersImageRow      = "\tImage: %s, State: %s"
+	containersCpuRow        = "\tCPU: %s%%"
+	containersMemoryRow     = "\tMemory: %s B / %s B"
+	containersNetRow        = "\tNet RX: %s B, TX: %s B"
+	containersBlockRow      = "\tBlock read: %s B, write: %s B"
+	containersLabelsRow     = "\tLabels: %s"
+
+	psiMessageHeader = "Pressure stall information:\n"
+	psiHostRow       = "Host:"
+	psiCgroupRow     = "Cgroup %s:"
+	psiResourceRow   = "\t%s %s: avg10 %s%%, avg60 %s%%, avg300 %s%%, total %ss"
+
+	connsMessageHeader = "Connections:\n"
+	connsStatesHeader  = "States:"
+	connsStateRow      = "\t%s %s: %d"
+	connsListenHeader  = "Listening sockets:"
+	connsActiveHeader  = "Active connections:"
+	connsListenRow     = "\t%s %s"
+	connsActiveRow     = "\t%s %s -> %s %s"
+	connsPidRow        = " (pid %d)"
+	connsCountersRow   = "Counters:\n" +
+		"\tTCP: established %d, retransmits %d, in errors %d, out resets %d, listen overflows %d, listen drops %d\n" +
+		"\tUDP: in errors %d, no ports %d, receive buffer errors %d, send buffer errors %d"
+
+	systemdMessageHeader = "Systemd units:\n"
+	systemdUnitRow       = "%s: %s (%s), load: %s"
+	systemdRestartsRow   = ", restarts: %d"
+	systemdMemoryRow     = ", memory: %s B"
+	systemdCpuRow        = ", CPU: %ss"
+	systemdFailedRow     = "Failed units: %d"
+
+	limitsMessageHeader  = "Kernel limits:\n"
+	limitsUsageRow       = "%s: %d of %s (%s%%)"
+	limitsInodesRow      = "Inodes: %d allocated, %d free"
+	limitsEntropyRow     = "Entropy: %d of %d bits"
+	limitsProcessesRow   = "File descriptors of the processes closest to their limit:"
+	limitsProcessRow     = "\t%d %s: %d of %s (%s%%)"
+	limitsNoConntrackRow = "Conntrack: nf_conntrack not loaded"
+
+	procGroupsMessageHeader = "Process groups:\n"
+	procGroupsNameRow       = "%s: %d processes"
+	procGroupsUsageRow      = "\tCPU: %s%%, RSS: %d B, threads: %d, open fds: %d"
+	procGroupsIORow         = "\tI/O read: %s B/s, write: %s B/s"
+	procGroupsPidsRow       = "\tPids: %s"
+	procGroupsNoGroups      = "No process groups configured"
+
+	memInfoMessageHeader = "Memory details:\n"
+	memInfoTotalsRow     = "Total: %d B, Free: %d B, Available: %d B, Buffers: %d B, Cached: %d B"
+	memInfoSwapRow       = "Swap: %d B free of %d B"
+	memInfoWritebackRow  = "Dirty: %d B, Writeback: %d B"
+	memInfoSlabRow       = "Slab: %d B (reclaimable %d B, unreclaimable %d B)"
+	memInfoCommitRow     = "Committed: %d B of commit limit %d B (%s%%)"
+	memInfoHugePagesRow  = "Hugepages:"
+	memInfoPoolRow       = "\t%d kB: total %d, free %d, reserved %d, surplus %d"
+	memInfoThpRow        = "Transparent hugepages: enabled %s, defrag %s, anonymous %d B"
+	memInfoNoThpRow      = "Transparent hugepages: not available"
+	memInfoNodesRow      = "NUMA nodes:"
+	memInfoNodeRow       = "\tNode %d: %d B free of %d B"
+	memInfoNodePoolRow   = "\t\t%d kB hugepages: total %d, free %d"
+
+	mountsMessageHeader = "Mounts:\n"
+	mountsRow           = "%s: %s on %s (%s), health: %s"
+	mountsUsageRow      = "\tSpace: %d B of %d B (%s%%), inodes: %d of %d (%s%%)"
+	mountsStaleRow      = "\tUsage not read within the stat timeout"
+	mountsUnhealthyRow  = "Unhealthy mounts: %d"
+
+	sensorsMessageHeader = "Sensors:\n"
+	sensorsChipRow       = "Chip: %s"
+	sensorsValueRow      = "\t%s (%s): %s %s"
+	sensorsLimitsRow     = " [%s]"
+	sensorsAlarm         = " ALARM"
+
+	collectorsMessageHeader = "Collectors:\n"
+	collectorsNameRow       = "Name: %s - %s"
+	collectorsMetricRow     = "\t%s (%s): %s"
+
+	samplesMessageHeader = "%s metrics:\n"
+	samplesRow           = "%s%s: %s"
 )
 
 func CpuMessage(cpuInfo []models.CpuInfo) string {
@@ -48,6 +161,9 @@ func CpuMessage(cpuInfo []models.CpuInfo) string {
 		}
 
 		message += fmt.Sprintf(cpuMetricsMessage, cpuID, usagePercent, frequency)
+		if cpu.Times != (models.CpuTimes{}) {
+			message += "\n" + cpuTimesMessage(cpu.Times)
+		}
 		if i != len(cpuInfo)-1 {
 			message += "\n"
 		}
@@ -55,15 +171,97 @@ func CpuMessage(cpuInfo []models.CpuInfo) string {
 	return message
 }
 
+// CpuStatsMessage shows the time breakdown of all CPUs together and the event rates.
+func CpuStatsMessage(stats models.CpuStats) string {
+	message := cpuTotalRow + "\n" + cpuTimesMessage(stats.Total) + "\n"
+	message += fmt.Sprintf(cpuRatesRow, strconv.FormatFloat(stats.ContextSwitchesPerSec, 'f', 0, 64), strconv.FormatFloat(stats.InterruptsPerSec, 'f', 0, 64))
+	return message
+}
+
+// CpuTopologyMessage shows the model of each socket and the logical CPUs of its cores.
+func CpuTopologyMessage(topology models.CpuTopology) string {
+	message := fmt.Sprintf(cpuTopologyCountsRow, topology.PhysicalCores, topology.LogicalCpus)
+	for _, socket := range topology.Sockets {
+		modelName := socket.ModelName
+		if modelName == "" {
+			modelName = "NA"
+		}
+		message += "\n" + fmt.Sprintf(cpuSocketRow, socket.ID, modelName)
+		if socket.VendorID != "" {
+			message += "\n" + fmt.Sprintf(cpuSocketModelRow, socket.VendorID, socket.Family, socket.Model, socket.Stepping, socket.CacheSizeKB)
+		}
+		if len(socket.Flags) > 0 {
+			message += "\n" + fmt.Sprintf(cpuSocketFlagsRow, strings.Join(socket.Flags, " "))
+		}
+		for _, core := range socket.Cores {
+			message += "\n" + fmt.Sprintf(cpuCoreRow, core.ID, strings.Join(core.Cpus, ", "))
+		}
+	}
+	return message
+}
+
+func CpuFrequenciesMessage(frequencies []models.CpuFrequency) string {
+	message := cpuFreqMessageHeader
+	format := func(mhz float64) string {
+		if mhz == 0 {
+			return "NA"
+		}
+		return strconv.FormatFloat(mhz, 'f', 0, 64)
+	}
+	orNA := func(value string) string {
+		if value == "" {
+			return "NA"
+		}
+		return value
+	}
+	for i, frequency := range frequencies {
+		message += fmt.Sprintf(cpuFreqRow, frequency.Cpu, format(frequency.CurrentMhz), format(frequency.MinMhz), format(frequency.MaxMhz),
+			format(frequency.HardwareMinMhz), format(frequency.HardwareMaxMhz)) + "\n"
+		message += fmt.Sprintf(cpuFreqGovernorRow, orNA(frequency.Governor), orNA(frequency.EnergyPerformancePreference))
+		if frequency.CoreThrottleCount > 0 || frequency.PackageThrottleCount > 0 {
+			message += "\n" + fmt.Sprintf(cpuFreqThrottleRow, frequency.CoreThrottleCount, frequency.PackageThrottleCount)
+		}
+		if i != len(frequencies)-1 {
+			message += "\n"
+		}
+	}
+	return message
+}
+
+func cpuTimesMessage(times models.CpuTimes) string {
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	}
+	return fmt.Sprintf(cpuTimesRow, format(times.User), format(times.System), format(times.Idle), format(times.Iowait),
+		format(times.Irq), format(times.Softirq), format(times.Steal), format(times.Guest))
+}
+
+// TempMessage lists the sensors under their chips, which are expected to be grouped as returned by GetTemperatures.
 func TempMessage(temps []models.TemperatureSensor) string {
 	message := tempMessageHeader
 	for i, temp := range temps {
+		if i == 0 || temps[i-1].Chip != temp.Chip {
+			chip := temp.Chip
+			if chip == "" {
+				chip = "NA"
+			}
+			message += fmt.Sprintf(tempChipRow, chip) + "\n"
+		}
 		sensorKey := temp.Key
 		if sensorKey == "" {
 			sensorKey = "NA"
 		}
 		value := strconv.FormatFloat(temp.Value, 'f', -1, 64)
 		message += fmt.Sprintf(tempMetricsMessage, sensorKey, value)
+		if temp.High > 0 {
+			message += fmt.Sprintf(tempHighRow, strconv.FormatFloat(temp.High, 'f', -1, 64))
+		}
+		if temp.Critical > 0 {
+			message += fmt.Sprintf(tempCriticalRow, strconv.FormatFloat(temp.Critical, 'f', -1, 64))
+		}
+		if temp.Status != "" {
+			message += fmt.Sprintf(tempStatusRow, temp.Status)
+		}
 		if i != len(temps)-1 {
 			message += "\n"
 		}
@@ -120,11 +318,57 @@ func HostInfoMessage(hostInfo models.HostInfo) string {
 	if hostInfo.Uptime != 0 {
 		uptime = strconv.FormatUint(hostInfo.Uptime, 10)
 	}
-	message += fmt.Sprintf(hostMessageUptimeRow, uptime)
+	message += fmt.Sprintf(hostMessageUptimeRow, uptime) + "\n"
+
+	message += fmt.Sprintf(hostMessageKernelRow, orNA(hostInfo.KernelVersion), orNA(hostInfo.KernelArch)) + "\n"
+
+	bootTime := "NA"
+	if hostInfo.BootTime != 0 {
+		bootTime = formatUnixTime(hostInfo.BootTime)
+	}
+	message += fmt.Sprintf(hostMessageBootTimeRow, bootTime) + "\n"
+
+	virtualization := "none"
+	if hostInfo.VirtualizationSystem != "" {
+		virtualization = hostInfo.VirtualizationSystem
+		if hostInfo.VirtualizationRole != "" {
+			virtualization += " " + hostInfo.VirtualizationRole
+		}
+	}
+	message += fmt.Sprintf(hostMessageVirtualizationRow, virtualization) + "\n"
+
+	message += fmt.Sprintf(hostMessageHostIDRow, orNA(hostInfo.HostID)) + "\n"
+
+	procs := "NA"
+	if hostInfo.Procs != 0 {
+		procs = strconv.FormatUint(hostInfo.Procs, 10)
+	}
+	message += fmt.Sprintf(hostMessageProcsRow, procs) + "\n"
+
+	message += fmt.Sprintf(hostMessageTimezoneRow, orNA(hostInfo.Timezone)) + "\n"
+
+	message += fmt.Sprintf(hostMessageUsersRow, len(hostInfo.Users))
+	for _, session := range hostInfo.Users {
+		message += "\n" + fmt.Sprintf(hostMessageSessionRow, session.User, orNA(session.Terminal), formatUnixTime(session.Started))
+		if session.Host != "" {
+			message += fmt.Sprintf(hostMessageSessionHostRow, session.Host)
+		}
+	}
 
 	return message
 }
 
+func orNA(value string) string {
+	if value == "" {
+		return "NA"
+	}
+	return value
+}
+
+func formatUnixTime(seconds uint64) string {
+	return time.Unix(int64(seconds), 0).UTC().Format(time.RFC3339)
+}
+
 func NetInterfacesMessage(netInferfaces []models.NetInterface) string {
 	message := netInterfacesMessageHeader
 
@@ -161,3 +405,314 @@ func NetInterfacesMessage(netInferfaces []models.NetInterface) string {
 
 	return message
 }
+
+func ContainersMessage(containers []models.ContainerStats) string {
+	message := containersMessageHeader
+	if len(containers) == 0 {
+		return message + "No running containers"
+	}
+	for i, container := range containers {
+		id := container.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		message += fmt.Sprintf(containersNameRow, container.Name, id) + "\n"
+		message += fmt.Sprintf(containersImageRow, container.Image, container.State) + "\n"
+		message += fmt.Sprintf(containersCpuRow, strconv.FormatFloat(container.CpuPercent, 'f', 2, 64)) + "\n"
+		message += fmt.Sprintf(containersMemoryRow, strconv.FormatUint(container.MemoryUsageB, 10), strconv.FormatUint(container.MemoryLimitB, 10)) + "\n"
+		message += fmt.Sprintf(containersNetRow, strconv.FormatUint(container.NetRxB, 10), strconv.FormatUint(container.NetTxB, 10)) + "\n"
+		message += fmt.Sprintf(containersBlockRow, strconv.FormatUint(container.BlockReadB, 10), strconv.FormatUint(container.BlockWriteB, 10))
+		if len(container.Labels) > 0 {
+			message += "\n" + fmt.Sprintf(containersLabelsRow, formatLabels(container.Labels))
+		}
+		if i != len(containers)-1 {
+			message += "\n"
+		}
+	}
+	return message
+}
+
+// PsiMessage lists the some and full stalls of each resource for the host and the cgroup.
+func PsiMessage(pressure []models.PressureStats) string {
+	message := psiMessageHeader
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	}
+	for i, scope := range pressure {
+		if scope.Cgroup == "" {
+			message += psiHostRow
+		} else {
+			message += fmt.Sprintf(psiCgroupRow, scope.Cgroup)
+		}
+		for _, resource := range scope.Resources {
+			stalls := []pressureKind{{"some", resource.Some}}
+			if resource.HasFull {
+				stalls = append(stalls, pressureKind{"full", resource.Full})
+			}
+			for _, s := range stalls {
+				message += "\n" + fmt.Sprintf(psiResourceRow, resource.Resource, s.kind, format(s.stall.Avg10), format(s.stall.Avg60), format(s.stall.Avg300), format(s.stall.TotalSeconds))
+			}
+		}
+		if i != len(pressure)-1 {
+			message += "\n"
+		}
+	}
+	return message
+}
+
+// ConnectionsMessage lists the socket counts by state, the listening sockets, the other connections and the protocol counters.
+func ConnectionsMessage(stats models.SocketStats) string {
+	message := connsMessageHeader
+	if len(stats.Connections) == 0 {
+		message += "No connections found\n"
+	} else {
+		message += connsStatesHeader
+		for _, count := range CountConnections(stats.Connections) {
+			message += "\n" + fmt.Sprintf(connsStateRow, count.Protocol, count.State, count.Count)
+		}
+		var listeners, active string
+		for _, conn := range stats.Connections {
+			local := net.JoinHostPort(conn.LocalAddr, strconv.Itoa(int(conn.LocalPort)))
+			var row string
+			if IsListener(conn) {
+				row = fmt.Sprintf(connsListenRow, conn.Protocol, local)
+			} else {
+				remote := net.JoinHostPort(conn.RemoteAddr, strconv.Itoa(int(conn.RemotePort)))
+				row = fmt.Sprintf(connsActiveRow, conn.Protocol, local, remote, conn.State)
+			}
+			if conn.Pid != 0 {
+				row += fmt.Sprintf(connsPidRow, conn.Pid)
+			}
+			if IsListener(conn) {
+				listeners += "\n" + row
+			} else {
+				active += "\n" + row
+			}
+		}
+		if listeners != "" {
+			message += "\n" + connsListenHeader + listeners
+		}
+		if active != "" {
+			message += "\n" + connsActiveHeader + active
+		}
+		message += "\n"
+	}
+	if !stats.HasCounters {
+		return strings.TrimSuffix(message, "\n")
+	}
+	c := stats.Counters
+	return message + fmt.Sprintf(connsCountersRow, c.TcpCurrEstab, c.TcpRetransSegs, c.TcpInErrs, c.TcpOutRsts, c.TcpListenOverflows, c.TcpListenDrops,
+		c.UdpInErrors, c.UdpNoPorts, c.UdpRcvbufErrors, c.UdpSndbufErrors)
+}
+
+// SystemdUnitsMessage lists the units with their state and the accounting systemd tracks for them, followed by the failed count.
+func SystemdUnitsMessage(units []models.SystemdUnit) string {
+	message := systemdMessageHeader
+	if len(units) == 0 {
+		return message + "No units found"
+	}
+	for _, unit := range units {
+		message += fmt.Sprintf(systemdUnitRow, unit.Name, unit.ActiveState, unit.SubState, unit.LoadState)
+		if unit.Restarts != 0 {
+			message += fmt.Sprintf(systemdRestartsRow, unit.Restarts)
+		}
+		if unit.MemoryB != 0 {
+			message += fmt.Sprintf(systemdMemoryRow, strconv.FormatUint(unit.MemoryB, 10))
+		}
+		if unit.CpuSeconds != 0 {
+			message += fmt.Sprintf(systemdCpuRow, strconv.FormatFloat(unit.CpuSeconds, 'f', 2, 64))
+		}
+		message += "\n"
+	}
+	return message + fmt.Sprintf(systemdFailedRow, len(FailedSystemdUnits(units)))
+}
+
+// LimitsMessage shows the usage of the kernel tables and the processes closest to their open files limit,
+// which are expected to be ordered as returned by GetKernelLimits.
+func LimitsMessage(limits models.KernelLimits, topProcesses int) string {
+	usage := func(name string, used, limit uint64) string {
+		return fmt.Sprintf(limitsUsageRow, name, used, formatLimit(limit), strconv.FormatFloat(usagePercent(used, limit), 'f', 2, 64))
+	}
+	message := limitsMessageHeader +
+		usage("File handles", limits.OpenFiles, limits.MaxFiles) + "\n" +
+		fmt.Sprintf(limitsInodesRow, limits.Inodes, limits.FreeInodes) + "\n"
+	if limits.HasConntrack {
+		message += usage("Conntrack", limits.ConntrackEntries, limits.ConntrackMax) + "\n"
+	} else {
+		message += limitsNoConntrackRow + "\n"
+	}
+	message += usage("Pids", limits.Pids, limits.PidMax) + "\n" +
+		usage("Threads", limits.Pids, limits.ThreadsMax) + "\n" +
+		fmt.Sprintf(limitsEntropyRow, limits.EntropyAvailable, limits.EntropyPoolSize)
+	if len(limits.Processes) == 0 {
+		return message
+	}
+	message += "\n" + limitsProcessesRow
+	for _, process := range limits.Processes[:min(len(limits.Processes), topProcesses)] {
+		message += "\n" + fmt.Sprintf(limitsProcessRow, process.Pid, process.Name, process.OpenFds, formatLimit(process.SoftLimit),
+			strconv.FormatFloat(FdUsagePercent(process), 'f', 2, 64))
+	}
+	return message
+}
+
+func formatLimit(limit uint64) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return strconv.FormatUint(limit, 10)
+}
+
+// ProcGroupsMessage shows the aggregated usage of each process group and the pids of its processes.
+func ProcGroupsMessage(groups []models.ProcessGroup) string {
+	message := procGroupsMessageHeader
+	if len(groups) == 0 {
+		return message + procGroupsNoGroups
+	}
+	for i, group := range groups {
+		if i > 0 {
+			message += "\n"
+		}
+		message += fmt.Sprintf(procGroupsNameRow, group.Name, len(group.Pids)) + "\n" +
+			fmt.Sprintf(procGroupsUsageRow, strconv.FormatFloat(group.CpuPercent, 'f', 2, 64), group.RssB, group.Threads, group.OpenFds) + "\n" +
+			fmt.Sprintf(procGroupsIORow, strconv.FormatFloat(group.ReadBps, 'f', 0, 64), strconv.FormatFloat(group.WriteBps, 'f', 0, 64))
+		if len(group.Pids) > 0 {
+			pids := make([]string, len(group.Pids))
+			for j, pid := range group.Pids {
+				pids[j] = strconv.Itoa(int(pid))
+			}
+			message += "\n" + fmt.Sprintf(procGroupsPidsRow, strings.Join(pids, ", "))
+		}
+	}
+	return message
+}
+
+// MemInfoMessage shows the memory breakdown, the hugepage pools and the memory of each NUMA node.
+func MemInfoMessage(details models.MemoryDetails) string {
+	message := memInfoMessageHeader +
+		fmt.Sprintf(memInfoTotalsRow, details.TotalB, details.FreeB, details.AvailableB, details.BuffersB, details.CachedB) + "\n" +
+		fmt.Sprintf(memInfoSwapRow, details.SwapFreeB, details.SwapTotalB) + "\n" +
+		fmt.Sprintf(memInfoWritebackRow, details.DirtyB, details.WritebackB) + "\n" +
+		fmt.Sprintf(memInfoSlabRow, details.SlabB, details.SlabReclaimableB, details.SlabUnreclaimableB) + "\n" +
+		fmt.Sprintf(memInfoCommitRow, details.CommittedAsB, details.CommitLimitB,
+			strconv.FormatFloat(usagePercent(details.CommittedAsB, details.CommitLimitB), 'f', 2, 64))
+	if len(details.HugePages) > 0 {
+		message += "\n" + memInfoHugePagesRow
+		for _, pool := range details.HugePages {
+			message += "\n" + fmt.Sprintf(memInfoPoolRow, pool.SizeB/1024, pool.Total, pool.Free, pool.Reserved, pool.Surplus)
+		}
+	}
+	if thp := details.TransparentHugePages; thp.Enabled != "" {
+		message += "\n" + fmt.Sprintf(memInfoThpRow, thp.Enabled, thp.Defrag, details.AnonHugePagesB)
+	} else {
+		message += "\n" + memInfoNoThpRow
+	}
+	if len(details.NumaNodes) > 0 {
+		message += "\n" + memInfoNodesRow
+		for _, node := range details.NumaNodes {
+			message += "\n" + fmt.Sprintf(memInfoNodeRow, node.ID, node.FreeB, node.TotalB)
+			for _, pool := range node.HugePages {
+				message += "\n" + fmt.Sprintf(memInfoNodePoolRow, pool.SizeB/1024, pool.Total, pool.Free)
+			}
+		}
+	}
+	return message
+}
+
+// MountsMessage lists the mounts with their options, health and usage.
+func MountsMessage(mounts []models.MountStatus) string {
+	message := mountsMessageHeader
+	if len(mounts) == 0 {
+		return message + "No mounts found"
+	}
+	for _, mount := range mounts {
+		message += fmt.Sprintf(mountsRow, mount.Path, mount.Fstype, mount.Device, strings.Join(mount.Options, ","), mount.Health) + "\n"
+		if mount.Stale {
+			message += mountsStaleRow + "\n"
+			continue
+		}
+		inodesUsed := mount.Inodes - mount.InodesFree
+		message += fmt.Sprintf(mountsUsageRow, mount.UsedB, mount.TotalB, strconv.FormatFloat(mount.UsedPercent, 'f', 2, 64),
+			inodesUsed, mount.Inodes, strconv.FormatFloat(mount.InodesUsedPercent, 'f', 2, 64)) + "\n"
+	}
+	return message + fmt.Sprintf(mountsUnhealthyRow, len(UnhealthyMounts(mounts)))
+}
+
+// SensorsMessage lists the hwmon sensors grouped by chip, with the limits the chip reports.
+func SensorsMessage(sensors []models.HwmonSensor) string {
+	message := sensorsMessageHeader
+	if len(sensors) == 0 {
+		return message + "No sensors found"
+	}
+	for i, sensor := range sensors {
+		if i == 0 || sensors[i-1].Chip != sensor.Chip {
+			if i != 0 {
+				message += "\n"
+			}
+			message += fmt.Sprintf(sensorsChipRow, sensor.Chip)
+		}
+		message += "\n" + fmt.Sprintf(sensorsValueRow, sensor.Label, sensor.Sensor, formatSensorValue(sensor.Value), sensor.Unit)
+		var limits []string
+		for _, limit := range []struct {
+			name  string
+			value *float64
+		}{{"min", sensor.Min}, {"max", sensor.Max}, {"crit", sensor.Crit}} {
+			if limit.value != nil {
+				limits = append(limits, limit.name+": "+formatSensorValue(*limit.value))
+			}
+		}
+		if len(limits) > 0 {
+			message += fmt.Sprintf(sensorsLimitsRow, strings.Join(limits, ", "))
+		}
+		if sensor.Alarm {
+			message += sensorsAlarm
+		}
+	}
+	return message
+}
+
+func formatSensorValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func CollectorsMessage(collectors []collector.Collector) string {
+	message := collectorsMessageHeader
+	for i, c := range collectors {
+		message += fmt.Sprintf(collectorsNameRow, c.Name(), c.Description())
+		for _, descriptor := range c.Descriptors() {
+			message += "\n" + fmt.Sprintf(collectorsMetricRow, descriptor.Name, descriptor.Type, descriptor.Help)
+		}
+		if i != len(collectors)-1 {
+			message += "\n"
+		}
+	}
+	return message
+}
+
+func SamplesMessage(collectorName string, samples []collector.Sample) string {
+	message := fmt.Sprintf(samplesMessageHeader, collectorName)
+	for i, sample := range samples {
+		value := strconv.FormatFloat(sample.Value, 'f', -1, 64)
+		message += fmt.Sprintf(samplesRow, sample.Metric, formatLabels(sample.Labels), value)
+		if i != len(samples)-1 {
+			message += "\n"
+		}
+	}
+	return message
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%s", name, labels[name])
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/Matyjash/Metrigo/internal/collector"
 	"github.com/Matyjash/Metrigo/internal/models"
 )
 
@@ -84,6 +85,21 @@ func Test_TempMessage(t *testing.T) {
 			},
 			wantReturnContains: []string{fmt.Sprintf(tempMetricsMessage, "NA", "30")},
 		},
+		{
+			name: "groups sensors by chip with thresholds and status",
+			temps: []models.TemperatureSensor{
+				{Key: "coretemp_core_0", Chip: "coretemp", Value: 60, High: 80, Critical: 100, Status: "ok"},
+				{Key: "coretemp_core_1", Chip: "coretemp", Value: 61, Status: "ok"},
+				{Key: "nvme_composite", Chip: "nvme", Value: 90, High: 70, Status: "high"},
+			},
+			wantReturnContains: []string{
+				tempMessageHeader + fmt.Sprintf(tempChipRow, "coretemp") + "\n" +
+					fmt.Sprintf(tempMetricsMessage, "coretemp_core_0", "60") + fmt.Sprintf(tempHighRow, "80") + fmt.Sprintf(tempCriticalRow, "100") + fmt.Sprintf(tempStatusRow, "ok") + "\n" +
+					fmt.Sprintf(tempMetricsMessage, "coretemp_core_1", "61") + fmt.Sprintf(tempStatusRow, "ok") + "\n" +
+					fmt.Sprintf(tempChipRow, "nvme") + "\n" +
+					fmt.Sprintf(tempMetricsMessage, "nvme_composite", "90") + fmt.Sprintf(tempHighRow, "70") + fmt.Sprintf(tempStatusRow, "high"),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -234,6 +250,43 @@ func Test_HostInfoMessage(t *testing.T) {
 				fmt.Sprintf(hostMessagePlatformVersionRow, "Ubuntu 24.04.3 LTS") + "\n" +
 				fmt.Sprintf(hostMessageUptimeRow, "NA"),
 		},
+		{
+			name: "returns inventory fields and user sessions",
+			hostInfo: models.HostInfo{
+				KernelVersion:        "6.8.0-45-generic",
+				KernelArch:           "x86_64",
+				BootTime:             1700000000,
+				VirtualizationSystem: "kvm",
+				VirtualizationRole:   "guest",
+				HostID:               "4c4c4544-0042",
+				Procs:                312,
+				Timezone:             "Europe/Warsaw",
+				Users: []models.UserSession{
+					{User: "alice", Terminal: "pts/0", Host: "10.0.0.2", Started: 1700003600},
+					{User: "bob", Terminal: "tty1", Started: 1700007200},
+				},
+			},
+			wantReturnContains: fmt.Sprintf(hostMessageKernelRow, "6.8.0-45-generic", "x86_64") + "\n" +
+				fmt.Sprintf(hostMessageBootTimeRow, "2023-11-14T22:13:20Z") + "\n" +
+				fmt.Sprintf(hostMessageVirtualizationRow, "kvm guest") + "\n" +
+				fmt.Sprintf(hostMessageHostIDRow, "4c4c4544-0042") + "\n" +
+				fmt.Sprintf(hostMessageProcsRow, "312") + "\n" +
+				fmt.Sprintf(hostMessageTimezoneRow, "Europe/Warsaw") + "\n" +
+				fmt.Sprintf(hostMessageUsersRow, 2) + "\n" +
+				fmt.Sprintf(hostMessageSessionRow, "alice", "pts/0", "2023-11-14T23:13:20Z") + fmt.Sprintf(hostMessageSessionHostRow, "10.0.0.2") + "\n" +
+				fmt.Sprintf(hostMessageSessionRow, "bob", "tty1", "2023-11-15T00:13:20Z"),
+		},
+		{
+			name:     "bare metal without inventory fields",
+			hostInfo: models.HostInfo{},
+			wantReturnContains: fmt.Sprintf(hostMessageKernelRow, "NA", "NA") + "\n" +
+				fmt.Sprintf(hostMessageBootTimeRow, "NA") + "\n" +
+				fmt.Sprintf(hostMessageVirtualizationRow, "none") + "\n" +
+				fmt.Sprintf(hostMessageHostIDRow, "NA") + "\n" +
+				fmt.Sprintf(hostMessageProcsRow, "NA") + "\n" +
+				fmt.Sprintf(hostMessageTimezoneRow, "NA") + "\n" +
+				fmt.Sprintf(hostMessageUsersRow, 0),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -321,3 +374,481 @@ func Test_NetInterfacesMessage(t *testing.T) {
 		})
 	}
 }
+
+func Test_CollectorsMessage(t *testing.T) {
+	collectors := []collector.Collector{
+		collector.New("load", "System load averages", []collector.Descriptor{
+			{Name: "load1", Help: "1 minute load average."},
+		}, nil),
+		collector.New("host", "Host information and uptime", []collector.Descriptor{
+			{Name: "uptime_seconds", Help: "Host uptime in seconds.", Type: collector.Counter},
+		}, nil),
+	}
+	wantReturnContains := []string{
+		fmt.Sprintf(collectorsNameRow, "load", "System load averages"),
+		fmt.Sprintf(collectorsMetricRow, "load1", "gauge", "1 minute load average."),
+		fmt.Sprintf(collectorsNameRow, "host", "Host information and uptime"),
+		fmt.Sprintf(collectorsMetricRow, "uptime_seconds", "counter", "Host uptime in seconds."),
+	}
+
+	got := CollectorsMessage(collectors)
+	for _, substr := range wantReturnContains {
+		if !strings.Contains(got, substr) {
+			t.Errorf("CollectorsMessage() = %v, want contains %v", got, substr)
+		}
+	}
+}
+
+func Test_SamplesMessage(t *testing.T) {
+	tests := []struct {
+		name               string
+		samples            []collector.Sample
+		wantReturnContains []string
+	}{
+		{
+			name:               "formats sample without labels",
+			samples:            []collector.Sample{{Metric: "load1", Value: 0.5}},
+			wantReturnContains: []string{fmt.Sprintf(samplesRow, "load1", "", "0.5")},
+		},
+		{
+			name: "formats sample labels sorted by name",
+			samples: []collector.Sample{
+				{Metric: "used_bytes", Labels: map[string]string{"path": "/", "fstype": "ext4"}, Value: 1024},
+			},
+			wantReturnContains: []string{fmt.Sprintf(samplesRow, "used_bytes", "{fstype=ext4, path=/}", "1024")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SamplesMessage("test", tt.samples)
+			for _, substr := range tt.wantReturnContains {
+				if !strings.Contains(got, substr) {
+					t.Errorf("SamplesMessage() = %v, want contains %v", got, substr)
+				}
+			}
+		})
+	}
+}
+
+func Test_ContainersMessage(t *testing.T) {
+	tests := []struct {
+		name               string
+		containers         []models.ContainerStats
+		wantReturnContains []string
+	}{
+		{
+			name:               "no containers",
+			wantReturnContains: []string{containersMessageHeader + "No running containers"},
+		},
+		{
+			name: "formats container with short id and labels",
+			containers: []models.ContainerStats{{
+				ID:           "0123456789abcdef",
+				Name:         "web",
+				Image:        "nginx:1.27",
+				State:        "running",
+				Labels:       map[string]string{"tier": "frontend"},
+				CpuPercent:   12.345,
+				MemoryUsageB: 1024,
+				MemoryLimitB: 2048,
+			}},
+			wantReturnContains: []string{
+				fmt.Sprintf(containersNameRow, "web", "0123456789ab"),
+				fmt.Sprintf(containersImageRow, "nginx:1.27", "running"),
+				fmt.Sprintf(containersCpuRow, "12.35"),
+				fmt.Sprintf(containersMemoryRow, "1024", "2048"),
+				fmt.Sprintf(containersLabelsRow, "{tier=frontend}"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ContainersMessage(tt.containers)
+			for _, substr := range tt.wantReturnContains {
+				if !strings.Contains(got, substr) {
+					t.Errorf("ContainersMessage() = %v, want contains %v", got, substr)
+				}
+			}
+		})
+	}
+}
+
+func Test_SensorsMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		sensors    []models.HwmonSensor
+		wantReturn string
+	}{
+		{
+			name:       "no sensors",
+			wantReturn: sensorsMessageHeader + "No sensors found",
+		},
+		{
+			name: "groups sensors by chip with limits and alarms",
+			sensors: []models.HwmonSensor{
+				{Chip: "nct6775", Sensor: "fan1", Label: "CPU Fan", Type: "fan", Unit: "rpm", Value: 0, Min: floatPtr(300), Alarm: true},
+				{Chip: "nct6775", Sensor: "in0", Label: "Vcore", Type: "voltage", Unit: "V", Value: 1.2, Min: floatPtr(0.8), Max: floatPtr(1.5)},
+				{Chip: "ina3221", Sensor: "power1", Label: "power1", Type: "power", Unit: "W", Value: 12.5, Min: floatPtr(0)},
+			},
+			wantReturn: sensorsMessageHeader +
+				fmt.Sprintf(sensorsChipRow, "nct6775") + "\n" +
+				fmt.Sprintf(sensorsValueRow, "CPU Fan", "fan1", "0", "rpm") + fmt.Sprintf(sensorsLimitsRow, "min: 300") + sensorsAlarm + "\n" +
+				fmt.Sprintf(sensorsValueRow, "Vcore", "in0", "1.2", "V") + fmt.Sprintf(sensorsLimitsRow, "min: 0.8, max: 1.5") + "\n" +
+				fmt.Sprintf(sensorsChipRow, "ina3221") + "\n" +
+				fmt.Sprintf(sensorsValueRow, "power1", "power1", "12.5", "W") + fmt.Sprintf(sensorsLimitsRow, "min: 0"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SensorsMessage(tt.sensors); got != tt.wantReturn {
+				t.Errorf("SensorsMessage() = %v, want %v", got, tt.wantReturn)
+			}
+		})
+	}
+}
+
+func Test_CpuStatsMessage(t *testing.T) {
+	stats := models.CpuStats{
+		Total:                 models.CpuTimes{User: 10, System: 5, Idle: 77.5, Iowait: 3, Irq: 0.5, Softirq: 1, Steal: 3},
+		ContextSwitchesPerSec: 1500.4,
+		InterruptsPerSec:      900,
+	}
+	want := cpuTotalRow + "\n" +
+		fmt.Sprintf(cpuTimesRow, "10.00", "5.00", "77.50", "3.00", "0.50", "1.00", "3.00", "0.00") + "\n" +
+		fmt.Sprintf(cpuRatesRow, "1500", "900")
+	if got := CpuStatsMessage(stats); got != want {
+		t.Errorf("CpuStatsMessage() = %v, want %v", got, want)
+	}
+}
+
+func Test_CpuTopologyMessage(t *testing.T) {
+	topology := models.CpuTopology{
+		PhysicalCores: 2,
+		LogicalCpus:   4,
+		Sockets: []models.CpuSocket{{
+			ID:          "0",
+			VendorID:    "GenuineIntel",
+			ModelName:   "Xeon",
+			Family:      "6",
+			Model:       "85",
+			Stepping:    4,
+			CacheSizeKB: 16384,
+			Flags:       []string{"avx2", "aes"},
+			Cores: []models.CpuCore{
+				{ID: "0", Cpus: []string{"cpu0", "cpu2"}},
+				{ID: "1", Cpus: []string{"cpu1", "cpu3"}},
+			},
+		}},
+	}
+	want := fmt.Sprintf(cpuTopologyCountsRow, 2, 4) + "\n" +
+		fmt.Sprintf(cpuSocketRow, "0", "Xeon") + "\n" +
+		fmt.Sprintf(cpuSocketModelRow, "GenuineIntel", "6", "85", 4, 16384) + "\n" +
+		fmt.Sprintf(cpuSocketFlagsRow, "avx2 aes") + "\n" +
+		fmt.Sprintf(cpuCoreRow, "0", "cpu0, cpu2") + "\n" +
+		fmt.Sprintf(cpuCoreRow, "1", "cpu1, cpu3")
+	if got := CpuTopologyMessage(topology); got != want {
+		t.Errorf("CpuTopologyMessage() = %v, want %v", got, want)
+	}
+}
+
+func Test_CpuFrequenciesMessage(t *testing.T) {
+	frequencies := []models.CpuFrequency{
+		{Cpu: "cpu0", CurrentMhz: 800, MinMhz: 800, MaxMhz: 3500, HardwareMinMhz: 800, HardwareMaxMhz: 4200, Governor: "powersave", EnergyPerformancePreference: "power", CoreThrottleCount: 3, PackageThrottleCount: 7},
+		{Cpu: "cpu1", CurrentMhz: 3400.4, Governor: "performance"},
+	}
+	want := cpuFreqMessageHeader +
+		fmt.Sprintf(cpuFreqRow, "cpu0", "800", "800", "3500", "800", "4200") + "\n" +
+		fmt.Sprintf(cpuFreqGovernorRow, "powersave", "power") + "\n" +
+		fmt.Sprintf(cpuFreqThrottleRow, 3, 7) + "\n" +
+		fmt.Sprintf(cpuFreqRow, "cpu1", "3400", "NA", "NA", "NA", "NA") + "\n" +
+		fmt.Sprintf(cpuFreqGovernorRow, "performance", "NA")
+	if got := CpuFrequenciesMessage(frequencies); got != want {
+		t.Errorf("CpuFrequenciesMessage() = %v, want %v", got, want)
+	}
+}
+
+func Test_PsiMessage(t *testing.T) {
+	pressure := []models.PressureStats{
+		{Resources: []models.ResourcePressure{
+			{Resource: "cpu", Some: models.PressureStall{Avg10: 1.5, Avg60: 0.75, Avg300: 0.1, TotalSeconds: 12.345678}},
+			{Resource: "memory", Some: models.PressureStall{Avg10: 3}, Full: models.PressureStall{Avg10: 2}, HasFull: true},
+		}},
+		{Cgroup: "/system.slice/app.service", Resources: []models.ResourcePressure{
+			{Resource: "io", Some: models.PressureStall{Avg10: 40}},
+		}},
+	}
+	want := psiMessageHeader + psiHostRow + "\n" +
+		fmt.Sprintf(psiResourceRow, "cpu", "some", "1.50", "0.75", "0.10", "12.35") + "\n" +
+		fmt.Sprintf(psiResourceRow, "memory", "some", "3.00", "0.00", "0.00", "0.00") + "\n" +
+		fmt.Sprintf(psiResourceRow, "memory", "full", "2.00", "0.00", "0.00", "0.00") + "\n" +
+		fmt.Sprintf(psiCgroupRow, "/system.slice/app.service") + "\n" +
+		fmt.Sprintf(psiResourceRow, "io", "some", "40.00", "0.00", "0.00", "0.00")
+	if got := PsiMessage(pressure); got != want {
+		t.Errorf("PsiMessage() = %v, want %v", got, want)
+	}
+}
+
+func Test_ConnectionsMessage(t *testing.T) {
+	counters := models.ProtocolCounters{TcpCurrEstab: 1, TcpRetransSegs: 12, TcpOutRsts: 3, TcpListenOverflows: 2, UdpNoPorts: 5}
+	countersRow := fmt.Sprintf(connsCountersRow, 1, 12, 0, 3, 2, 0, 0, 5, 0, 0)
+	tests := []struct {
+		name  string
+		stats models.SocketStats
+		want  string
+	}{
+		{
+			name: "listeners and connections",
+			stats: models.SocketStats{HasCounters: true, Counters: counters, Connections: []models.Connection{
+				{Protocol: "tcp", LocalAddr: "0.0.0.0", LocalPort: 22, State: "LISTEN", Pid: 812},
+				{Protocol: "tcp", LocalAddr: "10.0.0.1", LocalPort: 22, RemoteAddr: "10.0.0.2", RemotePort: 51000, State: "ESTABLISHED", Pid: 900},
+				{Protocol: "udp6", LocalAddr: "::", LocalPort: 53, State: "UNCONN"},
+			}},
+			want: connsMessageHeader + connsStatesHeader + "\n" +
+				fmt.Sprintf(connsStateRow, "tcp", "ESTABLISHED", 1) + "\n" +
+				fmt.Sprintf(connsStateRow, "tcp", "LISTEN", 1) + "\n" +
+				fmt.Sprintf(connsStateRow, "udp6", "UNCONN", 1) + "\n" +
+				connsListenHeader + "\n" +
+				fmt.Sprintf(connsListenRow, "tcp", "0.0.0.0:22") + fmt.Sprintf(connsPidRow, 812) + "\n" +
+				fmt.Sprintf(connsListenRow, "udp6", "[::]:53") + "\n" +
+				connsActiveHeader + "\n" +
+				fmt.Sprintf(connsActiveRow, "tcp", "10.0.0.1:22", "10.0.0.2:51000", "ESTABLISHED") + fmt.Sprintf(connsPidRow, 900) + "\n" +
+				countersRow,
+		},
+		{
+			name:  "no connections",
+			stats: models.SocketStats{HasCounters: true, Counters: counters},
+			want:  connsMessageHeader + "No connections found\n" + countersRow,
+		},
+		{
+			name: "without counters",
+			stats: models.SocketStats{Connections: []models.Connection{
+				{Protocol: "tcp", LocalAddr: "0.0.0.0", LocalPort: 22, State: "LISTEN"},
+			}},
+			want: connsMessageHeader + connsStatesHeader + "\n" +
+				fmt.Sprintf(connsStateRow, "tcp", "LISTEN", 1) + "\n" +
+				connsListenHeader + "\n" +
+				fmt.Sprintf(connsListenRow, "tcp", "0.0.0.0:22"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConnectionsMessage(tt.stats); got != tt.want {
+				t.Errorf("ConnectionsMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_SystemdUnitsMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		units []models.SystemdUnit
+		want  string
+	}{
+		{
+			name: "units with accounting",
+			units: []models.SystemdUnit{
+				{Name: "backup.service", LoadState: "loaded", ActiveState: "failed", SubState: "failed"},
+				{Name: "nginx.service", LoadState: "loaded", ActiveState: "active", SubState: "running", Restarts: 2, MemoryB: 15728640, CpuSeconds: 2.5},
+			},
+			want: systemdMessageHeader +
+				fmt.Sprintf(systemdUnitRow, "backup.service", "failed", "failed", "loaded") + "\n" +
+				fmt.Sprintf(systemdUnitRow, "nginx.service", "active", "running", "loaded") +
+				fmt.Sprintf(systemdRestartsRow, 2) + fmt.Sprintf(systemdMemoryRow, "15728640") + fmt.Sprintf(systemdCpuRow, "2.50") + "\n" +
+				fmt.Sprintf(systemdFailedRow, 1),
+		},
+		{
+			name: "no units",
+			want: systemdMessageHeader + "No units found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SystemdUnitsMessage(tt.units); got != tt.want {
+				t.Errorf("SystemdUnitsMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_LimitsMessage(t *testing.T) {
+	limits := models.KernelLimits{
+		OpenFiles: 9344, MaxFiles: 93440,
+		Inodes: 36847, FreeInodes: 512,
+		Pids: 812, PidMax: 4096, ThreadsMax: 8120,
+		EntropyAvailable: 256, EntropyPoolSize: 256,
+		Processes: []models.ProcessFds{
+			{Pid: 42, Name: "nginx", OpenFds: 512, SoftLimit: 1024},
+			{Pid: 7, Name: "java", OpenFds: 5000},
+		},
+	}
+	header := limitsMessageHeader +
+		fmt.Sprintf(limitsUsageRow, "File handles", 9344, "93440", "10.00") + "\n" +
+		fmt.Sprintf(limitsInodesRow, 36847, 512) + "\n"
+	footer := fmt.Sprintf(limitsUsageRow, "Pids", 812, "4096", "19.82") + "\n" +
+		fmt.Sprintf(limitsUsageRow, "Threads", 812, "8120", "10.00") + "\n" +
+		fmt.Sprintf(limitsEntropyRow, 256, 256)
+	withConntrack := limits
+	withConntrack.HasConntrack, withConntrack.ConntrackEntries, withConntrack.ConntrackMax = true, 200, 800
+
+	tests := []struct {
+		name         string
+		limits       models.KernelLimits
+		topProcesses int
+		want         string
+	}{
+		{
+			name:         "without conntrack",
+			limits:       limits,
+			topProcesses: 10,
+			want: header + limitsNoConntrackRow + "\n" + footer + "\n" + limitsProcessesRow + "\n" +
+				fmt.Sprintf(limitsProcessRow, 42, "nginx", 512, "1024", "50.00") + "\n" +
+				fmt.Sprintf(limitsProcessRow, 7, "java", 5000, "unlimited", "0.00"),
+		},
+		{
+			name:         "with conntrack and one process",
+			limits:       withConntrack,
+			topProcesses: 1,
+			want: header + fmt.Sprintf(limitsUsageRow, "Conntrack", 200, "800", "25.00") + "\n" + footer + "\n" + limitsProcessesRow + "\n" +
+				fmt.Sprintf(limitsProcessRow, 42, "nginx", 512, "1024", "50.00"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LimitsMessage(tt.limits, tt.topProcesses); got != tt.want {
+				t.Errorf("LimitsMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ProcGroupsMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []models.ProcessGroup
+		want   string
+	}{
+		{
+			name: "groups with and without processes",
+			groups: []models.ProcessGroup{
+				{Name: "postgres", Pids: []int32{800, 812}, CpuPercent: 2.5, RssB: 4000, ReadBps: 1536.4, WriteBps: 21, Threads: 2, OpenFds: 30},
+				{Name: "redis"},
+			},
+			want: procGroupsMessageHeader +
+				fmt.Sprintf(procGroupsNameRow, "postgres", 2) + "\n" +
+				fmt.Sprintf(procGroupsUsageRow, "2.50", 4000, 2, 30) + "\n" +
+				fmt.Sprintf(procGroupsIORow, "1536", "21") + "\n" +
+				fmt.Sprintf(procGroupsPidsRow, "800, 812") + "\n" +
+				fmt.Sprintf(procGroupsNameRow, "redis", 0) + "\n" +
+				fmt.Sprintf(procGroupsUsageRow, "0.00", 0, 0, 0) + "\n" +
+				fmt.Sprintf(procGroupsIORow, "0", "0"),
+		},
+		{
+			name: "no groups",
+			want: procGroupsMessageHeader + procGroupsNoGroups,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProcGroupsMessage(tt.groups); got != tt.want {
+				t.Errorf("ProcGroupsMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_MemInfoMessage(t *testing.T) {
+	base := models.MemoryDetails{
+		TotalB: 16000, FreeB: 1000, AvailableB: 9000, BuffersB: 400, CachedB: 7000,
+		SwapTotalB: 2000, SwapFreeB: 1500, DirtyB: 40, WritebackB: 8,
+		SlabB: 800, SlabReclaimableB: 600, SlabUnreclaimableB: 200,
+		CommittedAsB: 15000, CommitLimitB: 10000,
+	}
+	baseRows := memInfoMessageHeader +
+		fmt.Sprintf(memInfoTotalsRow, 16000, 1000, 9000, 400, 7000) + "\n" +
+		fmt.Sprintf(memInfoSwapRow, 1500, 2000) + "\n" +
+		fmt.Sprintf(memInfoWritebackRow, 40, 8) + "\n" +
+		fmt.Sprintf(memInfoSlabRow, 800, 600, 200) + "\n" +
+		fmt.Sprintf(memInfoCommitRow, 15000, 10000, "150.00") + "\n"
+	withNuma := base
+	withNuma.AnonHugePagesB = 4096
+	withNuma.HugePages = []models.HugePagePool{{SizeB: 2 << 20, Total: 16, Free: 10, Reserved: 2, Surplus: 1}}
+	withNuma.TransparentHugePages = models.TransparentHugePages{Enabled: "madvise", Defrag: "defer"}
+	withNuma.NumaNodes = []models.NumaNode{
+		{ID: 0, TotalB: 8000, FreeB: 500, HugePages: []models.HugePagePool{{SizeB: 2 << 20, Total: 8, Free: 5}}},
+		{ID: 1, TotalB: 8000, FreeB: 500},
+	}
+
+	tests := []struct {
+		name    string
+		details models.MemoryDetails
+		want    string
+	}{
+		{
+			name:    "hugepages and NUMA nodes",
+			details: withNuma,
+			want: baseRows +
+				memInfoHugePagesRow + "\n" +
+				fmt.Sprintf(memInfoPoolRow, 2048, 16, 10, 2, 1) + "\n" +
+				fmt.Sprintf(memInfoThpRow, "madvise", "defer", 4096) + "\n" +
+				memInfoNodesRow + "\n" +
+				fmt.Sprintf(memInfoNodeRow, 0, 500, 8000) + "\n" +
+				fmt.Sprintf(memInfoNodePoolRow, 2048, 8, 5) + "\n" +
+				fmt.Sprintf(memInfoNodeRow, 1, 500, 8000),
+		},
+		{
+			name:    "without hugepages and NUMA",
+			details: base,
+			want:    baseRows + memInfoNoThpRow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MemInfoMessage(tt.details); got != tt.want {
+				t.Errorf("MemInfoMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_MountsMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		mounts []models.MountStatus
+		want   string
+	}{
+		{
+			name: "healthy, read-only and stale mounts",
+			mounts: []models.MountStatus{
+				{Path: "/", Device: "/dev/sda1", Fstype: "ext4", Options: []string{"rw", "relatime"}, Health: MountHealthOK,
+					TotalB: 1000, UsedB: 250, UsedPercent: 25, Inodes: 100, InodesFree: 40, InodesUsedPercent: 60},
+				{Path: "/data", Device: "/dev/sdb1", Fstype: "xfs", Options: []string{"ro", "noatime"}, ReadOnly: true, Health: MountHealthReadOnly,
+					TotalB: 2000, UsedB: 1000, UsedPercent: 50},
+				{Path: "/mnt/share", Device: "nas:/export", Fstype: "nfs4", Options: []string{"rw", "hard"}, Stale: true, Health: MountHealthStale},
+			},
+			want: mountsMessageHeader +
+				fmt.Sprintf(mountsRow, "/", "ext4", "/dev/sda1", "rw,relatime", "ok") + "\n" +
+				fmt.Sprintf(mountsUsageRow, 250, 1000, "25.00", 60, 100, "60.00") + "\n" +
+				fmt.Sprintf(mountsRow, "/data", "xfs", "/dev/sdb1", "ro,noatime", "read-only") + "\n" +
+				fmt.Sprintf(mountsUsageRow, 1000, 2000, "50.00", 0, 0, "0.00") + "\n" +
+				fmt.Sprintf(mountsRow, "/mnt/share", "nfs4", "nas:/export", "rw,hard", "stale") + "\n" +
+				mountsStaleRow + "\n" +
+				fmt.Sprintf(mountsUnhealthyRow, 2),
+		},
+		{
+			name: "no mounts",
+			want: mountsMessageHeader + "No mounts found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MountsMessage(tt.mounts); got != tt.want {
+				t.Errorf("MountsMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
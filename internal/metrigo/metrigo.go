@@ -1,103 +1,990 @@
 package metrigo
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/Matyjash/Metrigo/internal/config"
 	"github.com/Matyjash/Metrigo/internal/metrics"
 	"github.com/Matyjash/Metrigo/internal/models"
 )
 
-const defaultMeasureInterval = 200 * time.Millisecond
+const (
+	defaultMeasureInterval  = 200 * time.Millisecond
+	defaultMountStatTimeout = 5 * time.Second
+)
 
 type Metrigo struct {
 	metricsPuller metrics.MetricsPuller
+
+	// mu guards the settings below, which can be changed on config reload.
+	mu              sync.RWMutex
+	measureInterval time.Duration
+	defaultTimeout  time.Duration
+	timeouts        map[string]time.Duration
+	cgroupPath      string
+	containerSocket string
+	systemdUnits    []string
+	processGroups   []models.ProcessGroupSpec
+	mountTimeout    time.Duration
+	roots           metrics.Roots
+
+	// mountsMu guards writableMounts, the mount points seen read-write since the agent started.
+	mountsMu       sync.Mutex
+	writableMounts map[string]bool
 }
 
 func NewMetrigo() Metrigo {
+	return NewMetrigoWithPuller(metrics.NewGopsutilPuller())
+}
+
+// NewMetrigoWithPuller returns a Metrigo collecting metrics from metricsPuller instead of gopsutil.
+func NewMetrigoWithPuller(metricsPuller metrics.MetricsPuller) Metrigo {
 	return Metrigo{
-		metricsPuller: metrics.NewGopsutilPuller(),
+		metricsPuller: metricsPuller,
+	}
+}
+
+// SetMeasureInterval sets the window over which CPU usage is measured.
+func (m *Metrigo) SetMeasureInterval(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.measureInterval = interval
+}
+
+func (m *Metrigo) getMeasureInterval() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.measureInterval <= 0 {
+		return defaultMeasureInterval
+	}
+	return m.measureInterval
+}
+
+// SetTimeouts limits how long each collector may take. Collectors missing from timeouts use defaultTimeout,
+// a zero timeout means no limit other than the deadline of the caller's context.
+func (m *Metrigo) SetTimeouts(defaultTimeout time.Duration, timeouts map[string]time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaultTimeout = defaultTimeout
+	m.timeouts = timeouts
+}
+
+// Configure applies the collectors configuration.
+func (m *Metrigo) Configure(cfg config.CollectorsConfig) {
+	m.SetMeasureInterval(cfg.Intervals.CpuSample)
+	m.SetTimeouts(cfg.Timeout, cfg.Timeouts)
+	m.SetCgroupPath(cfg.Cgroup.Path)
+	m.SetContainerSocket(cfg.Containers.Socket)
+	m.SetSystemdPatterns(cfg.Systemd.Units)
+	m.SetProcessGroups(processGroupSpecs(cfg.ProcessGroups))
+	m.SetMountStatTimeout(cfg.Mounts.StatTimeout)
+	m.SetRoots(metrics.Roots{
+		Root: cfg.Roots.Root,
+		Proc: cfg.Roots.Proc,
+		Sys:  cfg.Roots.Sys,
+		Etc:  cfg.Roots.Etc,
+		Run:  cfg.Roots.Run,
+		Var:  cfg.Roots.Var,
+	})
+}
+
+// SetRoots sets the directories the host filesystems are read from.
+func (m *Metrigo) SetRoots(roots metrics.Roots) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.roots = roots
+}
+
+func (m *Metrigo) getRoots() metrics.Roots {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.roots
+}
+
+// SetCgroupPath sets the cgroup read by the cgroup and psi collectors, relative to the cgroupfs root.
+// An empty path reads the agent's own cgroup.
+func (m *Metrigo) SetCgroupPath(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cgroupPath = path
+}
+
+func (m *Metrigo) getCgroupPath() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cgroupPath
+}
+
+// SetSystemdPatterns sets the glob patterns of the units read by the systemd collector, all loaded units when empty.
+func (m *Metrigo) SetSystemdPatterns(patterns []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.systemdUnits = patterns
+}
+
+func (m *Metrigo) getSystemdPatterns() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.systemdUnits
+}
+
+// SetProcessGroups sets the process groups reported by the procgroups collector.
+func (m *Metrigo) SetProcessGroups(groups []models.ProcessGroupSpec) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.processGroups = groups
+}
+
+func (m *Metrigo) getProcessGroupSpecs() []models.ProcessGroupSpec {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.processGroups
+}
+
+func processGroupSpecs(groups []config.ProcessGroupConfig) []models.ProcessGroupSpec {
+	specs := make([]models.ProcessGroupSpec, len(groups))
+	for i, group := range groups {
+		specs[i] = models.ProcessGroupSpec{
+			Name:    group.Name,
+			Process: group.Process,
+			User:    group.User,
+			Cgroup:  group.Cgroup,
+			Unit:    group.Unit,
+		}
 	}
+	return specs
 }
 
-func (m *Metrigo) GetCpuInfo() ([]models.CpuInfo, error) {
-	logicalCpuCount, err := m.metricsPuller.GetLogicalCpuCount()
+// SetMountStatTimeout sets how long the usage of a mount is waited for before it is reported as stale.
+func (m *Metrigo) SetMountStatTimeout(timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mountTimeout = timeout
+}
+
+func (m *Metrigo) getMountStatTimeout() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.mountTimeout <= 0 {
+		return defaultMountStatTimeout
+	}
+	return m.mountTimeout
+}
+
+// SetContainerSocket sets the Unix socket of the container runtime API, the Docker socket when empty.
+func (m *Metrigo) SetContainerSocket(socket string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.containerSocket = socket
+}
+
+func (m *Metrigo) getContainerSocket() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.containerSocket == "" {
+		return metrics.DefaultContainerSocket
+	}
+	return m.containerSocket
+}
+
+func (m *Metrigo) getTimeout(collector string) time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if timeout, ok := m.timeouts[collector]; ok {
+		return timeout
+	}
+	return m.defaultTimeout
+}
+
+// collect runs fn with the timeout of the collector. It returns as soon as the context is done,
+// even if fn is blocked in a call that does not observe the context. Errors are returned as *CollectorError.
+func collect[T any](ctx context.Context, m *Metrigo, collector string, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx = metrics.WithRoots(ctx, m.getRoots())
+	if timeout := m.getTimeout(collector); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn(ctx)
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, newCollectorError(collector, r.err)
+	case <-ctx.Done():
+		var zero T
+		return zero, newCollectorError(collector, fmt.Errorf("%s collector: %w", collector, ctx.Err()))
+	}
+}
+
+func (m *Metrigo) GetCpuInfo(ctx context.Context) ([]models.CpuInfo, error) {
+	return collect(ctx, m, CollectorCpu, m.getCpuInfo)
+}
+
+// GetCpuInfoAndStats returns the per CPU info together with the CPU stats, both measured over the same interval.
+// It is used instead of GetCpuInfo and GetCpuStats when both are needed, as each one samples the CPUs again.
+func (m *Metrigo) GetCpuInfoAndStats(ctx context.Context) ([]models.CpuInfo, models.CpuStats, error) {
+	sample, err := collect(ctx, m, CollectorCpu, m.getCpuSample)
+	return sample.info, sample.stats, err
+}
+
+// cpuSample is the per CPU info and the CPU stats measured over the same interval.
+type cpuSample struct {
+	info  []models.CpuInfo
+	stats models.CpuStats
+}
+
+func (m *Metrigo) getCpuInfo(ctx context.Context) ([]models.CpuInfo, error) {
+	sample, err := m.getCpuSample(ctx)
+	return sample.info, err
+}
+
+func (m *Metrigo) getCpuSample(ctx context.Context) (cpuSample, error) {
+	logicalCpuCount, err := m.metricsPuller.GetLogicalCpuCount(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get CPU count info: %v", err)
+		return cpuSample{}, fmt.Errorf("failed to get CPU count info: %w", err)
 	}
 
-	usage, err := m.metricsPuller.GetCpuUsage(true, defaultMeasureInterval)
+	// The usage and the time breakdown are measured over the same interval concurrently.
+	type statsResult struct {
+		stats models.CpuStats
+		err   error
+	}
+	statsDone := make(chan statsResult, 1)
+	go func() {
+		stats, err := m.metricsPuller.GetCpuStats(ctx, m.getMeasureInterval())
+		statsDone <- statsResult{stats: stats, err: err}
+	}()
+
+	usage, err := m.metricsPuller.GetCpuUsage(ctx, true, m.getMeasureInterval())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get CPU usage: %v", err)
+		return cpuSample{}, fmt.Errorf("failed to get CPU usage: %w", err)
+	}
+
+	// Without the time breakdown, e.g. from a puller that does not read it, the usage is reported with empty times.
+	statsRes := <-statsDone
+	if errors.Is(statsRes.err, metrics.ErrNotSupported) {
+		statsRes = statsResult{}
+	}
+	if statsRes.err != nil {
+		return cpuSample{}, fmt.Errorf("failed to get CPU times: %w", statsRes.err)
 	}
 
-	cpuSpec, err := m.metricsPuller.GetCpusSpec()
+	cpuSpec, err := m.metricsPuller.GetCpusSpec(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get CPUs frequencies: %v", err)
+		return cpuSample{}, fmt.Errorf("failed to get CPUs frequencies: %w", err)
 	}
 
 	if len(usage) != logicalCpuCount {
-		return nil, fmt.Errorf("mismatched CPU count and usage length: %d, %d", logicalCpuCount, len(usage))
+		return cpuSample{}, fmt.Errorf("mismatched CPU count and usage length: %d, %d", logicalCpuCount, len(usage))
 	}
 
-	cpuInfo, err := m.buildCpuInfo(logicalCpuCount, cpuSpec, usage)
+	cpuInfo, err := m.buildCpuInfo(logicalCpuCount, cpuSpec, usage, statsRes.stats.PerCpu)
 	if err != nil {
-		return nil, fmt.Errorf("failed building cpu info object: %v", err)
+		return cpuSample{}, fmt.Errorf("failed building cpu info object: %w", err)
 	}
 
-	return cpuInfo, nil
+	// The spec frequency is often the nominal one, so the live cpufreq frequency is preferred where available.
+	frequencies, err := m.metricsPuller.GetCpuFrequencies(ctx)
+	if err != nil && !errors.Is(err, metrics.ErrNoCpuFreq) {
+		return cpuSample{}, fmt.Errorf("failed to get CPU frequencies: %w", err)
+	}
+	currentMhz := make(map[string]float64, len(frequencies))
+	for _, frequency := range frequencies {
+		currentMhz[frequency.Cpu] = frequency.CurrentMhz
+	}
+	for i := range cpuInfo {
+		if mhz := currentMhz[cpuInfo[i].ID]; mhz > 0 {
+			cpuInfo[i].FrequencyMhz = mhz
+		}
+	}
+
+	return cpuSample{info: cpuInfo, stats: statsRes.stats}, nil
+}
+
+func (m *Metrigo) GetTotalCpuUsage(ctx context.Context) (float64, error) {
+	return collect(ctx, m, CollectorCpu, m.getTotalCpuUsage)
 }
 
-func (m *Metrigo) GetTemperatures() ([]models.TemperatureSensor, error) {
-	temps, err := m.metricsPuller.GetTemperatures()
+func (m *Metrigo) getTotalCpuUsage(ctx context.Context) (float64, error) {
+	usage, err := m.metricsPuller.GetCpuUsage(ctx, false, m.getMeasureInterval())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get temperatures: %v", err)
+		return 0, fmt.Errorf("failed to get CPU usage: %w", err)
+	}
+	if len(usage) != 1 {
+		return 0, fmt.Errorf("unexpected total CPU usage length: %d", len(usage))
 	}
-	return temps, nil
+	return usage[0], nil
 }
 
-func (m *Metrigo) GetMemoryUsage() (models.MemoryUsage, error) {
-	usage, err := m.metricsPuller.GetVMMemoryUsage()
+// GetCpuStats returns the time spent in each CPU state, in aggregate and per logical CPU,
+// and the rate of context switches and interrupts.
+func (m *Metrigo) GetCpuStats(ctx context.Context) (models.CpuStats, error) {
+	return collect(ctx, m, CollectorCpu, m.getCpuStats)
+}
+
+func (m *Metrigo) getCpuStats(ctx context.Context) (models.CpuStats, error) {
+	stats, err := m.metricsPuller.GetCpuStats(ctx, m.getMeasureInterval())
+	if err != nil {
+		return stats, fmt.Errorf("failed to get CPU times: %w", err)
+	}
+	return stats, nil
+}
+
+func (m *Metrigo) GetTemperatures(ctx context.Context) ([]models.TemperatureSensor, error) {
+	return collect(ctx, m, CollectorTemp, m.getTemperatures)
+}
+
+func (m *Metrigo) getTemperatures(ctx context.Context) ([]models.TemperatureSensor, error) {
+	temps, err := m.metricsPuller.GetTemperatures(ctx)
 	if err != nil {
-		return usage, fmt.Errorf("failed to get memory usage: %v", err)
+		return nil, fmt.Errorf("failed to get temperatures: %w", err)
+	}
+	return groupTemperatures(temps), nil
+}
+
+// groupTemperatures orders the sensors by chip, keeping the order in which the chips and the sensors
+// of each chip were reported, and sets their status.
+func groupTemperatures(temps []models.TemperatureSensor) []models.TemperatureSensor {
+	chipOrder := make(map[string]int)
+	for _, temp := range temps {
+		if _, ok := chipOrder[temp.Chip]; !ok {
+			chipOrder[temp.Chip] = len(chipOrder)
+		}
+	}
+	grouped := make([]models.TemperatureSensor, len(temps))
+	copy(grouped, temps)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return chipOrder[grouped[i].Chip] < chipOrder[grouped[j].Chip]
+	})
+	for i := range grouped {
+		grouped[i].Status = TemperatureStatus(grouped[i])
+	}
+	return grouped
+}
+
+// Statuses of a temperature sensor.
+const (
+	TemperatureOk       = "ok"
+	TemperatureHigh     = "high"
+	TemperatureCritical = "critical"
+)
+
+// TemperatureStatus compares the temperature with the thresholds of the sensor.
+// Thresholds that are not reported are ignored.
+func TemperatureStatus(temp models.TemperatureSensor) string {
+	switch {
+	case temp.Critical > 0 && temp.Value >= temp.Critical:
+		return TemperatureCritical
+	case temp.High > 0 && temp.Value >= temp.High:
+		return TemperatureHigh
+	default:
+		return TemperatureOk
+	}
+}
+
+// FilterTemperatures returns the sensors of the chip.
+func FilterTemperatures(temps []models.TemperatureSensor, chip string) []models.TemperatureSensor {
+	var filtered []models.TemperatureSensor
+	for _, temp := range temps {
+		if temp.Chip == chip {
+			filtered = append(filtered, temp)
+		}
+	}
+	return filtered
+}
+
+func (m *Metrigo) GetMemoryUsage(ctx context.Context) (models.MemoryUsage, error) {
+	return collect(ctx, m, CollectorMem, m.getMemoryUsage)
+}
+
+func (m *Metrigo) getMemoryUsage(ctx context.Context) (models.MemoryUsage, error) {
+	usage, err := m.metricsPuller.GetVMMemoryUsage(ctx)
+	if err != nil {
+		return usage, fmt.Errorf("failed to get memory usage: %w", err)
 	}
 	return usage, nil
 }
 
-func (m *Metrigo) GetHostInfo() (models.HostInfo, error) {
-	hostInfo, err := m.metricsPuller.GetHostInfo()
+func (m *Metrigo) GetHostInfo(ctx context.Context) (models.HostInfo, error) {
+	return collect(ctx, m, CollectorHost, m.getHostInfo)
+}
+
+func (m *Metrigo) getHostInfo(ctx context.Context) (models.HostInfo, error) {
+	hostInfo, err := m.metricsPuller.GetHostInfo(ctx)
 	if err != nil {
-		return hostInfo, fmt.Errorf("failed to get host info: %v", err)
+		return hostInfo, fmt.Errorf("failed to get host info: %w", err)
 	}
 	return hostInfo, err
 }
 
-func (m *Metrigo) buildCpuInfo(logicalCpuCount int, cpuSpec []models.CpuSpec, usage []float64) ([]models.CpuInfo, error) {
-	if len(cpuSpec) != 1 && len(cpuSpec) != logicalCpuCount {
-		return nil, fmt.Errorf("not implemented yet, CPU info length (%d) and logicalCpuCount (%d) missmatch", len(cpuSpec), logicalCpuCount)
+// buildCpuInfo combines the per CPU measurements. The time breakdown is left out when perCpuTimes
+// does not match the CPU count, e.g. after a CPU was hot-plugged during the measurement.
+func (m *Metrigo) buildCpuInfo(logicalCpuCount int, cpuSpec []models.CpuSpec, usage []float64, perCpuTimes []models.CpuTimes) ([]models.CpuInfo, error) {
+	specs, err := expandCpuSpecs(logicalCpuCount, cpuSpec)
+	if err != nil {
+		return nil, err
 	}
 	cpus := make([]models.CpuInfo, logicalCpuCount)
 
 	for i := range logicalCpuCount {
-		freq := cpuSpec[0].FrequencyMhz
-		if len(cpuSpec) == logicalCpuCount {
-			freq = cpuSpec[i].FrequencyMhz
-		}
 		cpus[i] = models.CpuInfo{
-			ID:           fmt.Sprintf("cpu%d", i),
+			ID:           cpuID(i),
 			UsagePercent: usage[i],
-			CpuSpec:      models.CpuSpec{FrequencyMhz: freq},
+			CpuSpec:      specs[i],
+		}
+		if len(perCpuTimes) == logicalCpuCount {
+			cpus[i].Times = perCpuTimes[i]
 		}
 	}
 
 	return cpus, nil
 }
 
-func (m *Metrigo) GetNetInterfaces() ([]models.NetInterface, error) {
+func cpuID(i int) string {
+	return fmt.Sprintf("cpu%d", i)
+}
+
+// expandCpuSpecs returns the spec of each logical CPU. Linux reports a spec per logical CPU, while other
+// platforms report one per socket, or a single one for the host, with the count of logical CPUs it covers.
+func expandCpuSpecs(logicalCpuCount int, cpuSpec []models.CpuSpec) ([]models.CpuSpec, error) {
+	if len(cpuSpec) == logicalCpuCount {
+		return cpuSpec, nil
+	}
+	if len(cpuSpec) == 0 {
+		return nil, fmt.Errorf("no CPU specs for %d logical CPUs", logicalCpuCount)
+	}
+
+	covered := 0
+	for _, spec := range cpuSpec {
+		covered += int(spec.Cores)
+	}
+	perSpec := func(i int) int {
+		if covered == logicalCpuCount {
+			return int(cpuSpec[i].Cores)
+		}
+		return logicalCpuCount / len(cpuSpec)
+	}
+	if covered != logicalCpuCount && logicalCpuCount%len(cpuSpec) != 0 {
+		return nil, fmt.Errorf("cannot map %d CPU specs to %d logical CPUs", len(cpuSpec), logicalCpuCount)
+	}
+
+	specs := make([]models.CpuSpec, 0, logicalCpuCount)
+	for i, spec := range cpuSpec {
+		if spec.SocketID == "" {
+			spec.SocketID = strconv.Itoa(i)
+		}
+		for range perSpec(i) {
+			specs = append(specs, spec)
+		}
+	}
+	return specs, nil
+}
+
+// GetCpuFrequencies returns the current frequency, scaling limits, governor and throttling counts of each logical CPU.
+func (m *Metrigo) GetCpuFrequencies(ctx context.Context) ([]models.CpuFrequency, error) {
+	return collect(ctx, m, CollectorCpu, m.getCpuFrequencies)
+}
+
+func (m *Metrigo) getCpuFrequencies(ctx context.Context) ([]models.CpuFrequency, error) {
+	frequencies, err := m.metricsPuller.GetCpuFrequencies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CPU frequencies: %w", err)
+	}
+	return frequencies, nil
+}
+
+// GetCpuTopology returns the sockets with their model, cores and logical CPUs, and the physical core count.
+func (m *Metrigo) GetCpuTopology(ctx context.Context) (models.CpuTopology, error) {
+	return collect(ctx, m, CollectorCpu, m.getCpuTopology)
+}
+
+func (m *Metrigo) getCpuTopology(ctx context.Context) (models.CpuTopology, error) {
+	physicalCpuCount, err := m.metricsPuller.GetPhysicalCpuCount(ctx)
+	if err != nil {
+		return models.CpuTopology{}, fmt.Errorf("failed to get physical CPU count: %w", err)
+	}
+	logicalCpuCount, err := m.metricsPuller.GetLogicalCpuCount(ctx)
+	if err != nil {
+		return models.CpuTopology{}, fmt.Errorf("failed to get CPU count info: %w", err)
+	}
+	cpuSpec, err := m.metricsPuller.GetCpusSpec(ctx)
+	if err != nil {
+		return models.CpuTopology{}, fmt.Errorf("failed to get CPUs specs: %w", err)
+	}
+	specs, err := expandCpuSpecs(logicalCpuCount, cpuSpec)
+	if err != nil {
+		return models.CpuTopology{}, fmt.Errorf("failed building cpu topology: %w", err)
+	}
+	return buildCpuTopology(physicalCpuCount, specs), nil
+}
+
+// buildCpuTopology groups the logical CPUs by socket and core in the order they are reported.
+// A logical CPU without a core ID is treated as a core of its own.
+func buildCpuTopology(physicalCpuCount int, specs []models.CpuSpec) models.CpuTopology {
+	topology := models.CpuTopology{PhysicalCores: physicalCpuCount, LogicalCpus: len(specs)}
+	socketIndex := make(map[string]int)
+	coreIndex := make(map[[2]string]int)
+	for i, spec := range specs {
+		si, ok := socketIndex[spec.SocketID]
+		if !ok {
+			si = len(topology.Sockets)
+			socketIndex[spec.SocketID] = si
+			topology.Sockets = append(topology.Sockets, models.CpuSocket{
+				ID:          spec.SocketID,
+				VendorID:    spec.VendorID,
+				ModelName:   spec.ModelName,
+				Family:      spec.Family,
+				Model:       spec.Model,
+				Stepping:    spec.Stepping,
+				CacheSizeKB: spec.CacheSizeKB,
+				Flags:       spec.Flags,
+			})
+		}
+		socket := &topology.Sockets[si]
+		coreID := spec.CoreID
+		if coreID == "" {
+			coreID = strconv.Itoa(i)
+		}
+		key := [2]string{spec.SocketID, coreID}
+		ci, ok := coreIndex[key]
+		if !ok {
+			ci = len(socket.Cores)
+			coreIndex[key] = ci
+			socket.Cores = append(socket.Cores, models.CpuCore{ID: coreID})
+		}
+		socket.Cores[ci].Cpus = append(socket.Cores[ci].Cpus, cpuID(i))
+	}
+	return topology
+}
+
+func (m *Metrigo) GetNetInterfaces(ctx context.Context) ([]models.NetInterface, error) {
+	return collect(ctx, m, CollectorNet, m.getNetInterfaces)
+}
+
+func (m *Metrigo) getNetInterfaces(ctx context.Context) ([]models.NetInterface, error) {
 	//TODO: explore and potentially add here more net metrics and refactor the method
-	netInterfaces, err := m.metricsPuller.GetNetInterfaces()
+	netInterfaces, err := m.metricsPuller.GetNetInterfaces(ctx)
 	if err != nil {
-		return netInterfaces, fmt.Errorf("failed to get net interfaces: %v", err)
+		return netInterfaces, fmt.Errorf("failed to get net interfaces: %w", err)
 	}
 	return netInterfaces, err
 }
+
+func (m *Metrigo) GetDisksUsage(ctx context.Context) ([]models.DiskUsage, error) {
+	return collect(ctx, m, CollectorDisk, m.getDisksUsage)
+}
+
+func (m *Metrigo) getDisksUsage(ctx context.Context) ([]models.DiskUsage, error) {
+	disksUsage, err := m.metricsPuller.GetDisksUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disks usage: %w", err)
+	}
+	return disksUsage, nil
+}
+
+func (m *Metrigo) GetLoadAverage(ctx context.Context) (models.LoadAverage, error) {
+	return collect(ctx, m, CollectorLoad, m.getLoadAverage)
+}
+
+func (m *Metrigo) getLoadAverage(ctx context.Context) (models.LoadAverage, error) {
+	loadAverage, err := m.metricsPuller.GetLoadAverage(ctx)
+	if err != nil {
+		return loadAverage, fmt.Errorf("failed to get load average: %w", err)
+	}
+	return loadAverage, nil
+}
+
+func (m *Metrigo) GetCgroupStats(ctx context.Context) (models.CgroupStats, error) {
+	return collect(ctx, m, CollectorCgroup, m.getCgroupStats)
+}
+
+func (m *Metrigo) getCgroupStats(ctx context.Context) (models.CgroupStats, error) {
+	cgroupStats, err := m.metricsPuller.GetCgroupStats(ctx, m.getCgroupPath())
+	if err != nil {
+		return cgroupStats, fmt.Errorf("failed to get cgroup stats: %w", err)
+	}
+	return cgroupStats, nil
+}
+
+func (m *Metrigo) GetContainers(ctx context.Context) ([]models.ContainerStats, error) {
+	return collect(ctx, m, CollectorContainers, m.getContainers)
+}
+
+func (m *Metrigo) getContainers(ctx context.Context) ([]models.ContainerStats, error) {
+	containers, err := m.metricsPuller.GetContainers(ctx, m.getContainerSocket())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get containers: %w", err)
+	}
+	return containers, nil
+}
+
+func (m *Metrigo) GetHwmonSensors(ctx context.Context) ([]models.HwmonSensor, error) {
+	return collect(ctx, m, CollectorHwmon, m.getHwmonSensors)
+}
+
+func (m *Metrigo) getHwmonSensors(ctx context.Context) ([]models.HwmonSensor, error) {
+	sensors, err := m.metricsPuller.GetHwmonSensors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hwmon sensors: %w", err)
+	}
+	return sensors, nil
+}
+
+func (m *Metrigo) GetPressure(ctx context.Context) ([]models.PressureStats, error) {
+	return collect(ctx, m, CollectorPsi, m.getPressure)
+}
+
+func (m *Metrigo) getPressure(ctx context.Context) ([]models.PressureStats, error) {
+	pressure, err := m.metricsPuller.GetPressure(ctx, m.getCgroupPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pressure stall information: %w", err)
+	}
+	return pressure, nil
+}
+
+func (m *Metrigo) GetSocketStats(ctx context.Context) (models.SocketStats, error) {
+	return collect(ctx, m, CollectorConns, m.getSocketStats)
+}
+
+func (m *Metrigo) getSocketStats(ctx context.Context) (models.SocketStats, error) {
+	stats, err := m.metricsPuller.GetSocketStats(ctx)
+	if err != nil {
+		return models.SocketStats{}, fmt.Errorf("failed to get socket statistics: %w", err)
+	}
+	sort.SliceStable(stats.Connections, func(i, j int) bool {
+		a, b := stats.Connections[i], stats.Connections[j]
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.LocalPort < b.LocalPort
+	})
+	return stats, nil
+}
+
+// IsListener reports whether the socket accepts connections: a listening TCP socket or an unconnected UDP one.
+func IsListener(conn models.Connection) bool {
+	return conn.State == metrics.ConnListen || conn.State == metrics.ConnUnconnected
+}
+
+// FilterConnections returns the sockets with the local or remote port, when port is not 0,
+// and with the state, case-insensitive, when state is not empty.
+func FilterConnections(conns []models.Connection, port uint32, state string) []models.Connection {
+	var filtered []models.Connection
+	for _, conn := range conns {
+		if port != 0 && conn.LocalPort != port && conn.RemotePort != port {
+			continue
+		}
+		if state != "" && !strings.EqualFold(conn.State, state) {
+			continue
+		}
+		filtered = append(filtered, conn)
+	}
+	return filtered
+}
+
+// ConnectionCount is the number of sockets of a protocol in a state.
+type ConnectionCount struct {
+	Protocol string
+	State    string
+	Count    int
+}
+
+// CountConnections counts the sockets by protocol and state, sorted by protocol and state.
+func CountConnections(conns []models.Connection) []ConnectionCount {
+	counts := make(map[[2]string]int)
+	for _, conn := range conns {
+		counts[[2]string{conn.Protocol, conn.State}]++
+	}
+	result := make([]ConnectionCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, ConnectionCount{Protocol: key[0], State: key[1], Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Protocol != result[j].Protocol {
+			return result[i].Protocol < result[j].Protocol
+		}
+		return result[i].State < result[j].State
+	})
+	return result
+}
+
+func (m *Metrigo) GetSystemdUnits(ctx context.Context) ([]models.SystemdUnit, error) {
+	return collect(ctx, m, CollectorSystemd, m.getSystemdUnits)
+}
+
+// GetSystemdUnitsMatching returns the systemd units whose name matches the glob pattern, all of them when it is empty.
+func (m *Metrigo) GetSystemdUnitsMatching(ctx context.Context, pattern string) ([]models.SystemdUnit, error) {
+	units, err := m.GetSystemdUnits(ctx)
+	if err != nil || pattern == "" {
+		return units, err
+	}
+	return FilterSystemdUnits(units, pattern)
+}
+
+func (m *Metrigo) getSystemdUnits(ctx context.Context) ([]models.SystemdUnit, error) {
+	units, err := m.metricsPuller.GetSystemdUnits(ctx, m.getSystemdPatterns())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get systemd units: %w", err)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		return units[i].Name < units[j].Name
+	})
+	return units, nil
+}
+
+// SystemdUnitFailed is the active state of a unit that failed.
+const SystemdUnitFailed = models.SystemdUnitFailed
+
+// FailedSystemdUnits returns the units in the failed state.
+func FailedSystemdUnits(units []models.SystemdUnit) []models.SystemdUnit {
+	var failed []models.SystemdUnit
+	for _, unit := range units {
+		if unit.ActiveState == SystemdUnitFailed {
+			failed = append(failed, unit)
+		}
+	}
+	return failed
+}
+
+// FilterSystemdUnits returns the units whose name matches the glob pattern, e.g. "nginx*".
+func FilterSystemdUnits(units []models.SystemdUnit, pattern string) ([]models.SystemdUnit, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid unit pattern %q: %v", pattern, err)
+	}
+	var filtered []models.SystemdUnit
+	for _, unit := range units {
+		if matched, _ := path.Match(pattern, unit.Name); matched {
+			filtered = append(filtered, unit)
+		}
+	}
+	return filtered, nil
+}
+
+func (m *Metrigo) GetKernelLimits(ctx context.Context) (models.KernelLimits, error) {
+	return collect(ctx, m, CollectorLimits, m.getKernelLimits)
+}
+
+// getKernelLimits returns the processes ordered by their file descriptor usage, the closest to their limit first.
+func (m *Metrigo) getKernelLimits(ctx context.Context) (models.KernelLimits, error) {
+	limits, err := m.metricsPuller.GetKernelLimits(ctx)
+	if err != nil {
+		return models.KernelLimits{}, fmt.Errorf("failed to get kernel limits: %w", err)
+	}
+	sort.SliceStable(limits.Processes, func(i, j int) bool {
+		a, b := limits.Processes[i], limits.Processes[j]
+		if usageA, usageB := FdUsagePercent(a), FdUsagePercent(b); usageA != usageB {
+			return usageA > usageB
+		}
+		return a.OpenFds > b.OpenFds
+	})
+	return limits, nil
+}
+
+// FdUsagePercent returns the open file descriptors of the process in percent of its soft limit, 0 when it is unlimited.
+func FdUsagePercent(process models.ProcessFds) float64 {
+	return usagePercent(process.OpenFds, process.SoftLimit)
+}
+
+func usagePercent(used, limit uint64) float64 {
+	if limit == 0 {
+		return 0
+	}
+	return float64(used) / float64(limit) * 100
+}
+
+func (m *Metrigo) GetProcessGroups(ctx context.Context) ([]models.ProcessGroup, error) {
+	return collect(ctx, m, CollectorProcGroups, m.getProcessGroups)
+}
+
+// getProcessGroups returns the configured groups in their order, including the ones without processes.
+func (m *Metrigo) getProcessGroups(ctx context.Context) ([]models.ProcessGroup, error) {
+	specs := m.getProcessGroupSpecs()
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	matched, err := m.findProcesses(ctx, specs, m.getMeasureInterval())
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]models.ProcessGroup, len(specs))
+	for i, processes := range matched {
+		group := models.ProcessGroup{Name: specs[i].Name}
+		for _, process := range processes {
+			group.Pids = append(group.Pids, process.Pid)
+			group.CpuPercent += process.CpuPercent
+			group.RssB += process.RssB
+			group.ReadBps += process.ReadBps
+			group.WriteBps += process.WriteBps
+			group.Threads += process.Threads
+			group.OpenFds += process.OpenFds
+		}
+		groups[i] = group
+	}
+	return groups, nil
+}
+
+// FindProcesses returns the processes matching each spec, ordered by pid, without their CPU usage and I/O rates.
+func (m *Metrigo) FindProcesses(ctx context.Context, specs []models.ProcessGroupSpec) ([][]models.ProcessStats, error) {
+	return collect(ctx, m, CollectorProcGroups, func(ctx context.Context) ([][]models.ProcessStats, error) {
+		return m.findProcesses(ctx, specs, 0)
+	})
+}
+
+func (m *Metrigo) findProcesses(ctx context.Context, specs []models.ProcessGroupSpec, interval time.Duration) ([][]models.ProcessStats, error) {
+	matchers := make([]processMatcher, len(specs))
+	for i, spec := range specs {
+		matcher, err := newProcessMatcher(spec)
+		if err != nil {
+			return nil, err
+		}
+		matchers[i] = matcher
+	}
+
+	processes, err := m.metricsPuller.GetProcesses(ctx, interval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processes: %w", err)
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].Pid < processes[j].Pid
+	})
+
+	matched := make([][]models.ProcessStats, len(specs))
+	for i, matcher := range matchers {
+		for _, process := range processes {
+			if matcher.matches(process) {
+				matched[i] = append(matched[i], process)
+			}
+		}
+	}
+	return matched, nil
+}
+
+// processMatcher selects the processes of a group, see models.ProcessGroupSpec.
+type processMatcher struct {
+	spec    models.ProcessGroupSpec
+	process *regexp.Regexp
+}
+
+func newProcessMatcher(spec models.ProcessGroupSpec) (processMatcher, error) {
+	matcher := processMatcher{spec: spec}
+	if spec.Process != "" {
+		process, err := regexp.Compile(spec.Process)
+		if err != nil {
+			return processMatcher{}, fmt.Errorf("invalid process pattern of group %s: %v", spec.Name, err)
+		}
+		matcher.process = process
+	}
+	if _, err := path.Match(spec.Unit, ""); err != nil {
+		return processMatcher{}, fmt.Errorf("invalid unit pattern of group %s: %v", spec.Name, err)
+	}
+	return matcher, nil
+}
+
+func (pm processMatcher) matches(process models.ProcessStats) bool {
+	if pm.process != nil && !pm.process.MatchString(process.Name) && !pm.process.MatchString(process.Cmdline) {
+		return false
+	}
+	if pm.spec.User != "" && pm.spec.User != process.User {
+		return false
+	}
+	if cgroup := strings.TrimSuffix(pm.spec.Cgroup, "/"); pm.spec.Cgroup != "" &&
+		process.Cgroup != cgroup && !strings.HasPrefix(process.Cgroup, cgroup+"/") {
+		return false
+	}
+	if pm.spec.Unit != "" {
+		if matched, _ := path.Match(pm.spec.Unit, process.Unit); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Metrigo) GetMemoryDetails(ctx context.Context) (models.MemoryDetails, error) {
+	return collect(ctx, m, CollectorMemInfo, m.getMemoryDetails)
+}
+
+func (m *Metrigo) getMemoryDetails(ctx context.Context) (models.MemoryDetails, error) {
+	details, err := m.metricsPuller.GetMemoryDetails(ctx)
+	if err != nil {
+		return models.MemoryDetails{}, fmt.Errorf("failed to get memory details: %w", err)
+	}
+	return details, nil
+}
+
+// Health states of the mounts, from the worst to the best. They are described in the models package.
+const (
+	MountHealthStale           = models.MountHealthStale
+	MountHealthReadOnly        = models.MountHealthReadOnly
+	MountHealthInodesExhausted = models.MountHealthInodesExhausted
+	MountHealthOK              = models.MountHealthOK
+)
+
+// MountInodesExhaustedPercent is the inode usage from which a mount is reported as MountHealthInodesExhausted.
+const MountInodesExhaustedPercent = 95
+
+func (m *Metrigo) GetMounts(ctx context.Context) ([]models.MountStatus, error) {
+	return collect(ctx, m, CollectorMounts, m.getMounts)
+}
+
+// getMounts returns the mounts ordered by path with their health.
+func (m *Metrigo) getMounts(ctx context.Context) ([]models.MountStatus, error) {
+	mounts, err := m.metricsPuller.GetMounts(ctx, m.getMountStatTimeout())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mounts: %w", err)
+	}
+	sort.Slice(mounts, func(i, j int) bool {
+		return mounts[i].Path < mounts[j].Path
+	})
+
+	m.mountsMu.Lock()
+	defer m.mountsMu.Unlock()
+	if m.writableMounts == nil {
+		m.writableMounts = map[string]bool{}
+	}
+	for i, mount := range mounts {
+		if !mount.ReadOnly && !mount.Stale {
+			m.writableMounts[mount.Path] = true
+		}
+		switch {
+		case mount.Stale:
+			mounts[i].Health = MountHealthStale
+		case mount.ReadOnly && (mount.FstabWritable || m.writableMounts[mount.Path]):
+			mounts[i].Health = MountHealthReadOnly
+		case mount.InodesUsedPercent >= MountInodesExhaustedPercent:
+			mounts[i].Health = MountHealthInodesExhausted
+		default:
+			mounts[i].Health = MountHealthOK
+		}
+	}
+	return mounts, nil
+}
+
+// UnhealthyMounts returns the mounts whose health is not MountHealthOK.
+func UnhealthyMounts(mounts []models.MountStatus) []models.MountStatus {
+	var unhealthy []models.MountStatus
+	for _, mount := range mounts {
+		if mount.Health != MountHealthOK {
+			unhealthy = append(unhealthy, mount)
+		}
+	}
+	return unhealthy
+}
@@ -1,12 +1,17 @@
 package metrigo
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/Matyjash/Metrigo/internal/metrics"
 	"github.com/Matyjash/Metrigo/internal/models"
 )
 
@@ -15,36 +20,92 @@ type mockMetricsPuller struct {
 	getPhysicalCpuCount func() (int, error)
 	getCpuUsage         func(bool, time.Duration) ([]float64, error)
 	getCpusSpec         func() ([]models.CpuSpec, error)
+	getCpuStats         func(time.Duration) (models.CpuStats, error)
+	getCpuFrequencies   func() ([]models.CpuFrequency, error)
 	getVMMemoryUsage    func() (models.MemoryUsage, error)
 	getTemperatures     func() ([]models.TemperatureSensor, error)
 	getHostInfo         func() (models.HostInfo, error)
 	getNetInterfaces    func() ([]models.NetInterface, error)
+	getDisksUsage       func() ([]models.DiskUsage, error)
+	getLoadAverage      func() (models.LoadAverage, error)
+	getCgroupStats      func(string) (models.CgroupStats, error)
+	getContainers       func(string) ([]models.ContainerStats, error)
+	getHwmonSensors     func() ([]models.HwmonSensor, error)
+	getPressure         func(string) ([]models.PressureStats, error)
+	getSocketStats      func() (models.SocketStats, error)
+	getSystemdUnits     func([]string) ([]models.SystemdUnit, error)
+	getKernelLimits     func() (models.KernelLimits, error)
+	getProcesses        func(time.Duration) ([]models.ProcessStats, error)
+	getMemoryDetails    func() (models.MemoryDetails, error)
+	getMounts           func(time.Duration) ([]models.MountStatus, error)
 }
 
-func (m *mockMetricsPuller) GetLogicalCpuCount() (int, error) {
+func (m *mockMetricsPuller) GetLogicalCpuCount(ctx context.Context) (int, error) {
 	return m.getLogicalCpuCount()
 }
-func (m *mockMetricsPuller) GetPhysicalCpuCount() (int, error) {
+func (m *mockMetricsPuller) GetPhysicalCpuCount(ctx context.Context) (int, error) {
 	return m.getPhysicalCpuCount()
 }
-func (m *mockMetricsPuller) GetCpuUsage(percpu bool, interval time.Duration) ([]float64, error) {
+func (m *mockMetricsPuller) GetCpuUsage(ctx context.Context, percpu bool, interval time.Duration) ([]float64, error) {
 	return m.getCpuUsage(percpu, interval)
 }
-func (m *mockMetricsPuller) GetCpusSpec() ([]models.CpuSpec, error) {
+func (m *mockMetricsPuller) GetCpusSpec(ctx context.Context) ([]models.CpuSpec, error) {
 	return m.getCpusSpec()
 }
-func (m *mockMetricsPuller) GetVMMemoryUsage() (models.MemoryUsage, error) {
+func (m *mockMetricsPuller) GetCpuStats(ctx context.Context, interval time.Duration) (models.CpuStats, error) {
+	return m.getCpuStats(interval)
+}
+func (m *mockMetricsPuller) GetCpuFrequencies(ctx context.Context) ([]models.CpuFrequency, error) {
+	return m.getCpuFrequencies()
+}
+func (m *mockMetricsPuller) GetVMMemoryUsage(ctx context.Context) (models.MemoryUsage, error) {
 	return m.getVMMemoryUsage()
 }
-func (m *mockMetricsPuller) GetTemperatures() ([]models.TemperatureSensor, error) {
+func (m *mockMetricsPuller) GetTemperatures(ctx context.Context) ([]models.TemperatureSensor, error) {
 	return m.getTemperatures()
 }
-func (m *mockMetricsPuller) GetHostInfo() (models.HostInfo, error) {
+func (m *mockMetricsPuller) GetHostInfo(ctx context.Context) (models.HostInfo, error) {
 	return m.getHostInfo()
 }
-func (m *mockMetricsPuller) GetNetInterfaces() ([]models.NetInterface, error) {
+func (m *mockMetricsPuller) GetNetInterfaces(ctx context.Context) ([]models.NetInterface, error) {
 	return m.getNetInterfaces()
 }
+func (m *mockMetricsPuller) GetDisksUsage(ctx context.Context) ([]models.DiskUsage, error) {
+	return m.getDisksUsage()
+}
+func (m *mockMetricsPuller) GetLoadAverage(ctx context.Context) (models.LoadAverage, error) {
+	return m.getLoadAverage()
+}
+func (m *mockMetricsPuller) GetCgroupStats(ctx context.Context, path string) (models.CgroupStats, error) {
+	return m.getCgroupStats(path)
+}
+func (m *mockMetricsPuller) GetContainers(ctx context.Context, socket string) ([]models.ContainerStats, error) {
+	return m.getContainers(socket)
+}
+func (m *mockMetricsPuller) GetKernelLimits(ctx context.Context) (models.KernelLimits, error) {
+	return m.getKernelLimits()
+}
+func (m *mockMetricsPuller) GetProcesses(ctx context.Context, interval time.Duration) ([]models.ProcessStats, error) {
+	return m.getProcesses(interval)
+}
+func (m *mockMetricsPuller) GetMemoryDetails(ctx context.Context) (models.MemoryDetails, error) {
+	return m.getMemoryDetails()
+}
+func (m *mockMetricsPuller) GetMounts(ctx context.Context, statTimeout time.Duration) ([]models.MountStatus, error) {
+	return m.getMounts(statTimeout)
+}
+func (m *mockMetricsPuller) GetSystemdUnits(ctx context.Context, patterns []string) ([]models.SystemdUnit, error) {
+	return m.getSystemdUnits(patterns)
+}
+func (m *mockMetricsPuller) GetSocketStats(ctx context.Context) (models.SocketStats, error) {
+	return m.getSocketStats()
+}
+func (m *mockMetricsPuller) GetPressure(ctx context.Context, cgroupPath string) ([]models.PressureStats, error) {
+	return m.getPressure(cgroupPath)
+}
+func (m *mockMetricsPuller) GetHwmonSensors(ctx context.Context) ([]models.HwmonSensor, error) {
+	return m.getHwmonSensors()
+}
 
 // Defaults
 var (
@@ -53,14 +114,25 @@ var (
 		{FrequencyMhz: 3200},
 	}
 	defaultLogicalCpuCount = 2
+	defaultCpuStats        = models.CpuStats{
+		Total: models.CpuTimes{User: 10, System: 5, Idle: 80, Iowait: 3, Steal: 2},
+		PerCpu: []models.CpuTimes{
+			{User: 6, System: 4, Idle: 89.5, Iowait: 0.5},
+			{User: 14, System: 6, Idle: 70.5, Iowait: 5.5, Steal: 4},
+		},
+		ContextSwitchesPerSec: 1500,
+		InterruptsPerSec:      900,
+	}
 
 	defaultGetLogicalCpuCount = func() (int, error) { return defaultLogicalCpuCount, nil }
 	defaultGetCpuUsage        = func(percpu bool, interval time.Duration) ([]float64, error) { return defaultCpuUsage, nil }
 	defaultGetCpusSpecs       = func() ([]models.CpuSpec, error) { return defaultCpuSpecs, nil }
+	defaultGetCpuStats        = func(time.Duration) (models.CpuStats, error) { return defaultCpuStats, nil }
+	defaultGetCpuFrequencies  = func() ([]models.CpuFrequency, error) { return nil, metrics.ErrNoCpuFreq }
 
 	defaultExpectedCpuInfo = []models.CpuInfo{
-		{ID: "cpu0", UsagePercent: 10.5, CpuSpec: models.CpuSpec{FrequencyMhz: 3200}},
-		{ID: "cpu1", UsagePercent: 20.5, CpuSpec: models.CpuSpec{FrequencyMhz: 3200}},
+		{ID: "cpu0", UsagePercent: 10.5, CpuSpec: models.CpuSpec{FrequencyMhz: 3200, SocketID: "0"}},
+		{ID: "cpu1", UsagePercent: 20.5, CpuSpec: models.CpuSpec{FrequencyMhz: 3200, SocketID: "0"}},
 	}
 
 	defaultHostInfo = models.HostInfo{
@@ -75,6 +147,11 @@ var (
 		{Name: "eth2", Index: 2, Addressess: []string{"192.168.1.10/24", "fe80::a00:27ff:fe4e:66a1/64"}, MTU: 1500},
 		{Name: "lo", Index: 1, Addressess: []string{"127.0.0.1/8", "::1/128"}, MTU: 65536},
 	}
+
+	defaultDisksUsage = []models.DiskUsage{
+		{Path: "/", Fstype: "ext4", TotalB: 1000, UsedB: 400, FreeB: 600, UsedPercent: 40},
+		{Path: "/boot", Fstype: "vfat", TotalB: 100, UsedB: 90, FreeB: 10, UsedPercent: 90},
+	}
 )
 
 func Test_GetCpuInfo(t *testing.T) {
@@ -83,12 +160,37 @@ func Test_GetCpuInfo(t *testing.T) {
 		logicalCpuCountFunc func() (int, error)
 		cpuUsageFunc        func(bool, time.Duration) ([]float64, error)
 		cpusSpecFunc        func() ([]models.CpuSpec, error)
+		cpuStatsFunc        func(time.Duration) (models.CpuStats, error)
+		cpuFrequenciesFunc  func() ([]models.CpuFrequency, error)
 		wantReturn          []models.CpuInfo
 		wantErrContains     string
 	}{
 		{
-			name:       "successfully gets cpu info",
-			wantReturn: defaultExpectedCpuInfo,
+			name: "successfully gets cpu info",
+			wantReturn: []models.CpuInfo{
+				{ID: "cpu0", UsagePercent: 10.5, Times: defaultCpuStats.PerCpu[0], CpuSpec: models.CpuSpec{FrequencyMhz: 3200, SocketID: "0"}},
+				{ID: "cpu1", UsagePercent: 20.5, Times: defaultCpuStats.PerCpu[1], CpuSpec: models.CpuSpec{FrequencyMhz: 3200, SocketID: "0"}},
+			},
+		},
+		{
+			name: "live cpufreq frequency replaces the spec frequency",
+			cpuFrequenciesFunc: func() ([]models.CpuFrequency, error) {
+				return []models.CpuFrequency{{Cpu: "cpu1", CurrentMhz: 800}}, nil
+			},
+			wantReturn: []models.CpuInfo{
+				{ID: "cpu0", UsagePercent: 10.5, Times: defaultCpuStats.PerCpu[0], CpuSpec: models.CpuSpec{FrequencyMhz: 3200, SocketID: "0"}},
+				{ID: "cpu1", UsagePercent: 20.5, Times: defaultCpuStats.PerCpu[1], CpuSpec: models.CpuSpec{FrequencyMhz: 800, SocketID: "0"}},
+			},
+		},
+		{
+			name:               "cpu frequencies error",
+			cpuFrequenciesFunc: func() ([]models.CpuFrequency, error) { return nil, fmt.Errorf("fail") },
+			wantErrContains:    "failed to get CPU frequencies",
+		},
+		{
+			name:            "cpu times error",
+			cpuStatsFunc:    func(time.Duration) (models.CpuStats, error) { return models.CpuStats{}, fmt.Errorf("fail") },
+			wantErrContains: "failed to get CPU times",
 		},
 		{
 			name:                "logical cpu count error",
@@ -118,6 +220,8 @@ func Test_GetCpuInfo(t *testing.T) {
 				getLogicalCpuCount:  defaultGetLogicalCpuCount,
 				getCpuUsage:         defaultGetCpuUsage,
 				getCpusSpec:         defaultGetCpusSpecs,
+				getCpuStats:         defaultGetCpuStats,
+				getCpuFrequencies:   defaultGetCpuFrequencies,
 				getPhysicalCpuCount: nil,
 			}
 
@@ -130,11 +234,17 @@ func Test_GetCpuInfo(t *testing.T) {
 			if tt.cpusSpecFunc != nil {
 				mock.getCpusSpec = tt.cpusSpecFunc
 			}
+			if tt.cpuStatsFunc != nil {
+				mock.getCpuStats = tt.cpuStatsFunc
+			}
+			if tt.cpuFrequenciesFunc != nil {
+				mock.getCpuFrequencies = tt.cpuFrequenciesFunc
+			}
 
 			m := Metrigo{}
 			m.metricsPuller = mock
 
-			cpus, err := m.GetCpuInfo()
+			cpus, err := m.GetCpuInfo(context.Background())
 			if tt.wantErrContains != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
 					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
@@ -157,6 +267,7 @@ func Test_buildCpuInfo(t *testing.T) {
 		logicalCpuCount int
 		cpuSpec         []models.CpuSpec
 		usage           []float64
+		perCpuTimes     []models.CpuTimes
 	}
 	tests := []struct {
 		name            string
@@ -188,6 +299,29 @@ func Test_buildCpuInfo(t *testing.T) {
 				{ID: "cpu1", UsagePercent: defaultCpuUsage[1], CpuSpec: models.CpuSpec{FrequencyMhz: 3200}},
 			},
 		},
+		{
+			name: "success with per cpu times",
+			args: args{
+				logicalCpuCount: 2,
+				cpuSpec:         defaultCpuSpecs,
+				usage:           defaultCpuUsage,
+				perCpuTimes:     defaultCpuStats.PerCpu,
+			},
+			wantReturn: []models.CpuInfo{
+				{ID: "cpu0", UsagePercent: 10.5, Times: defaultCpuStats.PerCpu[0], CpuSpec: models.CpuSpec{FrequencyMhz: 3200, SocketID: "0"}},
+				{ID: "cpu1", UsagePercent: 20.5, Times: defaultCpuStats.PerCpu[1], CpuSpec: models.CpuSpec{FrequencyMhz: 3200, SocketID: "0"}},
+			},
+		},
+		{
+			name: "per cpu times not matching logical cpu count are left out",
+			args: args{
+				logicalCpuCount: 2,
+				cpuSpec:         defaultCpuSpecs,
+				usage:           defaultCpuUsage,
+				perCpuTimes:     defaultCpuStats.PerCpu[:1],
+			},
+			wantReturn: defaultExpectedCpuInfo,
+		},
 		{
 			name: "success with single cpu, single cpu spec",
 			args: args{
@@ -199,6 +333,40 @@ func Test_buildCpuInfo(t *testing.T) {
 				{ID: "cpu0", UsagePercent: 10.5, CpuSpec: defaultCpuSpecs[0]},
 			},
 		},
+		{
+			name: "success with one spec per socket covering its logical cpus",
+			args: args{
+				logicalCpuCount: 4,
+				cpuSpec: []models.CpuSpec{
+					{FrequencyMhz: 2500, SocketID: "CPU0", Cores: 2},
+					{FrequencyMhz: 2600, SocketID: "CPU1", Cores: 2},
+				},
+				usage: []float64{1, 2, 3, 4},
+			},
+			wantReturn: []models.CpuInfo{
+				{ID: "cpu0", UsagePercent: 1, CpuSpec: models.CpuSpec{FrequencyMhz: 2500, SocketID: "CPU0", Cores: 2}},
+				{ID: "cpu1", UsagePercent: 2, CpuSpec: models.CpuSpec{FrequencyMhz: 2500, SocketID: "CPU0", Cores: 2}},
+				{ID: "cpu2", UsagePercent: 3, CpuSpec: models.CpuSpec{FrequencyMhz: 2600, SocketID: "CPU1", Cores: 2}},
+				{ID: "cpu3", UsagePercent: 4, CpuSpec: models.CpuSpec{FrequencyMhz: 2600, SocketID: "CPU1", Cores: 2}},
+			},
+		},
+		{
+			name: "success with one spec per socket without logical cpu counts",
+			args: args{
+				logicalCpuCount: 4,
+				cpuSpec: []models.CpuSpec{
+					{FrequencyMhz: 2500},
+					{FrequencyMhz: 2600},
+				},
+				usage: []float64{1, 2, 3, 4},
+			},
+			wantReturn: []models.CpuInfo{
+				{ID: "cpu0", UsagePercent: 1, CpuSpec: models.CpuSpec{FrequencyMhz: 2500, SocketID: "0"}},
+				{ID: "cpu1", UsagePercent: 2, CpuSpec: models.CpuSpec{FrequencyMhz: 2500, SocketID: "0"}},
+				{ID: "cpu2", UsagePercent: 3, CpuSpec: models.CpuSpec{FrequencyMhz: 2600, SocketID: "1"}},
+				{ID: "cpu3", UsagePercent: 4, CpuSpec: models.CpuSpec{FrequencyMhz: 2600, SocketID: "1"}},
+			},
+		},
 		{
 			// Cpu count: 3, usage count:3, spec count:2
 			name: "spec length not one and not matching logical cpu count",
@@ -214,7 +382,7 @@ func Test_buildCpuInfo(t *testing.T) {
 					47.01,
 				},
 			},
-			wantErrContains: "not implemented yet",
+			wantErrContains: "cannot map 2 CPU specs to 3 logical CPUs",
 		},
 	}
 
@@ -225,6 +393,7 @@ func Test_buildCpuInfo(t *testing.T) {
 			tt.args.logicalCpuCount,
 			tt.args.cpuSpec,
 			tt.args.usage,
+			tt.args.perCpuTimes,
 		)
 		if tt.wantErrContains != "" {
 			if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
@@ -259,8 +428,23 @@ func Test_GetTemperatures(t *testing.T) {
 				}, nil
 			},
 			wantReturn: []models.TemperatureSensor{
-				{Key: "sensor1", Value: 45.0},
-				{Key: "sensor2", Value: 50.0},
+				{Key: "sensor1", Value: 45.0, Status: TemperatureOk},
+				{Key: "sensor2", Value: 50.0, Status: TemperatureOk},
+			},
+		},
+		{
+			name: "groups sensors by chip and sets their status",
+			getTemperatures: func() ([]models.TemperatureSensor, error) {
+				return []models.TemperatureSensor{
+					{Key: "coretemp_package_id_0", Chip: "coretemp", Value: 85, High: 80, Critical: 100},
+					{Key: "nvme_composite", Chip: "nvme", Value: 90, High: 70, Critical: 85},
+					{Key: "coretemp_core_0", Chip: "coretemp", Value: 60, High: 80, Critical: 100},
+				}, nil
+			},
+			wantReturn: []models.TemperatureSensor{
+				{Key: "coretemp_package_id_0", Chip: "coretemp", Value: 85, High: 80, Critical: 100, Status: TemperatureHigh},
+				{Key: "coretemp_core_0", Chip: "coretemp", Value: 60, High: 80, Critical: 100, Status: TemperatureOk},
+				{Key: "nvme_composite", Chip: "nvme", Value: 90, High: 70, Critical: 85, Status: TemperatureCritical},
 			},
 		},
 		{
@@ -280,7 +464,7 @@ func Test_GetTemperatures(t *testing.T) {
 
 			m := Metrigo{}
 			m.metricsPuller = mock
-			temps, err := m.GetTemperatures()
+			temps, err := m.GetTemperatures(context.Background())
 			if tt.wantErrContains != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
 					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
@@ -329,7 +513,7 @@ func Test_GetMemoryUsage(t *testing.T) {
 			}
 			m := Metrigo{}
 			m.metricsPuller = mock
-			usage, err := m.GetMemoryUsage()
+			usage, err := m.GetMemoryUsage(context.Background())
 			if tt.wantErrContains != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
 					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
@@ -376,7 +560,7 @@ func Test_GetHostInfo(t *testing.T) {
 			}
 			m := Metrigo{}
 			m.metricsPuller = mock
-			usage, err := m.GetHostInfo()
+			usage, err := m.GetHostInfo(context.Background())
 			if tt.wantErrContains != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
 					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
@@ -423,7 +607,7 @@ func Test_GetNetInterfaces(t *testing.T) {
 			}
 			m := Metrigo{}
 			m.metricsPuller = mock
-			ifaces, err := m.GetNetInterfaces()
+			ifaces, err := m.GetNetInterfaces(context.Background())
 			if tt.wantErrContains != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
 					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
@@ -439,3 +623,991 @@ func Test_GetNetInterfaces(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetTotalCpuUsage(t *testing.T) {
+	tests := []struct {
+		name            string
+		getCpuUsage     func(bool, time.Duration) ([]float64, error)
+		wantReturn      float64
+		wantErrContains string
+	}{
+		{
+			name: "successfully gets total cpu usage",
+			getCpuUsage: func(perCpu bool, interval time.Duration) ([]float64, error) {
+				if perCpu {
+					return nil, fmt.Errorf("expected total usage request")
+				}
+				return []float64{42.5}, nil
+			},
+			wantReturn: 42.5,
+		},
+		{
+			name: "returns error when getting cpu usage fails",
+			getCpuUsage: func(bool, time.Duration) ([]float64, error) {
+				return nil, fmt.Errorf("fail")
+			},
+			wantErrContains: "failed to get CPU usage",
+		},
+		{
+			name: "returns error when usage length is unexpected",
+			getCpuUsage: func(bool, time.Duration) ([]float64, error) {
+				return []float64{10, 20}, nil
+			},
+			wantErrContains: "unexpected total CPU usage length",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockMetricsPuller{
+				getCpuUsage: tt.getCpuUsage,
+			}
+			m := Metrigo{}
+			m.metricsPuller = mock
+			usage, err := m.GetTotalCpuUsage(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if usage != tt.wantReturn {
+				t.Errorf("expected %v, got %v", tt.wantReturn, usage)
+			}
+		})
+	}
+}
+
+func Test_GetDisksUsage(t *testing.T) {
+	tests := []struct {
+		name            string
+		getDisksUsage   func() ([]models.DiskUsage, error)
+		wantReturn      []models.DiskUsage
+		wantErrContains string
+	}{
+		{
+			name: "successfully gets disks usage",
+			getDisksUsage: func() ([]models.DiskUsage, error) {
+				return defaultDisksUsage, nil
+			},
+			wantReturn: defaultDisksUsage,
+		},
+		{
+			name: "returns error when getting disks usage fails",
+			getDisksUsage: func() ([]models.DiskUsage, error) {
+				return nil, fmt.Errorf("unexpected error")
+			},
+			wantErrContains: "failed to get disks usage",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockMetricsPuller{
+				getDisksUsage: tt.getDisksUsage,
+			}
+			m := Metrigo{}
+			m.metricsPuller = mock
+			disks, err := m.GetDisksUsage(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, disks) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, disks)
+			}
+		})
+	}
+}
+
+func Test_GetLoadAverage(t *testing.T) {
+	tests := []struct {
+		name            string
+		getLoadAverage  func() (models.LoadAverage, error)
+		wantReturn      models.LoadAverage
+		wantErrContains string
+	}{
+		{
+			name: "successfully gets load average",
+			getLoadAverage: func() (models.LoadAverage, error) {
+				return models.LoadAverage{Load1: 0.5, Load5: 0.75, Load15: 1}, nil
+			},
+			wantReturn: models.LoadAverage{Load1: 0.5, Load5: 0.75, Load15: 1},
+		},
+		{
+			name: "returns error when getting load average fails",
+			getLoadAverage: func() (models.LoadAverage, error) {
+				return models.LoadAverage{}, fmt.Errorf("unexpected error")
+			},
+			wantErrContains: "failed to get load average",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockMetricsPuller{
+				getLoadAverage: tt.getLoadAverage,
+			}
+			m := Metrigo{}
+			m.metricsPuller = mock
+			loadAverage, err := m.GetLoadAverage(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, loadAverage) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, loadAverage)
+			}
+		})
+	}
+}
+
+func Test_CollectorTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	blockingLoadAverage := func() (models.LoadAverage, error) {
+		<-release
+		return models.LoadAverage{}, nil
+	}
+
+	tests := []struct {
+		name           string
+		defaultTimeout time.Duration
+		timeouts       map[string]time.Duration
+		ctx            func() (context.Context, context.CancelFunc)
+		wantErr        error
+	}{
+		{
+			name:     "per collector timeout",
+			timeouts: map[string]time.Duration{CollectorLoad: 10 * time.Millisecond},
+			ctx:      func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			wantErr:  context.DeadlineExceeded,
+		},
+		{
+			name:           "default timeout",
+			defaultTimeout: 10 * time.Millisecond,
+			timeouts:       map[string]time.Duration{CollectorCpu: time.Minute},
+			ctx:            func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			wantErr:        context.DeadlineExceeded,
+		},
+		{
+			name: "caller deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "caller cancellation",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Metrigo{}
+			m.metricsPuller = &mockMetricsPuller{getLoadAverage: blockingLoadAverage}
+			m.SetTimeouts(tt.defaultTimeout, tt.timeouts)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			_, err := m.GetLoadAverage(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got \"%v\"", tt.wantErr, err)
+			}
+			if !strings.Contains(err.Error(), "load collector") {
+				t.Errorf("expected error to name the collector, got \"%v\"", err)
+			}
+		})
+	}
+}
+
+func Test_CollectorErrorKinds(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind ErrorKind
+	}{
+		{
+			name:     "no sensors is not found",
+			err:      metrics.ErrNoTemperatureSensors,
+			wantKind: KindNotFound,
+		},
+		{
+			name:     "unsupported platform",
+			err:      fmt.Errorf("%w: not implemented yet", metrics.ErrNotSupported),
+			wantKind: KindUnsupported,
+		},
+		{
+			name:     "other errors are internal",
+			err:      fmt.Errorf("permission denied"),
+			wantKind: KindInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Metrigo{}
+			m.metricsPuller = &mockMetricsPuller{
+				getTemperatures: func() ([]models.TemperatureSensor, error) {
+					return nil, tt.err
+				},
+			}
+			_, err := m.GetTemperatures(context.Background())
+			var collectorErr *CollectorError
+			if !errors.As(err, &collectorErr) {
+				t.Fatalf("expected *CollectorError, got %T", err)
+			}
+			if collectorErr.Collector != CollectorTemp {
+				t.Errorf("expected collector %s, got %s", CollectorTemp, collectorErr.Collector)
+			}
+			if KindOf(err) != tt.wantKind {
+				t.Errorf("expected kind %v, got %v", tt.wantKind, KindOf(err))
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected error to wrap %v", tt.err)
+			}
+		})
+	}
+}
+
+func Test_GetCgroupStats(t *testing.T) {
+	tests := []struct {
+		name            string
+		cgroupPath      string
+		getCgroupStats  func(string) (models.CgroupStats, error)
+		wantReturn      models.CgroupStats
+		wantErrContains string
+	}{
+		{
+			name:       "configured cgroup path is passed to the puller",
+			cgroupPath: "/system.slice/app",
+			getCgroupStats: func(path string) (models.CgroupStats, error) {
+				return models.CgroupStats{Path: path, Version: 2}, nil
+			},
+			wantReturn: models.CgroupStats{Path: "/system.slice/app", Version: 2},
+		},
+		{
+			name: "error",
+			getCgroupStats: func(path string) (models.CgroupStats, error) {
+				return models.CgroupStats{}, fmt.Errorf("failed to read own cgroup")
+			},
+			wantErrContains: "failed to get cgroup stats: failed to read own cgroup",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{getCgroupStats: tt.getCgroupStats})
+			m.SetCgroupPath(tt.cgroupPath)
+			stats, err := m.GetCgroupStats(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, stats) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, stats)
+			}
+		})
+	}
+}
+
+func Test_GetHwmonSensors(t *testing.T) {
+	fan := models.HwmonSensor{Chip: "nct6775", Sensor: "fan1", Label: "CPU Fan", Type: metrics.HwmonFan, Unit: "rpm", Value: 1200, Min: floatPtr(300)}
+	tests := []struct {
+		name            string
+		getHwmonSensors func() ([]models.HwmonSensor, error)
+		wantReturn      []models.HwmonSensor
+		wantKind        ErrorKind
+		wantErrContains string
+	}{
+		{
+			name: "success",
+			getHwmonSensors: func() ([]models.HwmonSensor, error) {
+				return []models.HwmonSensor{fan}, nil
+			},
+			wantReturn: []models.HwmonSensor{fan},
+		},
+		{
+			name: "no sensors",
+			getHwmonSensors: func() ([]models.HwmonSensor, error) {
+				return nil, metrics.ErrNoHwmonSensors
+			},
+			wantKind:        KindNotFound,
+			wantErrContains: "failed to get hwmon sensors: no hardware monitoring sensors found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{getHwmonSensors: tt.getHwmonSensors})
+			sensors, err := m.GetHwmonSensors(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				if kind := KindOf(err); kind != tt.wantKind {
+					t.Errorf("expected kind %v, got %v", tt.wantKind, kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, sensors) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, sensors)
+			}
+		})
+	}
+}
+
+func Test_GetCpuStats(t *testing.T) {
+	tests := []struct {
+		name            string
+		measureInterval time.Duration
+		getCpuStats     func(time.Duration) (models.CpuStats, error)
+		wantReturn      models.CpuStats
+		wantErrContains string
+	}{
+		{
+			name:            "measure interval is passed to the puller",
+			measureInterval: time.Second,
+			getCpuStats: func(interval time.Duration) (models.CpuStats, error) {
+				if interval != time.Second {
+					return models.CpuStats{}, fmt.Errorf("unexpected interval %v", interval)
+				}
+				return defaultCpuStats, nil
+			},
+			wantReturn: defaultCpuStats,
+		},
+		{
+			name: "error",
+			getCpuStats: func(time.Duration) (models.CpuStats, error) {
+				return models.CpuStats{}, fmt.Errorf("fail")
+			},
+			wantErrContains: "failed to get CPU times: fail",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{getCpuStats: tt.getCpuStats})
+			m.SetMeasureInterval(tt.measureInterval)
+			stats, err := m.GetCpuStats(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, stats) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, stats)
+			}
+		})
+	}
+}
+
+func Test_GetCpuInfoAndStats(t *testing.T) {
+	var samples atomic.Int32
+	mock := &mockMetricsPuller{
+		getLogicalCpuCount: defaultGetLogicalCpuCount,
+		getCpuUsage:        defaultGetCpuUsage,
+		getCpusSpec:        defaultGetCpusSpecs,
+		getCpuStats: func(interval time.Duration) (models.CpuStats, error) {
+			samples.Add(1)
+			return defaultCpuStats, nil
+		},
+		getCpuFrequencies: defaultGetCpuFrequencies,
+	}
+	m := NewMetrigoWithPuller(mock)
+	cpus, stats, err := m.GetCpuInfoAndStats(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := samples.Load(); got != 1 {
+		t.Errorf("expected the CPU times to be sampled once, got %d", got)
+	}
+	if !reflect.DeepEqual(defaultCpuStats, stats) {
+		t.Errorf("expected %v, got %v", defaultCpuStats, stats)
+	}
+	for i, cpu := range cpus {
+		if !reflect.DeepEqual(stats.PerCpu[i], cpu.Times) {
+			t.Errorf("expected the times of %s from the stats sample, got %v", cpu.ID, cpu.Times)
+		}
+	}
+}
+
+func Test_GetCpuTopology(t *testing.T) {
+	xeon := models.CpuSpec{VendorID: "GenuineIntel", ModelName: "Xeon", Family: "6", Model: "85", Stepping: 4, CacheSizeKB: 16384, Flags: []string{"avx2"}}
+	spec := func(socketID, coreID string) models.CpuSpec {
+		s := xeon
+		s.SocketID = socketID
+		s.CoreID = coreID
+		return s
+	}
+	tests := []struct {
+		name             string
+		physicalCpuCount func() (int, error)
+		logicalCpuCount  func() (int, error)
+		cpusSpec         func() ([]models.CpuSpec, error)
+		wantReturn       models.CpuTopology
+		wantErrContains  string
+	}{
+		{
+			name:             "groups logical cpus by socket and core",
+			physicalCpuCount: func() (int, error) { return 3, nil },
+			logicalCpuCount:  func() (int, error) { return 5, nil },
+			cpusSpec: func() ([]models.CpuSpec, error) {
+				return []models.CpuSpec{spec("0", "0"), spec("0", "1"), spec("1", "0"), spec("0", "0"), spec("0", "1")}, nil
+			},
+			wantReturn: models.CpuTopology{
+				PhysicalCores: 3,
+				LogicalCpus:   5,
+				Sockets: []models.CpuSocket{
+					{ID: "0", VendorID: "GenuineIntel", ModelName: "Xeon", Family: "6", Model: "85", Stepping: 4, CacheSizeKB: 16384, Flags: []string{"avx2"}, Cores: []models.CpuCore{
+						{ID: "0", Cpus: []string{"cpu0", "cpu3"}},
+						{ID: "1", Cpus: []string{"cpu1", "cpu4"}},
+					}},
+					{ID: "1", VendorID: "GenuineIntel", ModelName: "Xeon", Family: "6", Model: "85", Stepping: 4, CacheSizeKB: 16384, Flags: []string{"avx2"}, Cores: []models.CpuCore{
+						{ID: "0", Cpus: []string{"cpu2"}},
+					}},
+				},
+			},
+		},
+		{
+			name:             "logical cpus without core id are cores of their own",
+			physicalCpuCount: func() (int, error) { return 2, nil },
+			logicalCpuCount:  func() (int, error) { return 2, nil },
+			cpusSpec:         func() ([]models.CpuSpec, error) { return []models.CpuSpec{{ModelName: "M2", Cores: 2}}, nil },
+			wantReturn: models.CpuTopology{
+				PhysicalCores: 2,
+				LogicalCpus:   2,
+				Sockets: []models.CpuSocket{
+					{ID: "0", ModelName: "M2", Cores: []models.CpuCore{{ID: "0", Cpus: []string{"cpu0"}}, {ID: "1", Cpus: []string{"cpu1"}}}},
+				},
+			},
+		},
+		{
+			name:             "physical cpu count error",
+			physicalCpuCount: func() (int, error) { return 0, fmt.Errorf("fail") },
+			wantErrContains:  "failed to get physical CPU count",
+		},
+		{
+			name:             "specs not matching logical cpus",
+			physicalCpuCount: func() (int, error) { return 2, nil },
+			logicalCpuCount:  func() (int, error) { return 3, nil },
+			cpusSpec:         func() ([]models.CpuSpec, error) { return []models.CpuSpec{{}, {}}, nil },
+			wantErrContains:  "cannot map 2 CPU specs to 3 logical CPUs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{
+				getPhysicalCpuCount: tt.physicalCpuCount,
+				getLogicalCpuCount:  tt.logicalCpuCount,
+				getCpusSpec:         tt.cpusSpec,
+			})
+			topology, err := m.GetCpuTopology(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, topology) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, topology)
+			}
+		})
+	}
+}
+
+func Test_GetPressure(t *testing.T) {
+	hostPressure := models.PressureStats{Resources: []models.ResourcePressure{
+		{Resource: "memory", Some: models.PressureStall{Avg10: 2.5, TotalSeconds: 10}, Full: models.PressureStall{Avg10: 1}, HasFull: true},
+	}}
+	tests := []struct {
+		name            string
+		cgroupPath      string
+		getPressure     func(string) ([]models.PressureStats, error)
+		wantReturn      []models.PressureStats
+		wantKind        ErrorKind
+		wantErrContains string
+	}{
+		{
+			name:       "configured cgroup path is passed to the puller",
+			cgroupPath: "/system.slice/app.service",
+			getPressure: func(path string) ([]models.PressureStats, error) {
+				return []models.PressureStats{hostPressure, {Cgroup: path}}, nil
+			},
+			wantReturn: []models.PressureStats{hostPressure, {Cgroup: "/system.slice/app.service"}},
+		},
+		{
+			name: "psi not enabled",
+			getPressure: func(string) ([]models.PressureStats, error) {
+				return nil, fmt.Errorf("%w: pressure stall information is not enabled in the kernel", metrics.ErrNotSupported)
+			},
+			wantKind:        KindUnsupported,
+			wantErrContains: "failed to get pressure stall information",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{getPressure: tt.getPressure})
+			m.SetCgroupPath(tt.cgroupPath)
+			pressure, err := m.GetPressure(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				if kind := KindOf(err); kind != tt.wantKind {
+					t.Errorf("expected kind %v, got %v", tt.wantKind, kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, pressure) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, pressure)
+			}
+		})
+	}
+}
+
+func Test_GetSocketStats(t *testing.T) {
+	listener := models.Connection{Protocol: "tcp", LocalAddr: "0.0.0.0", LocalPort: 22, State: "LISTEN", Pid: 812}
+	established := models.Connection{Protocol: "tcp", LocalAddr: "10.0.0.1", LocalPort: 22, RemoteAddr: "10.0.0.2", RemotePort: 51000, State: "ESTABLISHED"}
+	dns := models.Connection{Protocol: "udp", LocalAddr: "127.0.0.53", LocalPort: 53, State: "UNCONN"}
+	tests := []struct {
+		name            string
+		getSocketStats  func() (models.SocketStats, error)
+		wantReturn      models.SocketStats
+		wantErrContains string
+	}{
+		{
+			name: "connections sorted by protocol and local port",
+			getSocketStats: func() (models.SocketStats, error) {
+				return models.SocketStats{Connections: []models.Connection{dns, listener, established}, Counters: models.ProtocolCounters{TcpRetransSegs: 4}}, nil
+			},
+			wantReturn: models.SocketStats{Connections: []models.Connection{listener, established, dns}, Counters: models.ProtocolCounters{TcpRetransSegs: 4}},
+		},
+		{
+			name: "puller error",
+			getSocketStats: func() (models.SocketStats, error) {
+				return models.SocketStats{}, errors.New("permission denied")
+			},
+			wantErrContains: "failed to get socket statistics: permission denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{getSocketStats: tt.getSocketStats})
+			stats, err := m.GetSocketStats(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, stats) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, stats)
+			}
+		})
+	}
+}
+
+func Test_FilterConnections(t *testing.T) {
+	conns := []models.Connection{
+		{Protocol: "tcp", LocalPort: 22, State: "LISTEN"},
+		{Protocol: "tcp", LocalPort: 22, RemotePort: 51000, State: "ESTABLISHED"},
+		{Protocol: "tcp", LocalPort: 43000, RemotePort: 443, State: "TIME_WAIT"},
+		{Protocol: "udp", LocalPort: 53, State: "UNCONN"},
+	}
+	tests := []struct {
+		name       string
+		port       uint32
+		state      string
+		wantReturn []models.Connection
+	}{
+		{name: "no filter", wantReturn: conns},
+		{name: "local port", port: 22, wantReturn: conns[:2]},
+		{name: "remote port", port: 443, wantReturn: conns[2:3]},
+		{name: "state is case-insensitive", state: "time_wait", wantReturn: conns[2:3]},
+		{name: "port and state", port: 22, state: "LISTEN", wantReturn: conns[:1]},
+		{name: "no match", port: 8080},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterConnections(conns, tt.port, tt.state); !reflect.DeepEqual(tt.wantReturn, got) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, got)
+			}
+		})
+	}
+}
+
+func Test_GetSystemdUnits(t *testing.T) {
+	nginx := models.SystemdUnit{Name: "nginx.service", ActiveState: "active"}
+	backup := models.SystemdUnit{Name: "backup.service", ActiveState: "failed"}
+	tests := []struct {
+		name            string
+		patterns        []string
+		getSystemdUnits func([]string) ([]models.SystemdUnit, error)
+		wantReturn      []models.SystemdUnit
+		wantKind        ErrorKind
+		wantErrContains string
+	}{
+		{
+			name:     "configured patterns are passed to the puller and units sorted by name",
+			patterns: []string{"*.service"},
+			getSystemdUnits: func(patterns []string) ([]models.SystemdUnit, error) {
+				if !reflect.DeepEqual(patterns, []string{"*.service"}) {
+					return nil, fmt.Errorf("unexpected patterns %v", patterns)
+				}
+				return []models.SystemdUnit{nginx, backup}, nil
+			},
+			wantReturn: []models.SystemdUnit{backup, nginx},
+		},
+		{
+			name: "host without systemd",
+			getSystemdUnits: func([]string) ([]models.SystemdUnit, error) {
+				return nil, metrics.ErrNoSystemd
+			},
+			wantKind:        KindNotFound,
+			wantErrContains: "failed to get systemd units: systemd is not running",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{getSystemdUnits: tt.getSystemdUnits})
+			m.SetSystemdPatterns(tt.patterns)
+			units, err := m.GetSystemdUnits(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				if kind := KindOf(err); kind != tt.wantKind {
+					t.Errorf("expected kind %v, got %v", tt.wantKind, kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, units) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, units)
+			}
+		})
+	}
+}
+
+func Test_FilterSystemdUnits(t *testing.T) {
+	units := []models.SystemdUnit{{Name: "nginx.service"}, {Name: "nginx-exporter.service"}, {Name: "home.mount"}}
+	tests := []struct {
+		name            string
+		pattern         string
+		wantReturn      []models.SystemdUnit
+		wantErrContains string
+	}{
+		{name: "prefix", pattern: "nginx*", wantReturn: units[:2]},
+		{name: "type", pattern: "*.mount", wantReturn: units[2:]},
+		{name: "exact name", pattern: "nginx.service", wantReturn: units[:1]},
+		{name: "no match", pattern: "sshd*"},
+		{name: "invalid pattern", pattern: "[nginx", wantErrContains: "invalid unit pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilterSystemdUnits(units, tt.pattern)
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, got) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, got)
+			}
+		})
+	}
+}
+
+func Test_GetKernelLimits(t *testing.T) {
+	systemd := models.ProcessFds{Pid: 1, Name: "systemd", OpenFds: 100, SoftLimit: 1024}
+	nginx := models.ProcessFds{Pid: 42, Name: "nginx", OpenFds: 900, SoftLimit: 1024}
+	unlimited := models.ProcessFds{Pid: 7, Name: "java", OpenFds: 5000}
+	small := models.ProcessFds{Pid: 8, Name: "cron", OpenFds: 5}
+	tests := []struct {
+		name            string
+		getKernelLimits func() (models.KernelLimits, error)
+		wantReturn      models.KernelLimits
+		wantErrContains string
+	}{
+		{
+			name: "processes ordered by usage of their limit",
+			getKernelLimits: func() (models.KernelLimits, error) {
+				return models.KernelLimits{OpenFiles: 10, Processes: []models.ProcessFds{small, systemd, unlimited, nginx}}, nil
+			},
+			wantReturn: models.KernelLimits{OpenFiles: 10, Processes: []models.ProcessFds{nginx, systemd, unlimited, small}},
+		},
+		{
+			name: "puller error",
+			getKernelLimits: func() (models.KernelLimits, error) {
+				return models.KernelLimits{}, errors.New("permission denied")
+			},
+			wantErrContains: "failed to get kernel limits: permission denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{getKernelLimits: tt.getKernelLimits})
+			limits, err := m.GetKernelLimits(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, limits) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, limits)
+			}
+		})
+	}
+}
+
+func Test_GetMemoryDetails(t *testing.T) {
+	tests := []struct {
+		name             string
+		getMemoryDetails func() (models.MemoryDetails, error)
+		wantReturn       models.MemoryDetails
+		wantErrContains  string
+	}{
+		{
+			name: "memory details",
+			getMemoryDetails: func() (models.MemoryDetails, error) {
+				return models.MemoryDetails{TotalB: 1000, HugePages: []models.HugePagePool{{SizeB: 2 << 20, Total: 4}}}, nil
+			},
+			wantReturn: models.MemoryDetails{TotalB: 1000, HugePages: []models.HugePagePool{{SizeB: 2 << 20, Total: 4}}},
+		},
+		{
+			name: "puller error",
+			getMemoryDetails: func() (models.MemoryDetails, error) {
+				return models.MemoryDetails{}, errors.New("permission denied")
+			},
+			wantErrContains: "failed to get memory details: permission denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{getMemoryDetails: tt.getMemoryDetails})
+			details, err := m.GetMemoryDetails(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, details) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, details)
+			}
+		})
+	}
+}
+
+func Test_GetMounts(t *testing.T) {
+	root := models.MountStatus{Path: "/", Fstype: "ext4", InodesUsedPercent: 40}
+	full := models.MountStatus{Path: "/var", Fstype: "ext4", InodesUsedPercent: 97}
+	share := models.MountStatus{Path: "/mnt/share", Fstype: "nfs4", Stale: true}
+	fstabData := models.MountStatus{Path: "/data", Fstype: "xfs", ReadOnly: true, FstabWritable: true}
+	media := models.MountStatus{Path: "/media/cdrom", Fstype: "iso9660", ReadOnly: true}
+	withHealth := func(mount models.MountStatus, health string) models.MountStatus {
+		mount.Health = health
+		return mount
+	}
+	tests := []struct {
+		name            string
+		getMounts       func(time.Duration) ([]models.MountStatus, error)
+		wantReturn      []models.MountStatus
+		wantErrContains string
+	}{
+		{
+			name: "mounts ordered by path with their health",
+			getMounts: func(statTimeout time.Duration) ([]models.MountStatus, error) {
+				if statTimeout != 3*time.Second {
+					return nil, fmt.Errorf("unexpected stat timeout %v", statTimeout)
+				}
+				return []models.MountStatus{root, full, share, fstabData, media}, nil
+			},
+			wantReturn: []models.MountStatus{
+				withHealth(root, MountHealthOK),
+				withHealth(fstabData, MountHealthReadOnly),
+				withHealth(media, MountHealthOK),
+				withHealth(share, MountHealthStale),
+				withHealth(full, MountHealthInodesExhausted),
+			},
+		},
+		{
+			name: "puller error",
+			getMounts: func(time.Duration) ([]models.MountStatus, error) {
+				return nil, errors.New("permission denied")
+			},
+			wantErrContains: "failed to get mounts: permission denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{getMounts: tt.getMounts})
+			m.SetMountStatTimeout(3 * time.Second)
+			mounts, err := m.GetMounts(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, mounts) {
+				t.Errorf("expected %v, got %v", tt.wantReturn, mounts)
+			}
+		})
+	}
+}
+
+func Test_GetMountsRemountedReadOnly(t *testing.T) {
+	mount := models.MountStatus{Path: "/srv", Fstype: "ext4"}
+	m := NewMetrigoWithPuller(&mockMetricsPuller{getMounts: func(time.Duration) ([]models.MountStatus, error) {
+		return []models.MountStatus{mount}, nil
+	}})
+
+	for _, step := range []struct {
+		readOnly   bool
+		wantHealth string
+	}{
+		{readOnly: false, wantHealth: MountHealthOK},
+		{readOnly: true, wantHealth: MountHealthReadOnly},
+		{readOnly: false, wantHealth: MountHealthOK},
+	} {
+		mount.ReadOnly = step.readOnly
+		mounts, err := m.GetMounts(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if mounts[0].Health != step.wantHealth {
+			t.Errorf("read-only %v: expected health %s, got %s", step.readOnly, step.wantHealth, mounts[0].Health)
+		}
+	}
+}
+
+func Test_GetProcessGroups(t *testing.T) {
+	processes := []models.ProcessStats{
+		{Pid: 812, Name: "postgres", Cmdline: "postgres: checkpointer", User: "postgres", Cgroup: "/system.slice/postgresql@16-main.service", Unit: "postgresql@16-main.service",
+			CpuPercent: 1.5, RssB: 1000, ReadBps: 10, WriteBps: 20, Threads: 1, OpenFds: 10},
+		{Pid: 42, Name: "nginx", Cmdline: "nginx: worker process", User: "www-data", Cgroup: "/system.slice/nginx.service", Unit: "nginx.service",
+			CpuPercent: 3, RssB: 500, Threads: 1, OpenFds: 30},
+		{Pid: 800, Name: "postgres", Cmdline: "/usr/lib/postgresql/16/bin/postgres -D /var/lib/postgresql/16/main", User: "postgres", Cgroup: "/system.slice/postgresql@16-main.service", Unit: "postgresql@16-main.service",
+			CpuPercent: 0.5, RssB: 3000, ReadBps: 5, WriteBps: 1, Threads: 1, OpenFds: 20},
+		{Pid: 41, Name: "nginx", Cmdline: "nginx: master process /usr/sbin/nginx", User: "root", Cgroup: "/system.slice/nginx.service", Unit: "nginx.service",
+			RssB: 200, Threads: 1, OpenFds: 8},
+	}
+	getProcesses := func(time.Duration) ([]models.ProcessStats, error) {
+		return slices.Clone(processes), nil
+	}
+	tests := []struct {
+		name            string
+		groups          []models.ProcessGroupSpec
+		getProcesses    func(time.Duration) ([]models.ProcessStats, error)
+		wantReturn      []models.ProcessGroup
+		wantErrContains string
+	}{
+		{
+			name: "groups by name, user, cgroup and unit",
+			groups: []models.ProcessGroupSpec{
+				{Name: "postgres", Process: "^postgres"},
+				{Name: "nginx workers", Process: "nginx: worker", User: "www-data"},
+				{Name: "system", Cgroup: "/system.slice/"},
+				{Name: "postgres unit", Unit: "postgresql@*.service"},
+				{Name: "redis", Process: "redis-server"},
+			},
+			getProcesses: getProcesses,
+			wantReturn: []models.ProcessGroup{
+				{Name: "postgres", Pids: []int32{800, 812}, CpuPercent: 2, RssB: 4000, ReadBps: 15, WriteBps: 21, Threads: 2, OpenFds: 30},
+				{Name: "nginx workers", Pids: []int32{42}, CpuPercent: 3, RssB: 500, Threads: 1, OpenFds: 30},
+				{Name: "system", Pids: []int32{41, 42, 800, 812}, CpuPercent: 5, RssB: 4700, ReadBps: 15, WriteBps: 21, Threads: 4, OpenFds: 68},
+				{Name: "postgres unit", Pids: []int32{800, 812}, CpuPercent: 2, RssB: 4000, ReadBps: 15, WriteBps: 21, Threads: 2, OpenFds: 30},
+				{Name: "redis"},
+			},
+		},
+		{
+			name: "no groups configured",
+			getProcesses: func(time.Duration) ([]models.ProcessStats, error) {
+				return nil, errors.New("should not be called")
+			},
+		},
+		{
+			name:            "invalid process pattern",
+			groups:          []models.ProcessGroupSpec{{Name: "postgres", Process: "(postgres"}},
+			getProcesses:    getProcesses,
+			wantErrContains: "invalid process pattern of group postgres",
+		},
+		{
+			name:   "puller error",
+			groups: []models.ProcessGroupSpec{{Name: "postgres", Process: "^postgres"}},
+			getProcesses: func(time.Duration) ([]models.ProcessStats, error) {
+				return nil, errors.New("permission denied")
+			},
+			wantErrContains: "failed to get processes: permission denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrigoWithPuller(&mockMetricsPuller{getProcesses: tt.getProcesses})
+			m.SetProcessGroups(tt.groups)
+			groups, err := m.GetProcessGroups(context.Background())
+			if tt.wantErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("expected error containing %q, got \"%v\"", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.wantReturn, groups) {
+				t.Errorf("expected %+v, got %+v", tt.wantReturn, groups)
+			}
+		})
+	}
+}
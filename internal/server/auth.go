@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const authorizationHeader = "authorization"
+
+// TokenAuthInterceptor accepts only requests carrying "authorization: Bearer <token>" metadata with one of the tokens.
+// The tokens are looked up on every request so they can be rotated at runtime. Auth is disabled when there are none.
+func TokenAuthInterceptor(tokens func() []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, tokens()); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// TokenAuthStreamInterceptor applies the token auth of TokenAuthInterceptor to streaming calls.
+func TokenAuthStreamInterceptor(tokens func() []string) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(stream.Context(), tokens()); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func authorize(ctx context.Context, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing metadata")
+	}
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "missing authorization token")
+	}
+	token, found := strings.CutPrefix(values[0], "Bearer ")
+	if !found {
+		return status.Error(codes.Unauthenticated, "authorization must use the Bearer scheme")
+	}
+	for _, valid := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(valid)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid authorization token")
+}
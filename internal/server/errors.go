@@ -0,0 +1,57 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/Matyjash/Metrigo/internal/metrigo"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the ErrorInfo domain of the errors returned by the server.
+const errorDomain = "metrigo"
+
+// Reasons reported in ErrorInfo details besides the metrigo.ErrorKind names.
+const (
+	reasonCollectorDisabled = "COLLECTOR_DISABLED"
+	reasonUnknownCollector  = "UNKNOWN_COLLECTOR"
+	reasonInvalidPattern    = "INVALID_PATTERN"
+)
+
+var kindCodes = map[metrigo.ErrorKind]codes.Code{
+	metrigo.KindInternal:    codes.Internal,
+	metrigo.KindNotFound:    codes.NotFound,
+	metrigo.KindUnsupported: codes.Unimplemented,
+	metrigo.KindTimeout:     codes.DeadlineExceeded,
+	metrigo.KindCanceled:    codes.Canceled,
+}
+
+// collectionError maps a collection error to a status with its code and an ErrorInfo detail,
+// so clients can branch on the reason instead of matching messages.
+func collectionError(err error) error {
+	kind := metrigo.KindOf(err)
+	collector := ""
+	var collectorErr *metrigo.CollectorError
+	if errors.As(err, &collectorErr) {
+		collector = collectorErr.Collector
+	}
+	return statusError(kindCodes[kind], kind.String(), collector, err.Error())
+}
+
+// errorReason returns the ErrorInfo reason of a collection error.
+func errorReason(err error) string {
+	return metrigo.KindOf(err).String()
+}
+
+func statusError(code codes.Code, reason string, collector string, message string) error {
+	st := status.New(code, message)
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}
+	if collector != "" {
+		info.Metadata = map[string]string{"collector": collector}
+	}
+	if withDetails, err := st.WithDetails(info); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/config"
+	"github.com/Matyjash/Metrigo/internal/metrics"
+	"github.com/Matyjash/Metrigo/internal/metrigo"
+	"github.com/Matyjash/Metrigo/internal/models"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_collectionError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantReason string
+	}{
+		{
+			name:       "not found",
+			err:        &metrigo.CollectorError{Collector: "temp", Kind: metrigo.KindNotFound, Err: fmt.Errorf("no temperature sensors found")},
+			wantCode:   codes.NotFound,
+			wantReason: "NOT_FOUND",
+		},
+		{
+			name:       "unsupported",
+			err:        &metrigo.CollectorError{Collector: "temp", Kind: metrigo.KindUnsupported, Err: fmt.Errorf("not implemented yet")},
+			wantCode:   codes.Unimplemented,
+			wantReason: "UNSUPPORTED",
+		},
+		{
+			name:       "timeout",
+			err:        &metrigo.CollectorError{Collector: "temp", Kind: metrigo.KindTimeout, Err: context.DeadlineExceeded},
+			wantCode:   codes.DeadlineExceeded,
+			wantReason: "TIMEOUT",
+		},
+		{
+			name:       "unclassified error",
+			err:        fmt.Errorf("boom"),
+			wantCode:   codes.Internal,
+			wantReason: "INTERNAL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(collectionError(tt.err))
+			if st.Code() != tt.wantCode {
+				t.Errorf("expected code %v, got %v", tt.wantCode, st.Code())
+			}
+			if st.Message() != tt.err.Error() {
+				t.Errorf("expected message %q, got %q", tt.err.Error(), st.Message())
+			}
+			details := st.Details()
+			if len(details) != 1 {
+				t.Fatalf("expected 1 detail, got %d", len(details))
+			}
+			info, ok := details[0].(*errdetails.ErrorInfo)
+			if !ok {
+				t.Fatalf("expected *errdetails.ErrorInfo, got %T", details[0])
+			}
+			if info.Reason != tt.wantReason || info.Domain != errorDomain {
+				t.Errorf("expected reason %s in domain %s, got %s in %s", tt.wantReason, errorDomain, info.Reason, info.Domain)
+			}
+		})
+	}
+}
+
+// failingPuller fails the cgroup collection with err. The other methods are not called.
+type failingPuller struct {
+	metrics.MetricsPuller
+	err error
+}
+
+func (p failingPuller) GetCgroupStats(ctx context.Context, path string) (models.CgroupStats, error) {
+	return models.CgroupStats{}, p.err
+}
+
+func Test_collectionErrorClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantReason string
+	}{
+		{
+			name:       "missing file",
+			err:        fmt.Errorf("failed to read own cgroup: %w", &fs.PathError{Op: "open", Path: "/proc/self/cgroup", Err: fs.ErrNotExist}),
+			wantCode:   codes.NotFound,
+			wantReason: "NOT_FOUND",
+		},
+		{
+			name:       "missing cgroup",
+			err:        fmt.Errorf("%w: /system.slice/nginx.service", metrics.ErrNoCgroup),
+			wantCode:   codes.NotFound,
+			wantReason: "NOT_FOUND",
+		},
+		{
+			name:       "pressure stall information not enabled",
+			err:        fmt.Errorf("%w: pressure stall information is not enabled in the kernel", metrics.ErrNotSupported),
+			wantCode:   codes.Unimplemented,
+			wantReason: "UNSUPPORTED",
+		},
+		{
+			name:       "systemctl not installed",
+			err:        fmt.Errorf("%w: %v", metrics.ErrNoSystemd, exec.ErrNotFound),
+			wantCode:   codes.NotFound,
+			wantReason: "NOT_FOUND",
+		},
+		{
+			name:       "utmp not implemented",
+			err:        fmt.Errorf("%w: not implemented yet", metrics.ErrNotSupported),
+			wantCode:   codes.Unimplemented,
+			wantReason: "UNSUPPORTED",
+		},
+		{
+			name:       "unclassified error",
+			err:        fmt.Errorf("failed to parse cpu.max: invalid syntax"),
+			wantCode:   codes.Internal,
+			wantReason: "INTERNAL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := metrigo.NewMetrigoWithPuller(failingPuller{err: tt.err})
+			_, err := m.GetCgroupStats(context.Background())
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			st := status.Convert(collectionError(err))
+			if st.Code() != tt.wantCode {
+				t.Errorf("expected code %v, got %v", tt.wantCode, st.Code())
+			}
+			details := st.Details()
+			if len(details) != 1 {
+				t.Fatalf("expected 1 detail, got %d", len(details))
+			}
+			if info, ok := details[0].(*errdetails.ErrorInfo); !ok || info.Reason != tt.wantReason {
+				t.Errorf("expected reason %s, got %+v", tt.wantReason, details[0])
+			}
+		})
+	}
+}
+
+func Test_checkEnabled(t *testing.T) {
+	s := NewServer(nil, nil, config.CollectorsConfig{Enabled: []string{metrigo.CollectorCpu}}, nil, nil)
+
+	st := status.Convert(s.checkEnabled(metrigo.CollectorMem))
+	if st.Code() != codes.FailedPrecondition {
+		t.Errorf("expected code %v, got %v", codes.FailedPrecondition, st.Code())
+	}
+	details := st.Details()
+	if len(details) != 1 {
+		t.Fatalf("expected 1 detail, got %d", len(details))
+	}
+	if info, ok := details[0].(*errdetails.ErrorInfo); !ok || info.Reason != reasonCollectorDisabled {
+		t.Errorf("expected reason %s, got %+v", reasonCollectorDisabled, details[0])
+	}
+
+	s.SetCollectors(config.CollectorsConfig{Enabled: []string{metrigo.CollectorCpu, metrigo.CollectorMem}})
+	if err := s.checkEnabled(metrigo.CollectorMem); err != nil {
+		t.Errorf("expected the collector to be enabled after the reload, got %v", err)
+	}
+}
@@ -2,27 +2,78 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/Matyjash/Metrigo/internal/collector"
+	"github.com/Matyjash/Metrigo/internal/config"
 	"github.com/Matyjash/Metrigo/internal/metrigo"
+	"github.com/Matyjash/Metrigo/internal/models"
 	pb "github.com/Matyjash/Metrigo/pb"
+	"google.golang.org/grpc/codes"
 )
 
+type StatusProvider interface {
+	Status() models.AgentStatus
+}
+
+// ProcessEventSource delivers the events of the process watcher until ctx is done, when the channel is closed.
+type ProcessEventSource interface {
+	Subscribe(ctx context.Context) <-chan models.ProcessEvent
+}
+
 type Server struct {
 	pb.UnimplementedMetrigoServer
-	metrigo metrigo.Metrigo
+	metrigo        *metrigo.Metrigo
+	registry       *collector.Registry
+	statusProvider StatusProvider
+	processEvents  ProcessEventSource
+
+	mu         sync.RWMutex
+	collectors config.CollectorsConfig
 }
 
-func NewServer(metrigo metrigo.Metrigo) *Server {
+func NewServer(metrigo *metrigo.Metrigo, registry *collector.Registry, collectors config.CollectorsConfig, statusProvider StatusProvider, processEvents ProcessEventSource) *Server {
 	return &Server{
-		metrigo: metrigo,
+		metrigo:        metrigo,
+		registry:       registry,
+		collectors:     collectors,
+		statusProvider: statusProvider,
+		processEvents:  processEvents,
 	}
 }
 
+// SetCollectors replaces the collectors configuration without affecting in-flight requests.
+func (s *Server) SetCollectors(collectors config.CollectorsConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collectors = collectors
+}
+
+func (s *Server) isEnabled(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.collectors.IsEnabled(name)
+}
+
+func (s *Server) checkEnabled(name string) error {
+	if !s.isEnabled(name) {
+		return statusError(codes.FailedPrecondition, reasonCollectorDisabled, name, fmt.Sprintf("collector %s is disabled", name))
+	}
+	return nil
+}
+
 func (s *Server) GetMemoryUsage(ctx context.Context, req *pb.MemoryUsageReq) (*pb.MemoryUsageRes, error) {
-	memoryUsage, err := s.metrigo.GetMemoryUsage()
-	if err != nil {
+	if err := s.checkEnabled(metrigo.CollectorMem); err != nil {
 		return nil, err
 	}
+
+	memoryUsage, err := s.metrigo.GetMemoryUsage(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
 	return &pb.MemoryUsageRes{
 		TotalB: memoryUsage.TotalB,
 		UsedB:  memoryUsage.UsedB,
@@ -30,34 +81,149 @@ func (s *Server) GetMemoryUsage(ctx context.Context, req *pb.MemoryUsageReq) (*p
 }
 
 func (s *Server) GetCpuInfo(ctx context.Context, req *pb.CpuInfoReq) (*pb.CpuInfoRes, error) {
-	cpuInfo, err := s.metrigo.GetCpuInfo()
-	if err != nil {
+	if err := s.checkEnabled(metrigo.CollectorCpu); err != nil {
 		return nil, err
 	}
 
+	cpuInfo, err := s.metrigo.GetCpuInfo(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
 	cpuInfosRes := make([]*pb.CpuInfo, len(cpuInfo))
 	for i, info := range cpuInfo {
 		cpuInfosRes[i] = &pb.CpuInfo{
 			Id:           info.ID,
 			UsagePercent: float32(info.UsagePercent),
 			Frequency:    float32(info.FrequencyMhz),
+			Times:        cpuTimesPb(info.Times),
+			SocketId:     info.SocketID,
+			CoreId:       info.CoreID,
 		}
 	}
 
 	return &pb.CpuInfoRes{CpuInfo: cpuInfosRes}, nil
 }
 
-func (s *Server) GetTemperatures(ctx context.Context, req *pb.TemperatureReq) (*pb.TemperatureRes, error) {
-	temperatures, err := s.metrigo.GetTemperatures()
+func (s *Server) GetCpuStats(ctx context.Context, req *pb.CpuStatsReq) (*pb.CpuStatsRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorCpu); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.metrigo.GetCpuStats(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	perCpu := make([]*pb.CpuTimes, len(stats.PerCpu))
+	for i, times := range stats.PerCpu {
+		perCpu[i] = cpuTimesPb(times)
+	}
+	return &pb.CpuStatsRes{
+		Total:                 cpuTimesPb(stats.Total),
+		PerCpu:                perCpu,
+		ContextSwitchesPerSec: stats.ContextSwitchesPerSec,
+		InterruptsPerSec:      stats.InterruptsPerSec,
+	}, nil
+}
+
+func (s *Server) GetCpuTopology(ctx context.Context, req *pb.CpuTopologyReq) (*pb.CpuTopologyRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorCpu); err != nil {
+		return nil, err
+	}
+
+	topology, err := s.metrigo.GetCpuTopology(ctx)
 	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	socketsPb := make([]*pb.CpuSocket, len(topology.Sockets))
+	for i, socket := range topology.Sockets {
+		coresPb := make([]*pb.CpuCore, len(socket.Cores))
+		for j, core := range socket.Cores {
+			coresPb[j] = &pb.CpuCore{Id: core.ID, Cpus: core.Cpus}
+		}
+		socketsPb[i] = &pb.CpuSocket{
+			Id:          socket.ID,
+			VendorId:    socket.VendorID,
+			ModelName:   socket.ModelName,
+			Family:      socket.Family,
+			Model:       socket.Model,
+			Stepping:    socket.Stepping,
+			CacheSizeKB: socket.CacheSizeKB,
+			Flags:       socket.Flags,
+			Cores:       coresPb,
+		}
+	}
+	return &pb.CpuTopologyRes{
+		PhysicalCores: int64(topology.PhysicalCores),
+		LogicalCpus:   int64(topology.LogicalCpus),
+		Sockets:       socketsPb,
+	}, nil
+}
+
+func (s *Server) GetCpuFrequencies(ctx context.Context, req *pb.CpuFrequenciesReq) (*pb.CpuFrequenciesRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorCpu); err != nil {
 		return nil, err
 	}
 
+	frequencies, err := s.metrigo.GetCpuFrequencies(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	frequenciesPb := make([]*pb.CpuFrequency, len(frequencies))
+	for i, frequency := range frequencies {
+		frequenciesPb[i] = &pb.CpuFrequency{
+			Cpu:                         frequency.Cpu,
+			CurrentMhz:                  frequency.CurrentMhz,
+			MinMhz:                      frequency.MinMhz,
+			MaxMhz:                      frequency.MaxMhz,
+			HardwareMinMhz:              frequency.HardwareMinMhz,
+			HardwareMaxMhz:              frequency.HardwareMaxMhz,
+			Governor:                    frequency.Governor,
+			EnergyPerformancePreference: frequency.EnergyPerformancePreference,
+			CoreThrottleCount:           frequency.CoreThrottleCount,
+			PackageThrottleCount:        frequency.PackageThrottleCount,
+		}
+	}
+	return &pb.CpuFrequenciesRes{Frequencies: frequenciesPb}, nil
+}
+
+func cpuTimesPb(times models.CpuTimes) *pb.CpuTimes {
+	return &pb.CpuTimes{
+		User:      times.User,
+		System:    times.System,
+		Idle:      times.Idle,
+		Nice:      times.Nice,
+		Iowait:    times.Iowait,
+		Irq:       times.Irq,
+		Softirq:   times.Softirq,
+		Steal:     times.Steal,
+		Guest:     times.Guest,
+		GuestNice: times.GuestNice,
+	}
+}
+
+func (s *Server) GetTemperatures(ctx context.Context, req *pb.TemperatureReq) (*pb.TemperatureRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorTemp); err != nil {
+		return nil, err
+	}
+
+	temperatures, err := s.metrigo.GetTemperatures(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
 	temperaturesRes := make([]*pb.TemperatureSensor, len(temperatures))
 	for i, temperature := range temperatures {
 		temperaturesRes[i] = &pb.TemperatureSensor{
-			Key:   temperature.Key,
-			Value: float32(temperature.Value),
+			Key:      temperature.Key,
+			Value:    float32(temperature.Value),
+			Chip:     temperature.Chip,
+			High:     float32(temperature.High),
+			Critical: float32(temperature.Critical),
+			Status:   temperature.Status,
 		}
 	}
 
@@ -65,26 +231,53 @@ func (s *Server) GetTemperatures(ctx context.Context, req *pb.TemperatureReq) (*
 }
 
 func (s *Server) GetHostInfo(ctx context.Context, req *pb.HostInfoReq) (*pb.HostInfoRes, error) {
-	hostInfo, err := s.metrigo.GetHostInfo()
-	if err != nil {
+	if err := s.checkEnabled(metrigo.CollectorHost); err != nil {
 		return nil, err
 	}
 
+	hostInfo, err := s.metrigo.GetHostInfo(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	usersPb := make([]*pb.UserSession, len(hostInfo.Users))
+	for i, session := range hostInfo.Users {
+		usersPb[i] = &pb.UserSession{
+			User:     session.User,
+			Terminal: session.Terminal,
+			Host:     session.Host,
+			Started:  session.Started,
+		}
+	}
+
 	return &pb.HostInfoRes{
-		Hostname:        hostInfo.Hostname,
-		Os:              hostInfo.OS,
-		Platform:        hostInfo.Platform,
-		PlatformVersion: hostInfo.PlatformVersion,
-		Uptime:          hostInfo.Uptime,
+		Hostname:             hostInfo.Hostname,
+		Os:                   hostInfo.OS,
+		Platform:             hostInfo.Platform,
+		PlatformVersion:      hostInfo.PlatformVersion,
+		Uptime:               hostInfo.Uptime,
+		KernelVersion:        hostInfo.KernelVersion,
+		KernelArch:           hostInfo.KernelArch,
+		BootTime:             hostInfo.BootTime,
+		VirtualizationSystem: hostInfo.VirtualizationSystem,
+		VirtualizationRole:   hostInfo.VirtualizationRole,
+		HostId:               hostInfo.HostID,
+		Procs:                hostInfo.Procs,
+		Users:                usersPb,
+		Timezone:             hostInfo.Timezone,
 	}, nil
 }
 
 func (s *Server) GetNetInfo(ctx context.Context, req *pb.NetInfoReq) (*pb.NetInfoRes, error) {
-	netInterfaces, err := s.metrigo.GetNetInterfaces()
-	if err != nil {
+	if err := s.checkEnabled(metrigo.CollectorNet); err != nil {
 		return nil, err
 	}
 
+	netInterfaces, err := s.metrigo.GetNetInterfaces(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
 	netInterfacesPb := make([]*pb.NetInterface, len(netInterfaces))
 	for i, iface := range netInterfaces {
 		netInterfacesPb[i] = &pb.NetInterface{
@@ -99,3 +292,461 @@ func (s *Server) GetNetInfo(ctx context.Context, req *pb.NetInfoReq) (*pb.NetInf
 		Interfaces: netInterfacesPb,
 	}, nil
 }
+
+func (s *Server) GetContainers(ctx context.Context, req *pb.ContainersReq) (*pb.ContainersRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorContainers); err != nil {
+		return nil, err
+	}
+
+	containers, err := s.metrigo.GetContainers(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	containersPb := make([]*pb.Container, len(containers))
+	for i, container := range containers {
+		containersPb[i] = &pb.Container{
+			Id:           container.ID,
+			Name:         container.Name,
+			Image:        container.Image,
+			State:        container.State,
+			Labels:       container.Labels,
+			CpuPercent:   container.CpuPercent,
+			MemoryUsageB: container.MemoryUsageB,
+			MemoryLimitB: container.MemoryLimitB,
+			NetRxB:       container.NetRxB,
+			NetTxB:       container.NetTxB,
+			BlockReadB:   container.BlockReadB,
+			BlockWriteB:  container.BlockWriteB,
+		}
+	}
+
+	return &pb.ContainersRes{Containers: containersPb}, nil
+}
+
+func (s *Server) GetHwmonSensors(ctx context.Context, req *pb.HwmonSensorsReq) (*pb.HwmonSensorsRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorHwmon); err != nil {
+		return nil, err
+	}
+
+	sensors, err := s.metrigo.GetHwmonSensors(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	sensorsPb := make([]*pb.HwmonSensor, len(sensors))
+	for i, sensor := range sensors {
+		sensorsPb[i] = &pb.HwmonSensor{
+			Chip:   sensor.Chip,
+			Sensor: sensor.Sensor,
+			Label:  sensor.Label,
+			Type:   sensor.Type,
+			Unit:   sensor.Unit,
+			Value:  sensor.Value,
+			Min:    sensor.Min,
+			Max:    sensor.Max,
+			Crit:   sensor.Crit,
+			Alarm:  sensor.Alarm,
+		}
+	}
+
+	return &pb.HwmonSensorsRes{Sensors: sensorsPb}, nil
+}
+
+func (s *Server) GetPressure(ctx context.Context, req *pb.PressureReq) (*pb.PressureRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorPsi); err != nil {
+		return nil, err
+	}
+
+	pressure, err := s.metrigo.GetPressure(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	scopesPb := make([]*pb.PressureScope, len(pressure))
+	for i, scope := range pressure {
+		resourcesPb := make([]*pb.ResourcePressure, len(scope.Resources))
+		for j, resource := range scope.Resources {
+			resourcesPb[j] = &pb.ResourcePressure{
+				Resource: resource.Resource,
+				Some:     pressureStallPb(resource.Some),
+			}
+			if resource.HasFull {
+				resourcesPb[j].Full = pressureStallPb(resource.Full)
+			}
+		}
+		scopesPb[i] = &pb.PressureScope{Cgroup: scope.Cgroup, Resources: resourcesPb}
+	}
+	return &pb.PressureRes{Scopes: scopesPb}, nil
+}
+
+func pressureStallPb(stall models.PressureStall) *pb.PressureStall {
+	return &pb.PressureStall{
+		Avg10:        stall.Avg10,
+		Avg60:        stall.Avg60,
+		Avg300:       stall.Avg300,
+		TotalSeconds: stall.TotalSeconds,
+	}
+}
+
+func (s *Server) GetSocketStats(ctx context.Context, req *pb.SocketStatsReq) (*pb.SocketStatsRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorConns); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.metrigo.GetSocketStats(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	connectionsPb := make([]*pb.Connection, len(stats.Connections))
+	for i, conn := range stats.Connections {
+		connectionsPb[i] = &pb.Connection{
+			Protocol:   conn.Protocol,
+			LocalAddr:  conn.LocalAddr,
+			LocalPort:  conn.LocalPort,
+			RemoteAddr: conn.RemoteAddr,
+			RemotePort: conn.RemotePort,
+			State:      conn.State,
+			Pid:        conn.Pid,
+		}
+	}
+	res := &pb.SocketStatsRes{Connections: connectionsPb}
+	if stats.HasCounters {
+		c := stats.Counters
+		res.Counters = &pb.ProtocolCounters{
+			TcpActiveOpens:     c.TcpActiveOpens,
+			TcpPassiveOpens:    c.TcpPassiveOpens,
+			TcpAttemptFails:    c.TcpAttemptFails,
+			TcpEstabResets:     c.TcpEstabResets,
+			TcpCurrEstab:       c.TcpCurrEstab,
+			TcpInSegs:          c.TcpInSegs,
+			TcpOutSegs:         c.TcpOutSegs,
+			TcpRetransSegs:     c.TcpRetransSegs,
+			TcpInErrs:          c.TcpInErrs,
+			TcpOutRsts:         c.TcpOutRsts,
+			TcpListenOverflows: c.TcpListenOverflows,
+			TcpListenDrops:     c.TcpListenDrops,
+			UdpInDatagrams:     c.UdpInDatagrams,
+			UdpOutDatagrams:    c.UdpOutDatagrams,
+			UdpNoPorts:         c.UdpNoPorts,
+			UdpInErrors:        c.UdpInErrors,
+			UdpRcvbufErrors:    c.UdpRcvbufErrors,
+			UdpSndbufErrors:    c.UdpSndbufErrors,
+		}
+	}
+	return res, nil
+}
+
+func (s *Server) GetSystemdUnits(ctx context.Context, req *pb.SystemdUnitsReq) (*pb.SystemdUnitsRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorSystemd); err != nil {
+		return nil, err
+	}
+
+	units, err := s.metrigo.GetSystemdUnits(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+	if req.Pattern != "" {
+		units, err = metrigo.FilterSystemdUnits(units, req.Pattern)
+		if err != nil {
+			return nil, statusError(codes.InvalidArgument, reasonInvalidPattern, metrigo.CollectorSystemd, err.Error())
+		}
+	}
+
+	unitsPb := make([]*pb.SystemdUnit, len(units))
+	for i, unit := range units {
+		unitsPb[i] = &pb.SystemdUnit{
+			Name:          unit.Name,
+			Description:   unit.Description,
+			LoadState:     unit.LoadState,
+			ActiveState:   unit.ActiveState,
+			SubState:      unit.SubState,
+			UnitFileState: unit.UnitFileState,
+			Restarts:      unit.Restarts,
+			MemoryB:       unit.MemoryB,
+			CpuSeconds:    unit.CpuSeconds,
+		}
+	}
+	return &pb.SystemdUnitsRes{Units: unitsPb}, nil
+}
+
+func (s *Server) GetKernelLimits(ctx context.Context, req *pb.KernelLimitsReq) (*pb.KernelLimitsRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorLimits); err != nil {
+		return nil, err
+	}
+
+	limits, err := s.metrigo.GetKernelLimits(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	processes := limits.Processes
+	if req.TopProcesses > 0 {
+		processes = processes[:min(len(processes), int(req.TopProcesses))]
+	}
+	processesPb := make([]*pb.ProcessFds, len(processes))
+	for i, process := range processes {
+		processesPb[i] = &pb.ProcessFds{
+			Pid:       process.Pid,
+			Name:      process.Name,
+			OpenFds:   process.OpenFds,
+			SoftLimit: process.SoftLimit,
+			HardLimit: process.HardLimit,
+		}
+	}
+	return &pb.KernelLimitsRes{
+		OpenFiles:        limits.OpenFiles,
+		MaxFiles:         limits.MaxFiles,
+		Inodes:           limits.Inodes,
+		FreeInodes:       limits.FreeInodes,
+		HasConntrack:     limits.HasConntrack,
+		ConntrackEntries: limits.ConntrackEntries,
+		ConntrackMax:     limits.ConntrackMax,
+		Pids:             limits.Pids,
+		PidMax:           limits.PidMax,
+		ThreadsMax:       limits.ThreadsMax,
+		EntropyAvailable: limits.EntropyAvailable,
+		EntropyPoolSize:  limits.EntropyPoolSize,
+		Processes:        processesPb,
+	}, nil
+}
+
+func (s *Server) GetProcessGroups(ctx context.Context, req *pb.ProcessGroupsReq) (*pb.ProcessGroupsRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorProcGroups); err != nil {
+		return nil, err
+	}
+
+	groups, err := s.metrigo.GetProcessGroups(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	var groupsPb []*pb.ProcessGroup
+	for _, group := range groups {
+		if req.Group != "" && group.Name != req.Group {
+			continue
+		}
+		groupsPb = append(groupsPb, &pb.ProcessGroup{
+			Name:       group.Name,
+			Pids:       group.Pids,
+			CpuPercent: group.CpuPercent,
+			RssB:       group.RssB,
+			ReadBps:    group.ReadBps,
+			WriteBps:   group.WriteBps,
+			Threads:    group.Threads,
+			OpenFds:    group.OpenFds,
+		})
+	}
+	return &pb.ProcessGroupsRes{Groups: groupsPb}, nil
+}
+
+func (s *Server) GetMemoryDetails(ctx context.Context, req *pb.MemoryDetailsReq) (*pb.MemoryDetailsRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorMemInfo); err != nil {
+		return nil, err
+	}
+
+	details, err := s.metrigo.GetMemoryDetails(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+
+	nodesPb := make([]*pb.NumaNode, len(details.NumaNodes))
+	for i, node := range details.NumaNodes {
+		nodesPb[i] = &pb.NumaNode{
+			Id:        int32(node.ID),
+			TotalB:    node.TotalB,
+			FreeB:     node.FreeB,
+			HugePages: hugePagePoolsPb(node.HugePages),
+		}
+	}
+	return &pb.MemoryDetailsRes{
+		TotalB:                      details.TotalB,
+		FreeB:                       details.FreeB,
+		AvailableB:                  details.AvailableB,
+		BuffersB:                    details.BuffersB,
+		CachedB:                     details.CachedB,
+		SwapTotalB:                  details.SwapTotalB,
+		SwapFreeB:                   details.SwapFreeB,
+		DirtyB:                      details.DirtyB,
+		WritebackB:                  details.WritebackB,
+		SlabB:                       details.SlabB,
+		SlabReclaimableB:            details.SlabReclaimableB,
+		SlabUnreclaimableB:          details.SlabUnreclaimableB,
+		CommittedAsB:                details.CommittedAsB,
+		CommitLimitB:                details.CommitLimitB,
+		AnonHugePagesB:              details.AnonHugePagesB,
+		HugePages:                   hugePagePoolsPb(details.HugePages),
+		TransparentHugePagesEnabled: details.TransparentHugePages.Enabled,
+		TransparentHugePagesDefrag:  details.TransparentHugePages.Defrag,
+		NumaNodes:                   nodesPb,
+	}, nil
+}
+
+func hugePagePoolsPb(pools []models.HugePagePool) []*pb.HugePagePool {
+	poolsPb := make([]*pb.HugePagePool, len(pools))
+	for i, pool := range pools {
+		poolsPb[i] = &pb.HugePagePool{
+			SizeB:    pool.SizeB,
+			Total:    pool.Total,
+			Free:     pool.Free,
+			Reserved: pool.Reserved,
+			Surplus:  pool.Surplus,
+		}
+	}
+	return poolsPb
+}
+
+func (s *Server) GetMounts(ctx context.Context, req *pb.MountsReq) (*pb.MountsRes, error) {
+	if err := s.checkEnabled(metrigo.CollectorMounts); err != nil {
+		return nil, err
+	}
+
+	mounts, err := s.metrigo.GetMounts(ctx)
+	if err != nil {
+		return nil, collectionError(err)
+	}
+	if req.UnhealthyOnly {
+		mounts = metrigo.UnhealthyMounts(mounts)
+	}
+
+	mountsPb := make([]*pb.Mount, len(mounts))
+	for i, mount := range mounts {
+		mountsPb[i] = &pb.Mount{
+			Path:              mount.Path,
+			Device:            mount.Device,
+			Fstype:            mount.Fstype,
+			Options:           mount.Options,
+			ReadOnly:          mount.ReadOnly,
+			Stale:             mount.Stale,
+			Health:            mount.Health,
+			TotalB:            mount.TotalB,
+			UsedB:             mount.UsedB,
+			FreeB:             mount.FreeB,
+			UsedPercent:       mount.UsedPercent,
+			Inodes:            mount.Inodes,
+			InodesFree:        mount.InodesFree,
+			InodesUsedPercent: mount.InodesUsedPercent,
+			FstabWritable:     mount.FstabWritable,
+		}
+	}
+	return &pb.MountsRes{Mounts: mountsPb}, nil
+}
+
+// WatchProcesses streams the process events until the client cancels. Events are dropped
+// while the client does not keep up with them.
+func (s *Server) WatchProcesses(req *pb.WatchProcessesReq, stream pb.Metrigo_WatchProcessesServer) error {
+	for event := range s.processEvents.Subscribe(stream.Context()) {
+		if len(req.Processes) > 0 && !slices.Contains(req.Processes, event.Process) {
+			continue
+		}
+		err := stream.Send(&pb.ProcessEvent{
+			Process:     event.Process,
+			Type:        event.Type,
+			Pid:         event.Pid,
+			PreviousPid: event.PreviousPid,
+			Restarts:    int32(event.Restarts),
+			Message:     event.Message,
+			Time:        unixOrZero(event.Time),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) GetStatus(ctx context.Context, req *pb.StatusReq) (*pb.StatusRes, error) {
+	agentStatus := s.statusProvider.Status()
+	return &pb.StatusRes{
+		Version:         agentStatus.Version,
+		ConfigPath:      agentStatus.ConfigPath,
+		StartedAt:       unixOrZero(agentStatus.StartedAt),
+		ConfigLoadedAt:  unixOrZero(agentStatus.ConfigLoadedAt),
+		LastReloadAt:    unixOrZero(agentStatus.LastReloadAt),
+		LastReloadError: agentStatus.LastReloadError,
+		ReloadCount:     agentStatus.ReloadCount,
+	}, nil
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func (s *Server) ListCollectors(ctx context.Context, req *pb.ListCollectorsReq) (*pb.ListCollectorsRes, error) {
+	collectors := s.registry.Collectors()
+	collectorsPb := make([]*pb.CollectorInfo, len(collectors))
+	for i, c := range collectors {
+		descriptors := c.Descriptors()
+		metricsPb := make([]*pb.MetricDescriptor, len(descriptors))
+		for j, descriptor := range descriptors {
+			metricsPb[j] = &pb.MetricDescriptor{
+				Name:   descriptor.Name,
+				Help:   descriptor.Help,
+				Unit:   descriptor.Unit,
+				Type:   metricTypePb(descriptor.Type),
+				Labels: descriptor.Labels,
+			}
+		}
+		collectorsPb[i] = &pb.CollectorInfo{
+			Name:        c.Name(),
+			Description: c.Description(),
+			Metrics:     metricsPb,
+			Enabled:     s.isEnabled(c.Name()),
+		}
+	}
+	return &pb.ListCollectorsRes{Collectors: collectorsPb}, nil
+}
+
+// Collect runs the requested collectors. A failing collector is reported in its result instead of failing the request.
+func (s *Server) Collect(ctx context.Context, req *pb.CollectReq) (*pb.CollectRes, error) {
+	var collectors []collector.Collector
+	if len(req.Collectors) == 0 {
+		for _, c := range s.registry.Collectors() {
+			if s.isEnabled(c.Name()) {
+				collectors = append(collectors, c)
+			}
+		}
+	} else {
+		for _, name := range req.Collectors {
+			c, ok := s.registry.Get(name)
+			if !ok {
+				return nil, statusError(codes.NotFound, reasonUnknownCollector, name, fmt.Sprintf("unknown collector %s", name))
+			}
+			if err := s.checkEnabled(name); err != nil {
+				return nil, err
+			}
+			collectors = append(collectors, c)
+		}
+	}
+
+	results := make([]*pb.CollectorSamples, len(collectors))
+	for i, c := range collectors {
+		result := &pb.CollectorSamples{Collector: c.Name()}
+		samples, err := c.Collect(ctx)
+		if err != nil {
+			result.Error = err.Error()
+			result.Reason = errorReason(err)
+		}
+		result.Samples = make([]*pb.Sample, len(samples))
+		for j, sample := range samples {
+			result.Samples[j] = &pb.Sample{
+				Metric: sample.Metric,
+				Labels: sample.Labels,
+				Value:  sample.Value,
+			}
+		}
+		results[i] = result
+	}
+	return &pb.CollectRes{Results: results}, nil
+}
+
+func metricTypePb(metricType collector.MetricType) pb.MetricType {
+	if metricType == collector.Counter {
+		return pb.MetricType_METRIC_TYPE_COUNTER
+	}
+	return pb.MetricType_METRIC_TYPE_GAUGE
+}
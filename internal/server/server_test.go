@@ -0,0 +1,34 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Matyjash/Metrigo/internal/collector"
+	"github.com/Matyjash/Metrigo/internal/config"
+	"github.com/Matyjash/Metrigo/internal/metrigo"
+	pb "github.com/Matyjash/Metrigo/pb"
+)
+
+func Test_CollectRegisteredCollector(t *testing.T) {
+	m := metrigo.NewMetrigo()
+	registry := metrigo.NewRegistry(&m)
+	registry.MustRegister(collector.New("queue", "Length of the work queue",
+		[]collector.Descriptor{{Name: "queue_length", Help: "Number of queued jobs."}},
+		func(ctx context.Context) ([]collector.Sample, error) {
+			return []collector.Sample{{Metric: "queue_length", Labels: map[string]string{"queue": "mail"}, Value: 3}}, nil
+		}))
+	s := NewServer(&m, registry, config.CollectorsConfig{}, nil, nil)
+
+	res, err := s.Collect(context.Background(), &pb.CollectReq{Collectors: []string{"queue"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Results) != 1 || res.Results[0].Collector != "queue" || res.Results[0].Error != "" {
+		t.Fatalf("expected the samples of the queue collector, got %+v", res.Results)
+	}
+	samples := res.Results[0].Samples
+	if len(samples) != 1 || samples[0].Metric != "queue_length" || samples[0].Labels["queue"] != "mail" || samples[0].Value != 3 {
+		t.Errorf("expected queue_length{queue=mail} 3, got %+v", samples)
+	}
+}
@@ -0,0 +1,292 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/alert"
+	"github.com/Matyjash/Metrigo/internal/check"
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+// Types of the process events.
+const (
+	EventStarted   = "started"
+	EventStopped   = "stopped"
+	EventRestarted = "restarted"
+	// EventFlapping is emitted when a process restarts more than MaxRestarts times within RestartWindow.
+	EventFlapping = "flapping"
+)
+
+// Family is the family of the alert events emitted for process events.
+const Family = "process"
+
+// subscriberBuffer is the number of events kept for a subscriber that does not keep up, newer ones are dropped.
+const subscriberBuffer = 16
+
+// Process is a watched process. The oldest process matching Spec is watched, e.g. the master process
+// of a daemon with worker processes.
+type Process struct {
+	Spec          models.ProcessGroupSpec
+	MaxRestarts   int
+	RestartWindow time.Duration
+}
+
+// Source is the subset of metrigo.Metrigo used by the watcher.
+type Source interface {
+	FindProcesses(ctx context.Context, specs []models.ProcessGroupSpec) ([][]models.ProcessStats, error)
+}
+
+type state struct {
+	seen      bool
+	running   bool
+	pid       int32
+	startTime uint64
+	restarts  []time.Time
+	flapping  bool
+	status    check.Status
+}
+
+// Watcher reports the starts, stops and restarts of the watched processes to the alert notifiers
+// and to its subscribers.
+type Watcher struct {
+	source Source
+
+	mu          sync.Mutex
+	processes   []Process
+	notifiers   []alert.Notifier
+	states      map[string]*state
+	subscribers map[chan models.ProcessEvent]struct{}
+}
+
+func NewWatcher(source Source, processes []Process, notifiers ...alert.Notifier) *Watcher {
+	return &Watcher{
+		source:      source,
+		processes:   processes,
+		notifiers:   notifiers,
+		states:      map[string]*state{},
+		subscribers: map[chan models.ProcessEvent]struct{}{},
+	}
+}
+
+// Update replaces the watched processes and the notifiers. The state of processes that are kept is preserved.
+func (w *Watcher) Update(processes []Process, notifiers []alert.Notifier) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	states := map[string]*state{}
+	for _, process := range processes {
+		if state, ok := w.states[process.Spec.Name]; ok {
+			states[process.Spec.Name] = state
+		}
+	}
+	w.processes = processes
+	w.notifiers = notifiers
+	w.states = states
+}
+
+// Subscribe returns a channel receiving the events until ctx is done, when it is closed.
+func (w *Watcher) Subscribe(ctx context.Context) <-chan models.ProcessEvent {
+	events := make(chan models.ProcessEvent, subscriberBuffer)
+	w.mu.Lock()
+	w.subscribers[events] = struct{}{}
+	w.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subscribers, events)
+		close(events)
+	}()
+	return events
+}
+
+// Poll looks the watched processes up once and emits the events of the ones that changed.
+// A process that is not running when it is first looked up is reported as stopped.
+// The lookup and the notifiers run without the lock, so a slow host or webhook does not
+// block Update and Subscribe.
+func (w *Watcher) Poll(ctx context.Context) []models.ProcessEvent {
+	w.mu.Lock()
+	processes := w.processes
+	w.mu.Unlock()
+	if len(processes) == 0 {
+		return nil
+	}
+
+	specs := make([]models.ProcessGroupSpec, len(processes))
+	for i, process := range processes {
+		specs[i] = process.Spec
+	}
+	matched, err := w.source.FindProcesses(ctx, specs)
+	if err != nil {
+		log.Printf("failed to look up watched processes: %v", err)
+		return nil
+	}
+
+	now := time.Now()
+	var events []models.ProcessEvent
+	var alertEvents []alert.Event
+	w.mu.Lock()
+	watched := map[string]bool{}
+	for _, process := range w.processes {
+		watched[process.Spec.Name] = true
+	}
+	for i, process := range processes {
+		// Processes removed by an Update during the lookup are dropped.
+		if !watched[process.Spec.Name] {
+			continue
+		}
+		st, ok := w.states[process.Spec.Name]
+		if !ok {
+			st = &state{}
+			w.states[process.Spec.Name] = st
+		}
+		for _, event := range st.update(process, oldest(matched[i]), now) {
+			events = append(events, event)
+			alertEvents = append(alertEvents, w.publish(event, st))
+		}
+	}
+	notifiers := w.notifiers
+	w.mu.Unlock()
+
+	for _, event := range alertEvents {
+		for _, notifier := range notifiers {
+			if err := notifier.Notify(ctx, event); err != nil {
+				log.Printf("failed to notify about process %s: %v", event.Rule, err)
+			}
+		}
+	}
+	return events
+}
+
+// Run polls the watched processes every interval until the context is cancelled.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Poll(ctx)
+		}
+	}
+}
+
+// publish delivers the event to the subscribers and returns the alert event for the notifiers,
+// moving the alert status of the state. It must be called with w.mu held.
+func (w *Watcher) publish(event models.ProcessEvent, st *state) alert.Event {
+	for subscriber := range w.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+
+	status := eventStatus(event.Type)
+	alertEvent := alert.Event{
+		Rule:     event.Process,
+		Family:   Family,
+		Status:   status,
+		Previous: st.status,
+		Message:  event.Message,
+		Time:     event.Time,
+	}
+	st.status = status
+	return alertEvent
+}
+
+// update moves the state to the process found, nil when none is running, and returns the resulting events.
+func (st *state) update(process Process, found *models.ProcessStats, now time.Time) []models.ProcessEvent {
+	name := process.Spec.Name
+	event := models.ProcessEvent{Process: name, Time: now}
+	defer func() { st.seen = true }()
+
+	switch {
+	case found == nil && (st.running || !st.seen):
+		event.Type, event.PreviousPid = EventStopped, st.pid
+		event.Message = fmt.Sprintf("process %s is not running", name)
+		if st.running {
+			event.Message = fmt.Sprintf("process %s (pid %d) stopped", name, st.pid)
+		}
+		st.running = false
+		return []models.ProcessEvent{event}
+	case found == nil:
+		return nil
+	case !st.running:
+		event.Type, event.Pid, event.PreviousPid = EventStarted, found.Pid, st.pid
+		event.Message = fmt.Sprintf("process %s started with pid %d", name, found.Pid)
+		restarted := st.pid != 0
+		st.running, st.pid, st.startTime = true, found.Pid, found.StartTime
+		if !st.seen {
+			// A process running when the watch starts is not reported.
+			return nil
+		}
+		if !restarted {
+			return []models.ProcessEvent{event}
+		}
+	case found.Pid != st.pid || found.StartTime != st.startTime:
+		event.Type, event.Pid, event.PreviousPid = EventRestarted, found.Pid, st.pid
+		event.Message = fmt.Sprintf("process %s restarted, pid %d -> %d", name, st.pid, found.Pid)
+		st.pid, st.startTime = found.Pid, found.StartTime
+	default:
+		st.pruneRestarts(process, now)
+		return nil
+	}
+
+	// A process starting again after it stopped restarted as well.
+	st.restarts = append(st.restarts, now)
+	st.pruneRestarts(process, now)
+	event.Restarts = len(st.restarts)
+	events := []models.ProcessEvent{event}
+	if process.MaxRestarts > 0 && len(st.restarts) > process.MaxRestarts && !st.flapping {
+		st.flapping = true
+		flapping := event
+		flapping.Type = EventFlapping
+		flapping.Message = fmt.Sprintf("process %s restarted %d times in %v, more than %d", name, len(st.restarts), process.RestartWindow, process.MaxRestarts)
+		events = append(events, flapping)
+	}
+	return events
+}
+
+// pruneRestarts forgets the restarts older than the restart window. The process stops flapping
+// once its restarts within the window are back to MaxRestarts.
+func (st *state) pruneRestarts(process Process, now time.Time) {
+	kept := st.restarts[:0]
+	for _, restart := range st.restarts {
+		if process.RestartWindow > 0 && now.Sub(restart) < process.RestartWindow {
+			kept = append(kept, restart)
+		}
+	}
+	st.restarts = kept
+	if len(st.restarts) <= process.MaxRestarts {
+		st.flapping = false
+	}
+}
+
+// oldest returns the process started first, nil when there are none.
+func oldest(processes []models.ProcessStats) *models.ProcessStats {
+	var found *models.ProcessStats
+	for i, process := range processes {
+		if found == nil || process.StartTime < found.StartTime {
+			found = &processes[i]
+		}
+	}
+	return found
+}
+
+// eventStatus maps the event types to the statuses reported in alert events.
+func eventStatus(eventType string) check.Status {
+	switch eventType {
+	case EventStopped, EventFlapping:
+		return check.StatusCritical
+	case EventRestarted:
+		return check.StatusWarning
+	default:
+		return check.StatusOK
+	}
+}
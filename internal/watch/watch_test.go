@@ -0,0 +1,177 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Matyjash/Metrigo/internal/alert"
+	"github.com/Matyjash/Metrigo/internal/check"
+	"github.com/Matyjash/Metrigo/internal/models"
+)
+
+type mockSource struct {
+	processes []models.ProcessStats
+	err       error
+}
+
+func (m *mockSource) FindProcesses(ctx context.Context, specs []models.ProcessGroupSpec) ([][]models.ProcessStats, error) {
+	matched := make([][]models.ProcessStats, len(specs))
+	for i := range specs {
+		matched[i] = m.processes
+	}
+	return matched, m.err
+}
+
+type recordingNotifier struct {
+	events []alert.Event
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, event alert.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func Test_WatcherPoll(t *testing.T) {
+	master := func(pid int32, startTime uint64) []models.ProcessStats {
+		// The workers are started after the master and are not watched.
+		return []models.ProcessStats{{Pid: pid + 1, StartTime: startTime + 1}, {Pid: pid, StartTime: startTime}}
+	}
+	tests := []struct {
+		name      string
+		process   Process
+		steps     [][]models.ProcessStats
+		wantTypes [][]string
+	}{
+		{
+			name:      "running process is not reported until it changes",
+			process:   Process{Spec: models.ProcessGroupSpec{Name: "nginx"}},
+			steps:     [][]models.ProcessStats{master(10, 100), master(10, 100), master(20, 200), nil, nil, master(30, 300)},
+			wantTypes: [][]string{nil, nil, {EventRestarted}, {EventStopped}, nil, {EventStarted}},
+		},
+		{
+			name:      "process missing when the watch starts",
+			process:   Process{Spec: models.ProcessGroupSpec{Name: "nginx"}},
+			steps:     [][]models.ProcessStats{nil, nil, master(10, 100)},
+			wantTypes: [][]string{{EventStopped}, nil, {EventStarted}},
+		},
+		{
+			name:      "reused pid of a new process is a restart",
+			process:   Process{Spec: models.ProcessGroupSpec{Name: "nginx"}},
+			steps:     [][]models.ProcessStats{master(10, 100), master(10, 500)},
+			wantTypes: [][]string{nil, {EventRestarted}},
+		},
+		{
+			name:      "too many restarts within the window",
+			process:   Process{Spec: models.ProcessGroupSpec{Name: "nginx"}, MaxRestarts: 2, RestartWindow: time.Hour},
+			steps:     [][]models.ProcessStats{master(10, 100), master(20, 200), nil, master(30, 300), master(40, 400)},
+			wantTypes: [][]string{nil, {EventRestarted}, {EventStopped}, {EventStarted}, {EventRestarted, EventFlapping}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &mockSource{}
+			watcher := NewWatcher(source, []Process{tt.process})
+			for i, processes := range tt.steps {
+				source.processes = processes
+				var types []string
+				for _, event := range watcher.Poll(context.Background()) {
+					types = append(types, event.Type)
+				}
+				if !reflect.DeepEqual(types, tt.wantTypes[i]) {
+					t.Errorf("step %d: expected events %v, got %v", i, tt.wantTypes[i], types)
+				}
+			}
+		})
+	}
+}
+
+func Test_WatcherNotifiesAndStreams(t *testing.T) {
+	source := &mockSource{processes: []models.ProcessStats{{Pid: 10, StartTime: 100}}}
+	notifier := &recordingNotifier{}
+	watcher := NewWatcher(source, []Process{{Spec: models.ProcessGroupSpec{Name: "sshd"}, MaxRestarts: 1, RestartWindow: time.Hour}}, notifier)
+	ctx, cancel := context.WithCancel(context.Background())
+	events := watcher.Subscribe(ctx)
+
+	watcher.Poll(context.Background())
+	source.processes = []models.ProcessStats{{Pid: 20, StartTime: 200}}
+	watcher.Poll(context.Background())
+	source.processes = []models.ProcessStats{{Pid: 30, StartTime: 300}}
+	watcher.Poll(context.Background())
+	source.processes = nil
+	watcher.Poll(context.Background())
+	source.err = errors.New("permission denied")
+	if events := watcher.Poll(context.Background()); events != nil {
+		t.Errorf("expected no events on lookup errors, got %v", events)
+	}
+
+	wantAlerts := []struct {
+		status, previous check.Status
+	}{
+		{check.StatusWarning, check.StatusOK},
+		{check.StatusWarning, check.StatusWarning},
+		{check.StatusCritical, check.StatusWarning},
+		{check.StatusCritical, check.StatusCritical},
+	}
+	if len(notifier.events) != len(wantAlerts) {
+		t.Fatalf("expected %d alert events, got %d: %v", len(wantAlerts), len(notifier.events), notifier.events)
+	}
+	for i, want := range wantAlerts {
+		event := notifier.events[i]
+		if event.Rule != "sshd" || event.Family != Family || event.Status != want.status || event.Previous != want.previous {
+			t.Errorf("alert event %d: expected sshd %v -> %v, got %+v", i, want.previous, want.status, event)
+		}
+	}
+	if want := "process sshd restarted, pid 20 -> 30"; notifier.events[1].Message != want {
+		t.Errorf("expected message %q, got %q", want, notifier.events[1].Message)
+	}
+
+	cancel()
+	var streamed []string
+	for event := range events {
+		streamed = append(streamed, event.Type)
+	}
+	if want := []string{EventRestarted, EventRestarted, EventFlapping, EventStopped}; !reflect.DeepEqual(streamed, want) {
+		t.Errorf("expected streamed events %v, got %v", want, streamed)
+	}
+}
+
+// blockingNotifier blocks in Notify until release is closed.
+type blockingNotifier struct {
+	notified chan struct{}
+	release  chan struct{}
+}
+
+func (b *blockingNotifier) Notify(ctx context.Context, event alert.Event) error {
+	close(b.notified)
+	<-b.release
+	return nil
+}
+
+func Test_WatcherPollDoesNotHoldLockWhileNotifying(t *testing.T) {
+	source := &mockSource{}
+	notifier := &blockingNotifier{notified: make(chan struct{}), release: make(chan struct{})}
+	process := Process{Spec: models.ProcessGroupSpec{Name: "nginx"}}
+	watcher := NewWatcher(source, []Process{process}, notifier)
+
+	done := make(chan []models.ProcessEvent)
+	go func() { done <- watcher.Poll(context.Background()) }()
+	<-notifier.notified
+
+	updated := make(chan struct{})
+	go func() {
+		watcher.Update([]Process{process}, nil)
+		close(updated)
+	}()
+	select {
+	case <-updated:
+	case <-time.After(time.Second):
+		t.Fatal("Update blocked while a notifier was running")
+	}
+	close(notifier.release)
+	if events := <-done; len(events) != 1 || events[0].Type != EventStopped {
+		t.Errorf("expected a stopped event, got %v", events)
+	}
+}